@@ -117,6 +117,24 @@ func (a *account) SignGenericMulti(ctx context.Context, accounts []e2wtypes.Acco
 	return sigs, nil
 }
 
+// SignGenericMultiResults signs multiple generic data roots, returning the
+// result for each.
+func (a *account) SignGenericMultiResults(ctx context.Context,
+	accounts []e2wtypes.Account,
+	data [][]byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	res, err := a.SignMultiResultsGRPC(ctx, accounts, data, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 // SignBeaconProposal signs a beacon proposal with protection.
 func (a *account) SignBeaconProposal(ctx context.Context,
 	slot uint64,
@@ -145,6 +163,10 @@ func (a *account) SignBeaconAttestation(ctx context.Context,
 	targetRoot []byte,
 	domain []byte,
 ) (e2types.Signature, error) {
+	if a.wallet.attestationCoalescer != nil {
+		return a.wallet.attestationCoalescer.sign(ctx, a, slot, committeeIndex, blockRoot, sourceEpoch, sourceRoot, targetEpoch, targetRoot, domain)
+	}
+
 	sig, err := a.SignBeaconAttestationGRPC(ctx, slot, committeeIndex, blockRoot, sourceEpoch, sourceRoot, targetEpoch, targetRoot, domain)
 	if err != nil {
 		return nil, err
@@ -172,3 +194,124 @@ func (a *account) SignBeaconAttestations(ctx context.Context,
 
 	return sigs, nil
 }
+
+// SignBeaconAttestationsResults signs multiple beacon attestations with
+// protection, returning the result for each.
+func (a *account) SignBeaconAttestationsResults(ctx context.Context,
+	slot uint64,
+	accounts []e2wtypes.Account,
+	committeeIndices []uint64,
+	blockRoot []byte,
+	sourceEpoch uint64,
+	sourceRoot []byte,
+	targetEpoch uint64,
+	targetRoot []byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	res, err := a.SignBeaconAttestationsResultsGRPC(ctx, slot, accounts, committeeIndices, blockRoot, sourceEpoch, sourceRoot, targetEpoch, targetRoot, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// SignSyncCommitteeMessage signs a sync committee message for a beacon block root.
+func (a *account) SignSyncCommitteeMessage(ctx context.Context,
+	beaconBlockRoot []byte,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signSyncCommitteeMessage(ctx, a, beaconBlockRoot, forkVersion, genesisValidatorsRoot)
+}
+
+// SignSyncCommitteeSelectionProof signs a proof of selection as a sync committee aggregator.
+func (a *account) SignSyncCommitteeSelectionProof(ctx context.Context,
+	slot uint64,
+	subcommitteeIndex uint64,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signSyncCommitteeSelectionProof(ctx, a, slot, subcommitteeIndex, forkVersion, genesisValidatorsRoot)
+}
+
+// SignContributionAndProof signs a sync committee contribution and proof.
+func (a *account) SignContributionAndProof(ctx context.Context,
+	contributionAndProof *ContributionAndProof,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signContributionAndProof(ctx, a, contributionAndProof, forkVersion, genesisValidatorsRoot)
+}
+
+// SignSelectionProof signs a proof of selection as an attestation aggregator.
+func (a *account) SignSelectionProof(ctx context.Context,
+	slot uint64,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signSelectionProof(ctx, a, slot, forkVersion, genesisValidatorsRoot)
+}
+
+// SignAggregateAndProof signs an aggregate and proof.
+func (a *account) SignAggregateAndProof(ctx context.Context,
+	aggregateAndProof *AggregateAndProof,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signAggregateAndProof(ctx, a, aggregateAndProof, forkVersion, genesisValidatorsRoot)
+}
+
+// SignRANDAOReveal signs a RANDAO reveal for an epoch.
+func (a *account) SignRANDAOReveal(ctx context.Context,
+	epoch uint64,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signRANDAOReveal(ctx, a, epoch, forkVersion, genesisValidatorsRoot)
+}
+
+// SignVoluntaryExit signs a voluntary exit.
+func (a *account) SignVoluntaryExit(ctx context.Context,
+	epoch uint64,
+	validatorIndex uint64,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signVoluntaryExit(ctx, a, epoch, validatorIndex, forkVersion, genesisValidatorsRoot)
+}
+
+// SignValidatorRegistration signs a validator registration.
+func (a *account) SignValidatorRegistration(ctx context.Context,
+	registration *ValidatorRegistration,
+	genesisForkVersion []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signValidatorRegistration(ctx, a, registration, genesisForkVersion)
+}
@@ -0,0 +1,413 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// accountChangesBuffer is the number of account changes buffered for each subscriber.
+const accountChangesBuffer = 64
+
+// accountCacheRetryDelay is the longest time for which stale accounts are
+// not refreshed again after a refresh fails.
+const accountCacheRetryDelay = 5 * time.Second
+
+// AccountChangeType is the type of a change to the accounts of a wallet.
+type AccountChangeType int
+
+const (
+	// AccountAdded is the type of change when an account is added to the wallet.
+	AccountAdded AccountChangeType = iota + 1
+	// AccountRemoved is the type of change when an account is removed from the wallet.
+	AccountRemoved
+	// AccountReplaced is the type of change when an account is replaced by
+	// a different account with the same name.
+	AccountReplaced
+)
+
+// String implements the stringer interface.
+func (t AccountChangeType) String() string {
+	switch t {
+	case AccountAdded:
+		return "added"
+	case AccountRemoved:
+		return "removed"
+	case AccountReplaced:
+		return "replaced"
+	default:
+		return "invalid"
+	}
+}
+
+// AccountChange is a change to the accounts of a wallet.
+type AccountChange struct {
+	// Type is the type of the change.
+	Type AccountChangeType
+	// Account is the account that was added or removed, or the account
+	// that replaced the previous account with its name.
+	Account e2wtypes.Account
+}
+
+// WalletAccountCache is the interface for wallets that cache their accounts.
+type WalletAccountCache interface {
+	// Refresh obtains the accounts of the wallet from Dirk, replacing those
+	// in the cache.  If some accounts are malformed the valid accounts are
+	// cached and an *AccountListError is returned.
+	Refresh(ctx context.Context) error
+
+	// AccountChanges provides the changes to the accounts of the wallet
+	// found by refreshes, until the context is done or the wallet is
+	// closed.  Changes are not sent for the first population of the cache,
+	// and are dropped if the channel is full.
+	AccountChanges(ctx context.Context) <-chan *AccountChange
+}
+
+// accountCache caches the accounts of a wallet.  Accounts are obtained
+// again in the background when they are older than the TTL, and
+// periodically if a refresh interval is set; stale accounts are used until
+// they are replaced, so that lookups do not wait on Dirk and a brief outage
+// of Dirk does not prevent accounts from being found.
+type accountCache struct {
+	log             zerolog.Logger
+	ttl             time.Duration
+	refreshInterval time.Duration
+	fetch           func(ctx context.Context) ([]e2wtypes.Account, error)
+
+	// refreshMu serialises refreshes.
+	refreshMu sync.Mutex
+
+	mu        sync.RWMutex
+	populated bool
+	updated   time.Time
+	accounts  []e2wtypes.Account
+	byName    map[string]e2wtypes.Account
+	// listErr reports the accounts that were malformed when the cache was last refreshed.
+	listErr error
+	// refreshing is set while stale accounts are refreshed in the background.
+	refreshing bool
+	// retryAt is the earliest time at which stale accounts are refreshed
+	// again after a failed refresh.
+	retryAt time.Time
+	stopped bool
+
+	// subscribers is nil once the cache is stopped.
+	subscribersMu sync.Mutex
+	subscribers   map[chan *AccountChange]struct{}
+
+	// ctx is the context for background refreshes, canceled when the cache is stopped.
+	ctx        context.Context
+	cancel     context.CancelFunc
+	background sync.WaitGroup
+}
+
+// newAccountCache creates a new account cache.
+func newAccountCache(log zerolog.Logger,
+	ttl time.Duration,
+	refreshInterval time.Duration,
+	fetch func(ctx context.Context) ([]e2wtypes.Account, error),
+) *accountCache {
+	return &accountCache{
+		log:             log,
+		ttl:             ttl,
+		refreshInterval: refreshInterval,
+		fetch:           fetch,
+		byName:          make(map[string]e2wtypes.Account),
+		subscribers:     make(map[chan *AccountChange]struct{}),
+	}
+}
+
+// start starts the cache, refreshing it periodically in the background if
+// a refresh interval is set.
+func (c *accountCache) start(ctx context.Context) {
+	c.ctx, c.cancel = context.WithCancel(ctx)
+	if c.refreshInterval == 0 {
+		return
+	}
+	c.background.Add(1)
+	go func() {
+		defer c.background.Done()
+		ticker := time.NewTicker(c.refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.refresh(c.ctx, true); err != nil && c.ctx.Err() == nil {
+					c.log.Warn().Err(err).Msg("Failed to refresh accounts")
+				}
+			}
+		}
+	}()
+}
+
+// stop stops refreshing, and closes the channels of all subscribers.
+func (c *accountCache) stop() {
+	c.mu.Lock()
+	c.stopped = true
+	c.mu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.background.Wait()
+
+	c.subscribersMu.Lock()
+	for ch := range c.subscribers {
+		close(ch)
+	}
+	c.subscribers = nil
+	c.subscribersMu.Unlock()
+}
+
+// fresh returns true if the cached accounts are within their TTL.
+func (c *accountCache) fresh() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.populated && time.Since(c.updated) < c.ttl
+}
+
+// invalidate marks the cached accounts as requiring a refresh.  The accounts
+// remain available in case the refresh fails.
+func (c *accountCache) invalidate() {
+	c.mu.Lock()
+	c.updated = time.Time{}
+	c.mu.Unlock()
+}
+
+// refresh obtains the accounts and replaces those in the cache.  Unless
+// forced, accounts are only obtained if the cached accounts are not fresh.
+// If some accounts are malformed the valid accounts are cached and an
+// *AccountListError is returned.
+func (c *accountCache) refresh(ctx context.Context, force bool) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	if !force && c.fresh() {
+		// Refreshed while waiting for the lock.
+		return nil
+	}
+
+	accounts, err := c.fetch(ctx)
+	if err != nil && !isAccountListError(err) {
+		return err
+	}
+	c.replace(accounts, err)
+
+	return err
+}
+
+// replace replaces the cached accounts, notifying subscribers of changes.
+func (c *accountCache) replace(accounts []e2wtypes.Account, listErr error) {
+	byName := make(map[string]e2wtypes.Account, len(accounts))
+	for _, account := range accounts {
+		byName[account.Name()] = account
+	}
+
+	c.mu.Lock()
+	var changes []*AccountChange
+	if c.populated {
+		changes = accountChanges(c.accounts, c.byName, accounts, byName)
+	}
+	c.populated = true
+	c.updated = time.Now()
+	c.accounts = accounts
+	c.byName = byName
+	c.listErr = listErr
+	c.mu.Unlock()
+
+	for _, change := range changes {
+		c.notify(change)
+	}
+}
+
+// accountChanges returns the changes between two sets of accounts.
+func accountChanges(previous []e2wtypes.Account,
+	previousByName map[string]e2wtypes.Account,
+	current []e2wtypes.Account,
+	currentByName map[string]e2wtypes.Account,
+) []*AccountChange {
+	changes := make([]*AccountChange, 0)
+	for _, account := range previous {
+		if _, exists := currentByName[account.Name()]; !exists {
+			changes = append(changes, &AccountChange{Type: AccountRemoved, Account: account})
+		}
+	}
+	for _, account := range current {
+		previousAccount, exists := previousByName[account.Name()]
+		switch {
+		case !exists:
+			changes = append(changes, &AccountChange{Type: AccountAdded, Account: account})
+		case previousAccount != account:
+			// Accounts are shared by public key, so a different account has a different key.
+			changes = append(changes, &AccountChange{Type: AccountReplaced, Account: account})
+		}
+	}
+
+	return changes
+}
+
+// current ensures that the cache is populated.  Only an empty cache is
+// refreshed before returning; stale accounts are refreshed in the
+// background, so lookups are served from the cache while Dirk is slow or
+// unreachable.
+func (c *accountCache) current(ctx context.Context) error {
+	c.mu.RLock()
+	populated := c.populated
+	c.mu.RUnlock()
+	if populated {
+		if !c.fresh() {
+			c.refreshInBackground()
+		}
+
+		return nil
+	}
+
+	if err := c.refresh(ctx, false); err != nil && !isAccountListError(err) {
+		return err
+	}
+
+	return nil
+}
+
+// refreshInBackground refreshes stale accounts in the background, unless a
+// refresh is already in progress or a refresh failed recently.
+func (c *accountCache) refreshInBackground() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stopped || c.refreshing || time.Now().Before(c.retryAt) {
+		return
+	}
+	c.refreshing = true
+
+	c.background.Add(1)
+	go func() {
+		defer c.background.Done()
+		err := c.refresh(c.ctx, false)
+		failed := err != nil && !isAccountListError(err)
+
+		c.mu.Lock()
+		c.refreshing = false
+		if failed {
+			c.retryAt = time.Now().Add(min(c.ttl, accountCacheRetryDelay))
+		}
+		c.mu.Unlock()
+
+		if failed && c.ctx.Err() == nil {
+			c.log.Warn().Err(err).Msg("Failed to refresh accounts; using cached accounts")
+		}
+	}()
+}
+
+// account returns the cached account with the given name, if present.
+func (c *accountCache) account(ctx context.Context, name string) (e2wtypes.Account, bool, error) {
+	if err := c.current(ctx); err != nil {
+		return nil, false, err
+	}
+
+	c.mu.RLock()
+	account, exists := c.byName[name]
+	c.mu.RUnlock()
+
+	return account, exists, nil
+}
+
+// all returns all cached accounts.  If some accounts were malformed when
+// the cache was last refreshed an *AccountListError is returned with them.
+func (c *accountCache) all(ctx context.Context) ([]e2wtypes.Account, error) {
+	if err := c.current(ctx); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	accounts := c.accounts
+	listErr := c.listErr
+	c.mu.RUnlock()
+
+	return accounts, listErr
+}
+
+// subscribe returns a channel that receives account changes until the
+// context is done or the cache is stopped.  If the cache is already stopped
+// the channel is closed immediately.
+func (c *accountCache) subscribe(ctx context.Context) <-chan *AccountChange {
+	ch := make(chan *AccountChange, accountChangesBuffer)
+	c.subscribersMu.Lock()
+	if c.subscribers == nil {
+		c.subscribersMu.Unlock()
+		close(ch)
+
+		return ch
+	}
+	c.subscribers[ch] = struct{}{}
+	c.subscribersMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		c.subscribersMu.Lock()
+		if _, exists := c.subscribers[ch]; exists {
+			delete(c.subscribers, ch)
+			close(ch)
+		}
+		c.subscribersMu.Unlock()
+	}()
+
+	return ch
+}
+
+// notify sends an account change to all subscribers.
+func (c *accountCache) notify(change *AccountChange) {
+	c.subscribersMu.Lock()
+	defer c.subscribersMu.Unlock()
+
+	for ch := range c.subscribers {
+		select {
+		case ch <- change:
+		default:
+			c.log.Warn().Str("account", change.Account.Name()).Stringer("change", change.Type).Msg("Subscriber full; dropped account change")
+		}
+	}
+}
+
+// Refresh obtains the accounts of the wallet from Dirk, replacing those in
+// the cache.  If some accounts are malformed the valid accounts are cached
+// and an *AccountListError is returned.
+func (w *wallet) Refresh(ctx context.Context) error {
+	if w.accountCache == nil {
+		return errors.New("account cache not enabled")
+	}
+
+	return w.accountCache.refresh(ctx, true)
+}
+
+// AccountChanges provides the changes to the accounts of the wallet found by
+// refreshes, until the context is done or the wallet is closed.  If the
+// account cache is not enabled the channel is closed immediately.
+func (w *wallet) AccountChanges(ctx context.Context) <-chan *AccountChange {
+	if w.accountCache == nil {
+		ch := make(chan *AccountChange)
+		close(ch)
+
+		return ch
+	}
+
+	return w.accountCache.subscribe(ctx)
+}
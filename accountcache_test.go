@@ -0,0 +1,288 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	mock "github.com/wealdtech/go-eth2-wallet-dirk/mock"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc/credentials"
+)
+
+// nextAccountChange returns the next account change, failing if there is none.
+func nextAccountChange(t *testing.T, changes <-chan *dirk.AccountChange) *dirk.AccountChange {
+	t.Helper()
+
+	select {
+	case change := <-changes:
+		require.NotNil(t, change)
+
+		return change
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no account change")
+	}
+
+	return nil
+}
+
+func TestAccountCacheParameters(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []dirk.Parameter
+		err    string
+	}{
+		{
+			name:   "TTLInvalid",
+			params: []dirk.Parameter{dirk.WithAccountCacheTTL(-1)},
+			err:    "problem with parameters: invalid account cache TTL specified",
+		},
+		{
+			name:   "RefreshIntervalInvalid",
+			params: []dirk.Parameter{dirk.WithAccountCacheTTL(time.Minute), dirk.WithAccountCacheRefreshInterval(-1)},
+			err:    "problem with parameters: invalid account cache refresh interval specified",
+		},
+		{
+			name:   "TTLMissing",
+			params: []dirk.Parameter{dirk.WithAccountCacheRefreshInterval(time.Minute)},
+			err:    "problem with parameters: no account cache TTL specified",
+		},
+		{
+			name:   "Good",
+			params: []dirk.Parameter{dirk.WithAccountCacheTTL(time.Minute), dirk.WithAccountCacheRefreshInterval(time.Minute)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wallet, err := dirk.Open(ctx, append([]dirk.Parameter{
+				dirk.WithName("Test wallet"),
+				dirk.WithEndpoints([]*dirk.Endpoint{dirk.NewEndpoint("localhost", 12345)}),
+				dirk.WithCredentials(credentials.NewTLS(nil)),
+			}, test.params...)...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.NoError(t, wallet.(dirk.WalletCloser).Close(ctx))
+			}
+		})
+	}
+}
+
+func TestAccountCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster := newCluster(t, 1)
+	_, err := cluster.AddAccount("Wallet", "Account 1", []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddAccount("Wallet", "Account 2", []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithAccountCacheTTL(time.Hour))
+	changes := wallet.(dirk.WalletAccountCache).AccountChanges(ctx)
+
+	// Lookups after the first are served from the cache.
+	account1, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+	_, err = wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 2")
+	require.NoError(t, err)
+	accounts := 0
+	for range wallet.Accounts(ctx) {
+		accounts++
+	}
+	require.Equal(t, 2, accounts)
+	require.Equal(t, uint64(1), requests(cluster, "ListAccounts"))
+
+	// Accounts not in the cache are looked up in Dirk.
+	_, err = cluster.AddAccount("Wallet", "Account 3", []byte("pass"))
+	require.NoError(t, err)
+	account3, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 3")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), requests(cluster, "ListAccounts"))
+
+	// Refreshing notifies of changes.
+	require.NoError(t, cluster.RemoveAccount("Wallet", "Account 1"))
+	require.NoError(t, wallet.(dirk.WalletAccountCache).Refresh(ctx))
+	change := nextAccountChange(t, changes)
+	require.Equal(t, dirk.AccountRemoved, change.Type)
+	require.Equal(t, account1, change.Account)
+	change = nextAccountChange(t, changes)
+	require.Equal(t, dirk.AccountAdded, change.Type)
+	require.Equal(t, account3, change.Account)
+
+	_, err = wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 3")
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), requests(cluster, "ListAccounts"))
+
+	// Replacing an account with a different key is a change.
+	require.NoError(t, cluster.RemoveAccount("Wallet", "Account 2"))
+	_, err = cluster.AddAccount("Wallet", "Account 2", []byte("pass"))
+	require.NoError(t, err)
+	require.NoError(t, wallet.(dirk.WalletAccountCache).Refresh(ctx))
+	change = nextAccountChange(t, changes)
+	require.Equal(t, dirk.AccountReplaced, change.Type)
+	require.Equal(t, "Account 2", change.Account.Name())
+
+	// Closing the wallet closes the channel, and those of later subscribers.
+	require.NoError(t, wallet.(dirk.WalletCloser).Close(ctx))
+	_, open := <-changes
+	require.False(t, open)
+	_, open = <-wallet.(dirk.WalletAccountCache).AccountChanges(ctx)
+	require.False(t, open)
+}
+
+func TestAccountCacheOutage(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 1)
+	_, err := cluster.AddAccount("Wallet", "Account", []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet",
+		dirk.WithAccountCacheTTL(10*time.Millisecond),
+		dirk.WithTimeout(time.Second),
+	)
+
+	// No cached accounts to fall back on.
+	cluster.Server(1).SetDown(true)
+	_, err = wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.ErrorContains(t, err, "failed to obtain account")
+
+	cluster.Server(1).SetDown(false)
+	_, err = wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+
+	// The cached accounts are used once they have expired if Dirk is down,
+	// and are refreshed in the background.
+	cluster.Server(1).SetDown(true)
+	time.Sleep(20 * time.Millisecond)
+	listed := requests(cluster, "ListAccounts")
+	_, err = wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return requests(cluster, "ListAccounts") > listed
+	}, time.Second, time.Millisecond)
+	require.Error(t, wallet.(dirk.WalletAccountCache).Refresh(ctx))
+}
+
+func TestAccountCacheSlowDirk(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 1)
+	_, err := cluster.AddAccount("Wallet", "Account", []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet",
+		dirk.WithAccountCacheTTL(10*time.Millisecond),
+		dirk.WithTimeout(2*time.Second),
+	)
+	defer func() {
+		require.NoError(t, wallet.(dirk.WalletCloser).Close(ctx))
+	}()
+	_, err = wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+
+	faultInjector := mock.NewFaultInjector(1)
+	faultInjector.Add(&mock.Fault{Method: "ListAccounts", Latency: time.Minute})
+	cluster.SetFaultInjector(faultInjector)
+	time.Sleep(20 * time.Millisecond)
+
+	// Concurrent lookups of expired accounts do not wait on Dirk.
+	started := time.Now()
+	errs := make([]error, 8)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+		}(i)
+	}
+	wg.Wait()
+	require.Less(t, time.Since(started), time.Second)
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+}
+
+func TestAccountCacheBackgroundRefresh(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster := newCluster(t, 1)
+	_, err := cluster.AddAccount("Wallet", "Account 1", []byte("pass"))
+	require.NoError(t, err)
+	// Refreshing continues after the context used to open the wallet is done.
+	openCtx, openCancel := context.WithCancel(ctx)
+	wallet := openClusterWallet(openCtx, t, cluster, "Wallet",
+		dirk.WithAccountCacheTTL(time.Hour),
+		dirk.WithAccountCacheRefreshInterval(10*time.Millisecond),
+	)
+	openCancel()
+	defer func() {
+		require.NoError(t, wallet.(dirk.WalletCloser).Close(ctx))
+	}()
+	changes := wallet.(dirk.WalletAccountCache).AccountChanges(ctx)
+
+	_, err = wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+
+	_, err = cluster.AddAccount("Wallet", "Account 2", []byte("pass"))
+	require.NoError(t, err)
+	change := nextAccountChange(t, changes)
+	require.Equal(t, dirk.AccountAdded, change.Type)
+	require.Equal(t, "Account 2", change.Account.Name())
+}
+
+func TestAccountCacheDisabled(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 1)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet")
+
+	require.EqualError(t, wallet.(dirk.WalletAccountCache).Refresh(ctx), "account cache not enabled")
+	_, open := <-wallet.(dirk.WalletAccountCache).AccountChanges(ctx)
+	require.False(t, open)
+}
+
+func TestAccountCacheLookups(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	pubKey, err := cluster.AddAccount("Wallet", "Account 1", []byte("pass"))
+	require.NoError(t, err)
+	compositePubKey, err := cluster.AddDistributedAccount("Wallet", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithAccountCacheTTL(time.Hour))
+
+	account1, err := wallet.(dirk.WalletAccountByPublicKeyProvider).AccountByPublicKey(ctx, pubKey)
+	require.NoError(t, err)
+	require.Equal(t, "Account 1", account1.Name())
+	account2, err := wallet.(dirk.WalletAccountByPublicKeyProvider).AccountByPublicKey(ctx, compositePubKey)
+	require.NoError(t, err)
+	require.Equal(t, "Account 2", account2.Name())
+	account, err := wallet.(e2wtypes.WalletAccountByIDProvider).AccountByID(ctx, account1.ID())
+	require.NoError(t, err)
+	require.Equal(t, account1, account)
+	// A single listing, along with a request to each participant for its public key share.
+	require.Equal(t, uint64(4), requests(cluster, "ListAccounts"))
+
+	// Accounts not in the cache are looked up in Dirk.
+	pubKey, err = cluster.AddAccount("Wallet", "Account 3", []byte("pass"))
+	require.NoError(t, err)
+	account, err = wallet.(dirk.WalletAccountByPublicKeyProvider).AccountByPublicKey(ctx, pubKey)
+	require.NoError(t, err)
+	require.Equal(t, "Account 3", account.Name())
+	require.Equal(t, uint64(5), requests(cluster, "ListAccounts"))
+}
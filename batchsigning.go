@@ -0,0 +1,357 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// WalletBatchSigner is the interface for wallets that sign batches of data
+// for any mix of their accounts, both distributed and not.
+type WalletBatchSigner interface {
+	// SignGenericBatch signs multiple generic data.
+	SignGenericBatch(ctx context.Context,
+		accounts []e2wtypes.Account,
+		data [][]byte,
+		domain []byte,
+	) (
+		*MultiSignResult,
+		error,
+	)
+
+	// SignBeaconAttestationsBatch signs multiple beacon attestations.
+	SignBeaconAttestationsBatch(ctx context.Context,
+		slot uint64,
+		accounts []e2wtypes.Account,
+		committeeIndices []uint64,
+		blockRoot []byte,
+		sourceEpoch uint64,
+		sourceRoot []byte,
+		targetEpoch uint64,
+		targetRoot []byte,
+		domain []byte,
+	) (
+		*MultiSignResult,
+		error,
+	)
+}
+
+// SignGenericBatch signs multiple generic data roots with the wallet's
+// accounts, which can be any mix of distributed and non-distributed
+// accounts.  Accounts are grouped by the endpoints that hold them, and the
+// groups are signed concurrently.  Results are returned in the order of the
+// accounts; if a group cannot be signed at all then the reason is returned
+// for each of its items.
+func (w *wallet) SignGenericBatch(ctx context.Context,
+	accounts []e2wtypes.Account,
+	data [][]byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	return signGenericBatch(ctx, w, accounts, data, domain)
+}
+
+// SignBeaconAttestationsBatch signs multiple beacon attestations with the
+// wallet's accounts, which can be any mix of distributed and non-distributed
+// accounts.  Accounts are grouped by the endpoints that hold them, and the
+// groups are signed concurrently.  Results are returned in the order of the
+// accounts; if a group cannot be signed at all then the reason is returned
+// for each of its items.
+func (w *wallet) SignBeaconAttestationsBatch(ctx context.Context,
+	slot uint64,
+	accounts []e2wtypes.Account,
+	committeeIndices []uint64,
+	blockRoot []byte,
+	sourceEpoch uint64,
+	sourceRoot []byte,
+	targetEpoch uint64,
+	targetRoot []byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	return signBeaconAttestationsBatch(ctx, w, slot, accounts, committeeIndices, blockRoot, sourceEpoch, sourceRoot, targetEpoch, targetRoot, domain)
+}
+
+// BatchSignGeneric signs multiple generic data roots with accounts from any
+// number of Dirk wallets.  Accounts held by the same endpoints are signed
+// with a single multiple signing request to each endpoint, and the groups are
+// signed concurrently.  Accounts in different wallets are only grouped
+// together if their wallets share connections, by being opened with the same
+// credentials and connection pool settings, and have the same slashing
+// protection, timeouts and duty deadlines.  Results are returned in
+// the order of the accounts; if a group cannot be signed at all then the
+// reason is returned for each of its items.
+func BatchSignGeneric(ctx context.Context,
+	accounts []e2wtypes.Account,
+	data [][]byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	return signGenericBatch(ctx, nil, accounts, data, domain)
+}
+
+// BatchSignBeaconAttestations signs multiple beacon attestations with
+// accounts from any number of Dirk wallets.  Accounts are grouped as for
+// BatchSignGeneric.
+func BatchSignBeaconAttestations(ctx context.Context,
+	slot uint64,
+	accounts []e2wtypes.Account,
+	committeeIndices []uint64,
+	blockRoot []byte,
+	sourceEpoch uint64,
+	sourceRoot []byte,
+	targetEpoch uint64,
+	targetRoot []byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	return signBeaconAttestationsBatch(ctx, nil, slot, accounts, committeeIndices, blockRoot, sourceEpoch, sourceRoot, targetEpoch, targetRoot, domain)
+}
+
+// signGenericBatch signs multiple generic data roots.  If a wallet is
+// supplied then all accounts must be in it.
+func signGenericBatch(ctx context.Context,
+	w *wallet,
+	accounts []e2wtypes.Account,
+	data [][]byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	if len(accounts) != len(data) {
+		return nil, errors.New("number of accounts does not match number of data")
+	}
+	for i := range data {
+		if len(data[i]) != 32 {
+			return nil, errors.New("data must be 32 bytes in length")
+		}
+	}
+
+	return signBatch(ctx, w, accounts, func(ctx context.Context, signer AccountMultiSignResultsProvider, indices []int) (*MultiSignResult, error) {
+		groupAccounts := make([]e2wtypes.Account, len(indices))
+		groupData := make([][]byte, len(indices))
+		for i, index := range indices {
+			groupAccounts[i] = accounts[index]
+			groupData[i] = data[index]
+		}
+
+		return signer.SignGenericMultiResults(ctx, groupAccounts, groupData, domain)
+	})
+}
+
+// signBeaconAttestationsBatch signs multiple beacon attestations.  If a
+// wallet is supplied then all accounts must be in it.
+func signBeaconAttestationsBatch(ctx context.Context,
+	w *wallet,
+	slot uint64,
+	accounts []e2wtypes.Account,
+	committeeIndices []uint64,
+	blockRoot []byte,
+	sourceEpoch uint64,
+	sourceRoot []byte,
+	targetEpoch uint64,
+	targetRoot []byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	if len(accounts) != len(committeeIndices) {
+		return nil, errors.New("number of accounts does not match number of committee indices")
+	}
+
+	return signBatch(ctx, w, accounts, func(ctx context.Context, signer AccountMultiSignResultsProvider, indices []int) (*MultiSignResult, error) {
+		groupAccounts := make([]e2wtypes.Account, len(indices))
+		groupCommitteeIndices := make([]uint64, len(indices))
+		for i, index := range indices {
+			groupAccounts[i] = accounts[index]
+			groupCommitteeIndices[i] = committeeIndices[index]
+		}
+
+		return signer.SignBeaconAttestationsResults(ctx, slot, groupAccounts, groupCommitteeIndices, blockRoot, sourceEpoch, sourceRoot, targetEpoch, targetRoot, domain)
+	})
+}
+
+// batchSigner signs the items at the given indices of a batch, using the
+// signer of the group to which they belong.
+type batchSigner func(ctx context.Context, signer AccountMultiSignResultsProvider, indices []int) (*MultiSignResult, error)
+
+// signBatch splits a batch in to groups that can be signed with a single
+// request, signs the groups concurrently and combines the results.
+func signBatch(ctx context.Context, w *wallet, accounts []e2wtypes.Account, sign batchSigner) (*MultiSignResult, error) {
+	groups, err := batchGroups(w, accounts)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &MultiSignResult{
+		Items: make([]*MultiSignItemResult, len(accounts)),
+	}
+	var wg sync.WaitGroup
+	for _, indices := range groups {
+		wg.Add(1)
+		go func(indices []int) {
+			defer wg.Done()
+			groupRes, err := sign(ctx, accounts[indices[0]].(AccountMultiSignResultsProvider), indices)
+			for i, index := range indices {
+				if err != nil {
+					res.Items[index] = &MultiSignItemResult{Err: err}
+
+					continue
+				}
+				res.Items[index] = groupRes.Items[i]
+			}
+		}(indices)
+	}
+	wg.Wait()
+
+	return res, nil
+}
+
+// batchGroups groups the indices of the accounts of a batch so that each
+// group can be signed with a single request: non-distributed accounts by the
+// endpoints of their wallets, and distributed accounts by their participants.
+// A group is signed with the wallet of its first account, so accounts are
+// only grouped if their wallets sign in the same way.  If a wallet is
+// supplied then all accounts must be in it.
+func batchGroups(w *wallet, accounts []e2wtypes.Account) ([][]int, error) {
+	keys := make([]batchGroupKey, 0)
+	groups := make(map[batchGroupKey][]int)
+	for i, acc := range accounts {
+		var key batchGroupKey
+		switch typedAccount := acc.(type) {
+		case *account:
+			if w != nil && typedAccount.wallet != w {
+				return nil, fmt.Errorf("account %s is not in wallet %s", acc.Name(), w.Name())
+			}
+			key = batchGroupKey{
+				wallet: walletKey(typedAccount.wallet),
+				group:  "endpoints:" + endpointsKey(typedAccount.wallet.endpoints),
+			}
+		case *distributedAccount:
+			if w != nil && typedAccount.wallet != w {
+				return nil, fmt.Errorf("account %s is not in wallet %s", acc.Name(), w.Name())
+			}
+			key = batchGroupKey{
+				wallet: walletKey(typedAccount.wallet),
+				group:  "distributed:" + participantsKey(typedAccount.participants),
+			}
+		default:
+			return nil, errors.New("account not of required type")
+		}
+		if _, exists := groups[key]; !exists {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	res := make([][]int, len(keys))
+	for i, key := range keys {
+		res[i] = groups[key]
+	}
+
+	return res, nil
+}
+
+// batchGroupKey identifies a group of accounts of a batch that can be signed
+// with a single request.
+type batchGroupKey struct {
+	wallet signingWalletKey
+	group  string
+}
+
+// signingWalletKey is the same for wallets that sign in the same way: with
+// the same connections and credentials, slashing protection, timeouts and
+// duty deadlines.
+type signingWalletKey struct {
+	connections               any
+	slashingProtection        *SlashingProtection
+	timeout                   time.Duration
+	hedgeDelay                time.Duration
+	verifyCompositeSignatures bool
+	genesisTime               int64
+	slotDuration              time.Duration
+}
+
+// walletKey returns the signing key of a wallet.  Wallets opened with the
+// same credentials and pool settings share their connections, so are keyed
+// on those rather than on their connection providers.
+func walletKey(w *wallet) signingWalletKey {
+	var connections any = w
+	switch provider := w.connectionProvider.(type) {
+	case *PuddleConnectionProvider:
+		connections = provider.poolKey("")
+	default:
+		if reflect.TypeOf(provider).Comparable() {
+			connections = provider
+		}
+	}
+
+	return signingWalletKey{
+		connections:               connections,
+		slashingProtection:        w.slashingProtection,
+		timeout:                   w.timeout,
+		hedgeDelay:                w.hedgeDelay,
+		verifyCompositeSignatures: w.verifyCompositeSignatures,
+		genesisTime:               w.genesisTime.Unix(),
+		slotDuration:              w.slotDuration,
+	}
+}
+
+// participantsKey returns a key that is the same for distributed accounts
+// with the same participants.
+func participantsKey(participants map[uint64]*Endpoint) string {
+	ids := make([]uint64, 0, len(participants))
+	for id := range participants {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = fmt.Sprintf("%d@%s", id, participants[id])
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// endpointsKey returns a key that is the same for wallets with the same
+// endpoints, regardless of their order.
+func endpointsKey(endpoints []*Endpoint) string {
+	parts := make([]string, len(endpoints))
+	for i, endpoint := range endpoints {
+		parts[i] = endpoint.String()
+	}
+	sort.Strings(parts)
+
+	return strings.Join(parts, ",")
+}
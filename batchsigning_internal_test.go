@@ -0,0 +1,70 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestBatchGroupsOpenedWallets(t *testing.T) {
+	require.NoError(t, e2types.InitBLS())
+	ctx := context.Background()
+	creds := credentials.NewTLS(nil)
+	endpoints := []*Endpoint{
+		{host: "localhost", port: 12345},
+		{host: "localhost", port: 12346},
+	}
+	open := func(name string, creds credentials.TransportCredentials, params ...Parameter) *wallet {
+		w, err := Open(ctx, append([]Parameter{
+			WithName(name),
+			WithEndpoints(endpoints),
+			WithCredentials(creds),
+		}, params...)...)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, w.(*wallet).Close(ctx)) })
+
+		return w.(*wallet)
+	}
+	account := func(w *wallet) e2wtypes.Account {
+		key, err := e2types.GenerateBLSPrivateKey()
+		require.NoError(t, err)
+
+		return newAccount(w, uuid.New(), "Account", key.PublicKey(), 4)
+	}
+
+	wallet1 := open("Wallet 1", creds)
+	wallet2 := open("Wallet 2", creds)
+	// Different credentials do not share connections.
+	wallet3 := open("Wallet 3", credentials.NewTLS(nil))
+	// Different timeouts do not sign in the same way.
+	wallet4 := open("Wallet 4", creds, WithTimeout(time.Minute))
+
+	groups, err := batchGroups(nil, []e2wtypes.Account{
+		account(wallet1),
+		account(wallet2),
+		account(wallet3),
+		account(wallet4),
+		account(wallet1),
+	})
+	require.NoError(t, err)
+	require.Equal(t, [][]int{{0, 1, 4}, {2}, {3}}, groups)
+}
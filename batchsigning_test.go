@@ -0,0 +1,254 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// verificationKey returns the key that verifies signatures for an account.
+func verificationKey(account e2wtypes.Account) e2types.PublicKey {
+	if provider, isProvider := account.(e2wtypes.AccountCompositePublicKeyProvider); isProvider {
+		return provider.CompositePublicKey()
+	}
+
+	return account.PublicKey()
+}
+
+func TestSignGenericBatch(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddAccount("Wallet", "Account 1", []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddAccount("Wallet", "Account 3", []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 4", 2, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet")
+
+	accounts := make([]e2wtypes.Account, 4)
+	for i, name := range []string{"Account 2", "Account 1", "Account 4", "Account 3"} {
+		accounts[i], err = wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, name)
+		require.NoError(t, err)
+	}
+	data := [][]byte{
+		bytes.Repeat([]byte{0x01}, 32),
+		bytes.Repeat([]byte{0x02}, 32),
+		bytes.Repeat([]byte{0x03}, 32),
+		bytes.Repeat([]byte{0x04}, 32),
+	}
+	domain := bytes.Repeat([]byte{0x05}, 32)
+
+	res, err := wallet.(dirk.WalletBatchSigner).SignGenericBatch(ctx, accounts, data, domain)
+	require.NoError(t, err)
+	require.Equal(t, dirk.MultiSignStatusSucceeded, res.Status())
+	require.Len(t, res.Items, len(accounts))
+	for i, item := range res.Items {
+		require.NoError(t, item.Err)
+		require.True(t, item.Signature.Verify(genericSigningRoot(data[i], domain), verificationKey(accounts[i])))
+	}
+	// One request for the non-distributed accounts, and one to each participant of the distributed accounts.
+	require.LessOrEqual(t, requests(cluster, "Multisign"), uint64(1+3+2))
+
+	// Requests for the attester domain are denied.
+	res, err = wallet.(dirk.WalletBatchSigner).SignGenericBatch(ctx, accounts, data, append([]byte{0x01, 0x00, 0x00, 0x00}, bytes.Repeat([]byte{0x02}, 28)...))
+	require.NoError(t, err)
+	require.Equal(t, dirk.MultiSignStatusFailed, res.Status())
+	for _, item := range res.Items {
+		require.Nil(t, item.Signature)
+		require.ErrorIs(t, item.Err, dirk.ErrDenied)
+	}
+
+	// Mismatched inputs.
+	_, err = wallet.(dirk.WalletBatchSigner).SignGenericBatch(ctx, accounts, data[1:], domain)
+	require.EqualError(t, err, "number of accounts does not match number of data")
+}
+
+func TestSignGenericBatchOtherWallet(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 1)
+	_, err := cluster.AddAccount("Wallet 1", "Account", []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddAccount("Wallet 2", "Account", []byte("pass"))
+	require.NoError(t, err)
+	wallet1 := openClusterWallet(ctx, t, cluster, "Wallet 1")
+	wallet2 := openClusterWallet(ctx, t, cluster, "Wallet 2")
+	account1, err := wallet1.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+	account2, err := wallet2.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+
+	_, err = wallet1.(dirk.WalletBatchSigner).SignGenericBatch(ctx,
+		[]e2wtypes.Account{account1, account2},
+		[][]byte{bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x02}, 32)},
+		bytes.Repeat([]byte{0x03}, 32),
+	)
+	require.EqualError(t, err, "account Account is not in wallet Wallet 1")
+}
+
+func TestSignBeaconAttestationsBatch(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddAccount("Wallet", "Account 1", []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet")
+	account1, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+	account2, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 2")
+	require.NoError(t, err)
+
+	signAttestations := func(accounts []e2wtypes.Account) *dirk.MultiSignResult {
+		res, err := wallet.(dirk.WalletBatchSigner).SignBeaconAttestationsBatch(ctx,
+			32,
+			accounts,
+			make([]uint64, len(accounts)),
+			bytes.Repeat([]byte{0x01}, 32),
+			0,
+			bytes.Repeat([]byte{0x02}, 32),
+			1,
+			bytes.Repeat([]byte{0x03}, 32),
+			bytes.Repeat([]byte{0x04}, 32),
+		)
+		require.NoError(t, err)
+
+		return res
+	}
+
+	res := signAttestations([]e2wtypes.Account{account2})
+	require.Equal(t, dirk.MultiSignStatusSucceeded, res.Status())
+
+	// The distributed account has already signed for the target epoch, but the other has not.
+	res = signAttestations([]e2wtypes.Account{account1, account2})
+	require.Equal(t, dirk.MultiSignStatusPartial, res.Status())
+	require.NotNil(t, res.Items[0].Signature)
+	require.NoError(t, res.Items[0].Err)
+	require.Nil(t, res.Items[1].Signature)
+	require.ErrorIs(t, res.Items[1].Err, dirk.ErrDenied)
+	require.ErrorIs(t, res.Items[1].Err, dirk.ErrInsufficientSignatures)
+
+	// Both have now signed for the target epoch.
+	res = signAttestations([]e2wtypes.Account{account1, account2})
+	require.Equal(t, dirk.MultiSignStatusFailed, res.Status())
+	require.EqualError(t, res.Items[0].Err, "request to obtain signatures denied")
+}
+
+func TestBatchSignGenericAcrossWallets(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddAccount("Wallet 1", "Account 1", []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet 1", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddAccount("Wallet 2", "Account 1", []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet 2", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet1 := openClusterWallet(ctx, t, cluster, "Wallet 1")
+	wallet2 := openClusterWallet(ctx, t, cluster, "Wallet 2")
+	// Wallets are only grouped together if they share connections; cluster
+	// wallets do so through a single connection provider.
+	connectionProvider := &clusterConnectionProvider{cluster: cluster}
+	for _, wallet := range []e2wtypes.Wallet{wallet1, wallet2} {
+		wallet.(interface {
+			SetConnectionProvider(connectionProvider dirk.ConnectionProvider)
+		}).SetConnectionProvider(connectionProvider)
+	}
+
+	accounts := make([]e2wtypes.Account, 0)
+	for _, wallet := range []e2wtypes.Wallet{wallet1, wallet2} {
+		for _, name := range []string{"Account 1", "Account 2"} {
+			account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, name)
+			require.NoError(t, err)
+			accounts = append(accounts, account)
+		}
+	}
+	data := [][]byte{
+		bytes.Repeat([]byte{0x01}, 32),
+		bytes.Repeat([]byte{0x02}, 32),
+		bytes.Repeat([]byte{0x03}, 32),
+		bytes.Repeat([]byte{0x04}, 32),
+	}
+	domain := bytes.Repeat([]byte{0x05}, 32)
+
+	res, err := dirk.BatchSignGeneric(ctx, accounts, data, domain)
+	require.NoError(t, err)
+	require.Equal(t, dirk.MultiSignStatusSucceeded, res.Status())
+	require.Len(t, res.Items, len(accounts))
+	for i, item := range res.Items {
+		require.NoError(t, item.Err)
+		require.True(t, item.Signature.Verify(genericSigningRoot(data[i], domain), verificationKey(accounts[i])))
+	}
+	// One request for the non-distributed accounts of both wallets, and one to
+	// each participant of the distributed accounts of both wallets.
+	require.LessOrEqual(t, requests(cluster, "Multisign"), uint64(1+3))
+
+	// Accounts must be from Dirk wallets.
+	_, err = dirk.BatchSignGeneric(ctx, []e2wtypes.Account{nil}, data[:1], domain)
+	require.EqualError(t, err, "account not of required type")
+}
+
+func TestBatchSignAcrossWalletsSlashingProtection(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 1)
+	pubKey, err := cluster.AddAccount("Wallet 1", "Account", []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddAccount("Wallet 2", "Account", []byte("pass"))
+	require.NoError(t, err)
+	store := dirk.NewMemorySlashingProtectionStore()
+	var key [48]byte
+	copy(key[:], pubKey)
+	require.NoError(t, store.SetRecord(ctx, key, &dirk.SlashingProtectionRecord{Attested: true, SourceEpoch: 1, TargetEpoch: 10}))
+	protection, err := dirk.NewSlashingProtection(store, genesisValidatorsRoot)
+	require.NoError(t, err)
+	wallet1 := openClusterWallet(ctx, t, cluster, "Wallet 1", dirk.WithSlashingProtection(protection))
+	wallet2 := openClusterWallet(ctx, t, cluster, "Wallet 2")
+	connectionProvider := &clusterConnectionProvider{cluster: cluster}
+	for _, wallet := range []e2wtypes.Wallet{wallet1, wallet2} {
+		wallet.(interface {
+			SetConnectionProvider(connectionProvider dirk.ConnectionProvider)
+		}).SetConnectionProvider(connectionProvider)
+	}
+	account1, err := wallet1.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+	account2, err := wallet2.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+
+	// The account with slashing protection is checked even though the
+	// account without it is first in the batch.
+	res, err := dirk.BatchSignBeaconAttestations(ctx,
+		5*32,
+		[]e2wtypes.Account{account2, account1},
+		[]uint64{0, 0},
+		bytes.Repeat([]byte{0x01}, 32),
+		1,
+		bytes.Repeat([]byte{0x02}, 32),
+		5,
+		bytes.Repeat([]byte{0x03}, 32),
+		bytes.Repeat([]byte{0x04}, 32),
+	)
+	require.NoError(t, err)
+	require.NoError(t, res.Items[0].Err)
+	require.ErrorIs(t, res.Items[1].Err, dirk.ErrSlashable)
+}
@@ -0,0 +1,246 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/credentials"
+)
+
+// certificateCheckInterval is the interval between checks of certificate expiry.
+const certificateCheckInterval = time.Minute
+
+// CertificateInfo is the interface for credentials that expose their certificates.
+type CertificateInfo interface {
+	// ClientCertificate provides the client certificate.
+	ClientCertificate() *x509.Certificate
+	// CACertificates provides the CA certificates, if any.
+	CACertificates() []*x509.Certificate
+}
+
+// tlsCredentials are TLS transport credentials that expose their certificates.
+type tlsCredentials struct {
+	credentials.TransportCredentials
+	clientCert *x509.Certificate
+	caCerts    []*x509.Certificate
+}
+
+// ClientCertificate provides the client certificate.
+func (c *tlsCredentials) ClientCertificate() *x509.Certificate {
+	return c.clientCert
+}
+
+// CACertificates provides the CA certificates, if any.
+func (c *tlsCredentials) CACertificates() []*x509.Certificate {
+	return c.caCerts
+}
+
+// Clone makes a copy of the credentials.
+func (c *tlsCredentials) Clone() credentials.TransportCredentials {
+	return &tlsCredentials{
+		TransportCredentials: c.TransportCredentials.Clone(),
+		clientCert:           c.clientCert,
+		caCerts:              c.caCerts,
+	}
+}
+
+// ClientCertificate provides the current client certificate.
+func (c *ReloadingCredentials) ClientCertificate() *x509.Certificate {
+	if info, isInfo := c.credentials().(CertificateInfo); isInfo {
+		return info.ClientCertificate()
+	}
+
+	return nil
+}
+
+// CACertificates provides the current CA certificates, if any.
+func (c *ReloadingCredentials) CACertificates() []*x509.Certificate {
+	if info, isInfo := c.credentials().(CertificateInfo); isInfo {
+		return info.CACertificates()
+	}
+
+	return nil
+}
+
+// parseCertificates parses the certificates in PEM-encoded data, ignoring any
+// blocks that are not valid certificates.
+func parseCertificates(data []byte) []*x509.Certificate {
+	res := make([]*x509.Certificate, 0)
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		res = append(res, cert)
+	}
+
+	return res
+}
+
+// checkClientCertificate checks that the client certificate is valid at the given time.
+func checkClientCertificate(cert *x509.Certificate, now time.Time) error {
+	if now.Before(cert.NotBefore) {
+		return fmt.Errorf("client certificate %q is not valid until %s", cert.Subject.CommonName, cert.NotBefore.UTC().Format(time.RFC3339))
+	}
+	if now.After(cert.NotAfter) {
+		return fmt.Errorf("client certificate %q expired at %s", cert.Subject.CommonName, cert.NotAfter.UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// certificateMonitor monitors the expiry of certificates, updating metrics
+// and warning when thresholds are crossed.
+type certificateMonitor struct {
+	log        zerolog.Logger
+	info       CertificateInfo
+	thresholds []time.Duration
+
+	// warned is the lowest threshold for which a warning has been logged, by certificate fingerprint.
+	warned map[[32]byte]time.Duration
+	// reported are the certificates for which expiry was reported by the last check.
+	reported map[certificateLabels]bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newCertificateMonitor creates a new certificate monitor.
+func newCertificateMonitor(log zerolog.Logger,
+	info CertificateInfo,
+	thresholds []time.Duration,
+) *certificateMonitor {
+	sortedThresholds := make([]time.Duration, len(thresholds))
+	copy(sortedThresholds, thresholds)
+	sort.Slice(sortedThresholds, func(i, j int) bool {
+		return sortedThresholds[i] < sortedThresholds[j]
+	})
+
+	return &certificateMonitor{
+		log:        log,
+		info:       info,
+		thresholds: sortedThresholds,
+		warned:     make(map[[32]byte]time.Duration),
+		reported:   make(map[certificateLabels]bool),
+	}
+}
+
+// certificateLabels are the labels of the expiry metric for a certificate.
+type certificateLabels struct {
+	role    string
+	subject string
+}
+
+// start starts monitoring in the background.
+func (m *certificateMonitor) start(ctx context.Context) {
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.done = make(chan struct{})
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(certificateCheckInterval)
+		defer ticker.Stop()
+		m.check(time.Now())
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.check(time.Now())
+			}
+		}
+	}()
+}
+
+// stop stops monitoring.
+func (m *certificateMonitor) stop() {
+	if m.cancel == nil {
+		return
+	}
+	m.cancel()
+	<-m.done
+}
+
+// check checks the certificates at the given time.  Expiry is no longer
+// reported for certificates that have been replaced since the last check.
+func (m *certificateMonitor) check(now time.Time) {
+	reported := make(map[certificateLabels]bool)
+	if cert := m.info.ClientCertificate(); cert != nil {
+		m.checkCertificate("client", cert, now)
+		reported[certificateLabels{role: "client", subject: cert.Subject.CommonName}] = true
+	}
+	for _, cert := range m.info.CACertificates() {
+		m.checkCertificate("ca", cert, now)
+		reported[certificateLabels{role: "ca", subject: cert.Subject.CommonName}] = true
+	}
+
+	for labels := range m.reported {
+		if !reported[labels] {
+			clearCertificateExpiry(labels.role, labels.subject)
+		}
+	}
+	m.reported = reported
+}
+
+// checkCertificate checks a single certificate at the given time.
+func (m *certificateMonitor) checkCertificate(role string, cert *x509.Certificate, now time.Time) {
+	remaining := cert.NotAfter.Sub(now)
+	setCertificateExpiry(role, cert.Subject.CommonName, remaining)
+
+	// Find the lowest threshold that has been crossed, with 0 for an expired certificate.
+	level := time.Duration(-1)
+	if remaining <= 0 {
+		level = 0
+	} else {
+		for _, threshold := range m.thresholds {
+			if remaining <= threshold {
+				level = threshold
+
+				break
+			}
+		}
+	}
+	if level < 0 {
+		return
+	}
+
+	fingerprint := sha256.Sum256(cert.Raw)
+	if warned, exists := m.warned[fingerprint]; exists && warned <= level {
+		// Already warned at this level.
+		return
+	}
+	m.warned[fingerprint] = level
+
+	if level == 0 {
+		m.log.Error().Str("certificate", role).Str("subject", cert.Subject.CommonName).Time("expiry", cert.NotAfter).Msg("Certificate has expired")
+
+		return
+	}
+	m.log.Warn().Str("certificate", role).Str("subject", cert.Subject.CommonName).Time("expiry", cert.NotAfter).Stringer("remaining", remaining.Round(time.Second)).Msg("Certificate expires soon")
+}
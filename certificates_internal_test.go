@@ -0,0 +1,201 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+// generateCertificate generates a self-signed certificate and key with the
+// given validity period, in PEM format.
+func generateCertificate(t *testing.T, name string, notBefore time.Time, notAfter time.Time) ([]byte, []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestCredentialsCertificates(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	clientCert, clientKey := generateCertificate(t, "client", now.Add(-time.Hour), now.Add(time.Hour))
+	caCert1, _ := generateCertificate(t, "ca1", now.Add(-time.Hour), now.Add(24*time.Hour))
+	caCert2, _ := generateCertificate(t, "ca2", now.Add(-time.Hour), now.Add(48*time.Hour))
+
+	creds, err := Credentials(ctx, clientCert, clientKey, append(caCert1, caCert2...))
+	require.NoError(t, err)
+	info, isInfo := creds.Clone().(CertificateInfo)
+	require.True(t, isInfo)
+	require.Equal(t, "client", info.ClientCertificate().Subject.CommonName)
+	require.Len(t, info.CACertificates(), 2)
+	require.Equal(t, "ca1", info.CACertificates()[0].Subject.CommonName)
+	require.Equal(t, "ca2", info.CACertificates()[1].Subject.CommonName)
+}
+
+func TestOpenCertificateValidity(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		notBefore time.Time
+		notAfter  time.Time
+		err       string
+	}{
+		{
+			name:      "Expired",
+			notBefore: now.Add(-2 * time.Hour),
+			notAfter:  now.Add(-time.Hour),
+			err:       `client certificate "client" expired at `,
+		},
+		{
+			name:      "NotYetValid",
+			notBefore: now.Add(time.Hour),
+			notAfter:  now.Add(2 * time.Hour),
+			err:       `client certificate "client" is not valid until `,
+		},
+		{
+			name:      "Good",
+			notBefore: now.Add(-time.Hour),
+			notAfter:  now.Add(time.Hour),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clientCert, clientKey := generateCertificate(t, "client", test.notBefore, test.notAfter)
+			creds, err := Credentials(ctx, clientCert, clientKey, nil)
+			require.NoError(t, err)
+			w, err := Open(ctx,
+				WithName("Test wallet"),
+				WithCredentials(creds),
+				WithEndpoints([]*Endpoint{{host: "localhost", port: 12345}}),
+			)
+			if test.err != "" {
+				require.ErrorContains(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.NoError(t, w.(WalletCloser).Close(ctx))
+			}
+		})
+	}
+}
+
+func TestCertificateMonitor(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	clientCert, clientKey := generateCertificate(t, "client", now.Add(-time.Hour), now.Add(10*24*time.Hour))
+	creds, err := Credentials(ctx, clientCert, clientKey, nil)
+	require.NoError(t, err)
+
+	var output bytes.Buffer
+	monitor := newCertificateMonitor(zerolog.New(&output), creds.(CertificateInfo), []time.Duration{24 * time.Hour, 7 * 24 * time.Hour})
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		warnings int
+		errors   int
+	}{
+		{
+			name: "NoThreshold",
+			at:   now,
+		},
+		{
+			name:     "SevenDays",
+			at:       now.Add(4 * 24 * time.Hour),
+			warnings: 1,
+		},
+		{
+			name:     "SevenDaysRepeat",
+			at:       now.Add(5 * 24 * time.Hour),
+			warnings: 1,
+		},
+		{
+			name:     "OneDay",
+			at:       now.Add(9*24*time.Hour + time.Hour),
+			warnings: 2,
+		},
+		{
+			name:     "Expired",
+			at:       now.Add(11 * 24 * time.Hour),
+			warnings: 2,
+			errors:   1,
+		},
+		{
+			name:     "ExpiredRepeat",
+			at:       now.Add(12 * 24 * time.Hour),
+			warnings: 2,
+			errors:   1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			monitor.check(test.at)
+			require.Equal(t, test.warnings, strings.Count(output.String(), "Certificate expires soon"))
+			require.Equal(t, test.errors, strings.Count(output.String(), "Certificate has expired"))
+		})
+	}
+}
+
+func TestCertificateMonitorRotation(t *testing.T) {
+	ctx := context.Background()
+	require.NoError(t, registerMetrics(ctx, &prometheusMetrics{}))
+	now := time.Now()
+	oldCert, _ := generateCertificate(t, "rotation-old", now.Add(-time.Hour), now.Add(time.Hour))
+	newCert, _ := generateCertificate(t, "rotation-new", now.Add(-time.Hour), now.Add(2*time.Hour))
+	info := &tlsCredentials{clientCert: parseCertificates(oldCert)[0]}
+	monitor := newCertificateMonitor(zerolog.Nop(), info, nil)
+
+	monitor.check(now)
+	series := testutil.CollectAndCount(certificateExpiry)
+	require.InDelta(t, time.Hour.Seconds(), testutil.ToFloat64(certificateExpiry.WithLabelValues("client", "rotation-old")), 1)
+
+	// The expiry of a replaced certificate is no longer reported.
+	info.clientCert = parseCertificates(newCert)[0]
+	monitor.check(now)
+	require.Equal(t, series, testutil.CollectAndCount(certificateExpiry))
+	require.InDelta(t, 2*time.Hour.Seconds(), testutil.ToFloat64(certificateExpiry.WithLabelValues("client", "rotation-new")), 1)
+}
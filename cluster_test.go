@@ -0,0 +1,93 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	mock "github.com/wealdtech/go-eth2-wallet-dirk/mock"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// clusterConnectionProvider provides connections to a mock cluster.
+type clusterConnectionProvider struct {
+	cluster *mock.Cluster
+}
+
+// Connection returns a connection and release function.
+func (c *clusterConnectionProvider) Connection(ctx context.Context, endpoint *dirk.Endpoint) (*grpc.ClientConn, func(), error) {
+	return c.cluster.Connection(ctx, endpoint.String())
+}
+
+// unreachableConnectionProvider provides connections to a mock cluster,
+// except for the given unreachable endpoints.
+type unreachableConnectionProvider struct {
+	clusterConnectionProvider
+	unreachable map[string]bool
+}
+
+// Connection returns a connection and release function.
+func (u *unreachableConnectionProvider) Connection(ctx context.Context, endpoint *dirk.Endpoint) (*grpc.ClientConn, func(), error) {
+	if u.unreachable[endpoint.String()] {
+		return nil, nil, fmt.Errorf("failed to dial %s", endpoint)
+	}
+
+	return u.clusterConnectionProvider.Connection(ctx, endpoint)
+}
+
+// newCluster creates a mock cluster with the given number of servers, closing it when the test finishes.
+func newCluster(t *testing.T, servers int) *mock.Cluster {
+	t.Helper()
+
+	cluster, err := mock.NewCluster(context.Background(), servers)
+	require.NoError(t, err)
+	t.Cleanup(cluster.Close)
+
+	return cluster
+}
+
+// openClusterWallet opens a wallet whose endpoints are the servers of a mock cluster.
+func openClusterWallet(ctx context.Context, t *testing.T, cluster *mock.Cluster, name string, params ...dirk.Parameter) e2wtypes.Wallet {
+	t.Helper()
+
+	endpoints := make([]*dirk.Endpoint, 0, len(cluster.Servers()))
+	for _, server := range cluster.Servers() {
+		endpoints = append(endpoints, dirk.NewEndpoint(server.Host(), server.Port()))
+	}
+	wallet, err := dirk.Open(ctx, append([]dirk.Parameter{
+		dirk.WithName(name),
+		dirk.WithEndpoints(endpoints),
+		dirk.WithCredentials(credentials.NewTLS(nil)),
+	}, params...)...)
+	require.NoError(t, err)
+	wallet.(interface {
+		SetConnectionProvider(connectionProvider dirk.ConnectionProvider)
+	}).SetConnectionProvider(&clusterConnectionProvider{cluster: cluster})
+
+	return wallet
+}
+
+// genericSigningRoot returns the signing root for 32-byte data and a domain.
+func genericSigningRoot(data []byte, domain []byte) []byte {
+	root := sha256.Sum256(append(append([]byte{}, data...), domain...))
+
+	return root[:]
+}
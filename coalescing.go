@@ -0,0 +1,225 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// attestationCoalescer gathers concurrent requests to sign attestations with
+// the same data, and signs them with a single batch request.
+type attestationCoalescer struct {
+	wallet  *wallet
+	window  time.Duration
+	mu      sync.Mutex
+	batches map[string]*attestationBatch
+	// stopped is set once the coalescer has been stopped, after which new
+	// attestations are rejected.
+	stopped bool
+	// flushes tracks batches that have yet to be signed.
+	flushes sync.WaitGroup
+}
+
+// attestationBatch is a batch of attestations with the same data.
+type attestationBatch struct {
+	slot             uint64
+	blockRoot        []byte
+	sourceEpoch      uint64
+	sourceRoot       []byte
+	targetEpoch      uint64
+	targetRoot       []byte
+	domain           []byte
+	accounts         []e2wtypes.Account
+	committeeIndices []uint64
+	// timer flushes the batch once its window has closed.
+	timer *time.Timer
+	// done is closed when results are available.
+	done   chan struct{}
+	result *MultiSignResult
+	err    error
+}
+
+// beaconAttestationGRPCSigner is the interface for accounts that sign
+// beacon attestations directly, bypassing the coalescer.
+type beaconAttestationGRPCSigner interface {
+	SignBeaconAttestationGRPC(ctx context.Context,
+		slot uint64,
+		committeeIndex uint64,
+		blockRoot []byte,
+		sourceEpoch uint64,
+		sourceRoot []byte,
+		targetEpoch uint64,
+		targetRoot []byte,
+		domain []byte,
+	) (
+		e2types.Signature,
+		error,
+	)
+}
+
+// newAttestationCoalescer creates a new attestation coalescer.
+func newAttestationCoalescer(wallet *wallet, window time.Duration) *attestationCoalescer {
+	return &attestationCoalescer{
+		wallet:  wallet,
+		window:  window,
+		batches: make(map[string]*attestationBatch),
+	}
+}
+
+// sign signs an attestation as part of a batch, waiting until the batch has
+// been signed or the context is done.
+func (c *attestationCoalescer) sign(ctx context.Context,
+	account e2wtypes.Account,
+	slot uint64,
+	committeeIndex uint64,
+	blockRoot []byte,
+	sourceEpoch uint64,
+	sourceRoot []byte,
+	targetEpoch uint64,
+	targetRoot []byte,
+	domain []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	// Reject attestations for slots that have ended before they join a
+	// batch, and stop waiting for the batch at the end of the slot.
+	ctx, dutyCancelFunc, err := c.wallet.dutyContext(ctx, slot)
+	if err != nil {
+		return nil, err
+	}
+	defer dutyCancelFunc()
+
+	key := attestationBatchKey(slot, blockRoot, sourceEpoch, sourceRoot, targetEpoch, targetRoot, domain)
+
+	c.mu.Lock()
+	if c.stopped {
+		c.mu.Unlock()
+
+		return nil, errors.New("wallet closed")
+	}
+	batch, exists := c.batches[key]
+	if !exists {
+		batch = &attestationBatch{
+			slot:        slot,
+			blockRoot:   blockRoot,
+			sourceEpoch: sourceEpoch,
+			sourceRoot:  sourceRoot,
+			targetEpoch: targetEpoch,
+			targetRoot:  targetRoot,
+			domain:      domain,
+			done:        make(chan struct{}),
+		}
+		c.batches[key] = batch
+		c.flushes.Add(1)
+		// The batch is signed on behalf of all of its callers, so is not
+		// tied to the context of any one of them; requests are still
+		// bounded by the timeout of the wallet.
+		batch.timer = time.AfterFunc(c.window, func() { c.flush(context.Background(), key, batch) })
+	}
+	index := len(batch.accounts)
+	batch.accounts = append(batch.accounts, account)
+	batch.committeeIndices = append(batch.committeeIndices, committeeIndex)
+	c.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		// The attestation remains in the batch, so is still signed.
+		return nil, errors.Wrap(ctx.Err(), "context done")
+	case <-batch.done:
+	}
+
+	if batch.err != nil {
+		return nil, batch.err
+	}
+	item := batch.result.Items[index]
+	if item.Err != nil {
+		return nil, item.Err
+	}
+
+	return item.Signature, nil
+}
+
+// stop rejects new attestations, signs the batches that are waiting for
+// their windows to close immediately, and waits for all batches to be
+// signed.
+func (c *attestationCoalescer) stop(ctx context.Context) {
+	c.mu.Lock()
+	c.stopped = true
+	for key, batch := range c.batches {
+		if batch.timer.Stop() {
+			go c.flush(ctx, key, batch)
+		}
+		// Otherwise the batch is already being flushed.
+	}
+	c.mu.Unlock()
+
+	c.flushes.Wait()
+}
+
+// flush signs a batch once its window has closed.
+func (c *attestationCoalescer) flush(ctx context.Context, key string, batch *attestationBatch) {
+	defer c.flushes.Done()
+	c.mu.Lock()
+	delete(c.batches, key)
+	c.mu.Unlock()
+	defer close(batch.done)
+
+	if len(batch.accounts) == 1 {
+		// Sign a lone attestation directly, to avoid the overhead of a batch.
+		signer, isSigner := batch.accounts[0].(beaconAttestationGRPCSigner)
+		if !isSigner {
+			batch.err = errors.New("account not of required type")
+
+			return
+		}
+		sig, err := signer.SignBeaconAttestationGRPC(ctx, batch.slot, batch.committeeIndices[0], batch.blockRoot, batch.sourceEpoch, batch.sourceRoot, batch.targetEpoch, batch.targetRoot, batch.domain)
+		batch.result = &MultiSignResult{
+			Items: []*MultiSignItemResult{{Signature: sig, Err: err}},
+		}
+
+		return
+	}
+
+	batch.result, batch.err = signBeaconAttestationsBatch(ctx, c.wallet, batch.slot, batch.accounts, batch.committeeIndices, batch.blockRoot, batch.sourceEpoch, batch.sourceRoot, batch.targetEpoch, batch.targetRoot, batch.domain)
+}
+
+// attestationBatchKey returns a key that is the same for attestations that
+// can be signed in the same batch.
+func attestationBatchKey(slot uint64,
+	blockRoot []byte,
+	sourceEpoch uint64,
+	sourceRoot []byte,
+	targetEpoch uint64,
+	targetRoot []byte,
+	domain []byte,
+) string {
+	key := make([]byte, 0, 24+len(blockRoot)+len(sourceRoot)+len(targetRoot)+len(domain)+4)
+	key = binary.BigEndian.AppendUint64(key, slot)
+	key = binary.BigEndian.AppendUint64(key, sourceEpoch)
+	key = binary.BigEndian.AppendUint64(key, targetEpoch)
+	for _, root := range [][]byte{blockRoot, sourceRoot, targetRoot, domain} {
+		key = append(key, byte(len(root)))
+		key = append(key, root...)
+	}
+
+	return string(key)
+}
@@ -0,0 +1,181 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestAttestationCoalescingParameter(t *testing.T) {
+	ctx := context.Background()
+	_, err := dirk.Open(ctx,
+		dirk.WithName("Test wallet"),
+		dirk.WithCredentials(credentials.NewTLS(nil)),
+		dirk.WithEndpoints([]*dirk.Endpoint{dirk.NewEndpoint("localhost", 12345)}),
+		dirk.WithAttestationCoalescing(-time.Second),
+	)
+	require.EqualError(t, err, "problem with parameters: invalid attestation coalescing window specified")
+}
+
+func TestAttestationCoalescing(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	names := make([]string, 0)
+	for i := 1; i <= 3; i++ {
+		name := fmt.Sprintf("Account %d", i)
+		_, err := cluster.AddAccount("Wallet", name, []byte("pass"))
+		require.NoError(t, err)
+		names = append(names, name)
+	}
+	for i := 4; i <= 5; i++ {
+		name := fmt.Sprintf("Account %d", i)
+		_, err := cluster.AddDistributedAccount("Wallet", name, 3, 2, []byte("pass"))
+		require.NoError(t, err)
+		names = append(names, name)
+	}
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithAttestationCoalescing(100*time.Millisecond))
+	accounts := make([]e2wtypes.Account, len(names))
+	for i, name := range names {
+		var err error
+		accounts[i], err = wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, name)
+		require.NoError(t, err)
+	}
+
+	signAttestations := func(accounts []e2wtypes.Account, targetEpoch uint64) ([]e2types.Signature, []error) {
+		sigs := make([]e2types.Signature, len(accounts))
+		errs := make([]error, len(accounts))
+		var wg sync.WaitGroup
+		for i, account := range accounts {
+			wg.Add(1)
+			go func(i int, account e2wtypes.Account) {
+				defer wg.Done()
+				sigs[i], errs[i] = account.(e2wtypes.AccountProtectingSigner).SignBeaconAttestation(ctx,
+					targetEpoch*32,
+					uint64(i),
+					bytes.Repeat([]byte{0x01}, 32),
+					0,
+					bytes.Repeat([]byte{0x02}, 32),
+					targetEpoch,
+					bytes.Repeat([]byte{0x03}, 32),
+					bytes.Repeat([]byte{0x04}, 32),
+				)
+			}(i, account)
+		}
+		wg.Wait()
+
+		return sigs, errs
+	}
+
+	// A lone attestation is signed directly.
+	sigs, errs := signAttestations(accounts[:1], 1)
+	require.NoError(t, errs[0])
+	require.NotNil(t, sigs[0])
+	require.Equal(t, uint64(1), requests(cluster, "SignBeaconAttestation"))
+	require.Zero(t, requests(cluster, "SignBeaconAttestations"))
+
+	// The first account has already signed for the target epoch, but the others
+	// have not; one request is made for the non-distributed accounts, and one
+	// to each participant of the distributed accounts.
+	sigs, errs = signAttestations(accounts, 1)
+	require.Nil(t, sigs[0])
+	require.ErrorIs(t, errs[0], dirk.ErrDenied)
+	for i := 1; i < len(accounts); i++ {
+		require.NoError(t, errs[i])
+		require.NotNil(t, sigs[i])
+	}
+	require.Equal(t, uint64(1), requests(cluster, "SignBeaconAttestation"))
+	require.LessOrEqual(t, requests(cluster, "SignBeaconAttestations"), uint64(1+3))
+}
+
+func TestAttestationCoalescingContextDone(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 1)
+	_, err := cluster.AddAccount("Wallet", "Account", []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithAttestationCoalescing(time.Second))
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = account.(e2wtypes.AccountProtectingSigner).SignBeaconAttestation(ctx,
+		32,
+		0,
+		bytes.Repeat([]byte{0x01}, 32),
+		0,
+		bytes.Repeat([]byte{0x02}, 32),
+		1,
+		bytes.Repeat([]byte{0x03}, 32),
+		bytes.Repeat([]byte{0x04}, 32),
+	)
+	require.EqualError(t, err, "context done: context deadline exceeded")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestAttestationCoalescingClose(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 1)
+	_, err := cluster.AddAccount("Wallet", "Account", []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithAttestationCoalescing(time.Hour))
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+
+	signAttestation := func() (e2types.Signature, error) {
+		return account.(e2wtypes.AccountProtectingSigner).SignBeaconAttestation(ctx,
+			32,
+			0,
+			bytes.Repeat([]byte{0x01}, 32),
+			0,
+			bytes.Repeat([]byte{0x02}, 32),
+			1,
+			bytes.Repeat([]byte{0x03}, 32),
+			bytes.Repeat([]byte{0x04}, 32),
+		)
+	}
+
+	// Closing the wallet signs the pending attestation without waiting for the window to close.
+	var sig e2types.Signature
+	var signErr error
+	signed := make(chan struct{})
+	go func() {
+		defer close(signed)
+		sig, signErr = signAttestation()
+	}()
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, wallet.(dirk.WalletCloser).Close(ctx))
+	select {
+	case <-signed:
+	case <-time.After(time.Second):
+		require.Fail(t, "pending attestation not signed on close")
+	}
+	require.NoError(t, signErr)
+	require.NotNil(t, sig)
+	require.Equal(t, uint64(1), requests(cluster, "SignBeaconAttestation"))
+
+	// Attestations are rejected once the wallet is closed.
+	_, err = signAttestation()
+	require.EqualError(t, err, "wallet closed")
+}
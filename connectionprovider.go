@@ -16,6 +16,7 @@ package dirk
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"sync"
 
 	"github.com/jackc/puddle/v2"
@@ -25,42 +26,181 @@ import (
 	"google.golang.org/grpc/credentials"
 )
 
-var (
-	// connectionPools is a per-address connection pool, to avoid excess connections.
-	connectionPools   = make(map[string]*puddle.Pool[*grpc.ClientConn])
-	connectionPoolsMu = sync.RWMutex{}
-)
-
 // ConnectionProvider is an interface that provides GRPC connections.
 type ConnectionProvider interface {
 	// Connection returns a connection and release function.
 	Connection(ctx context.Context, endpoint *Endpoint) (*grpc.ClientConn, func(), error)
 }
 
-// PuddleConnectionProvider provides connections using the Puddle connection pooler.
+// ClosingConnectionProvider is an interface for connection providers that
+// hold resources that should be released when they are no longer required.
+type ClosingConnectionProvider interface {
+	ConnectionProvider
+
+	// Close waits for connections in use to be released, or for the context
+	// to be done, and then closes all connections.
+	Close(ctx context.Context) error
+}
+
+// poolKey identifies a connection pool by the address to which it connects,
+// and the credentials and settings of its connections.
+type poolKey struct {
+	address         string
+	credentials     any
+	poolConnections int32
+}
+
+// sharedPool is a connection pool along with the number of connection
+// providers using it.
+type sharedPool struct {
+	pool *puddle.Pool[*grpc.ClientConn]
+	refs int
+}
+
+var (
+	// sharedPools are the connection pools shared between connection
+	// providers, so that wallets using the same endpoints with the same
+	// credentials and settings share their connections.
+	sharedPools   = make(map[poolKey]*sharedPool)
+	sharedPoolsMu sync.Mutex
+)
+
+// PuddleConnectionProvider provides connections using the Puddle connection
+// pooler.  Pools are shared with other providers that have the same
+// credentials and settings, and are closed when the last provider using them
+// is closed.
 type PuddleConnectionProvider struct {
 	name            string
 	poolConnections int32
 	credentials     credentials.TransportCredentials
+	// credentialsSource identifies the credentials for sharing pools, as
+	// credentials are cloned for each wallet.  If not set the credentials
+	// themselves are used.
+	credentialsSource credentials.TransportCredentials
+
+	// pools are the pools used by this provider.
+	pools    map[poolKey]*puddle.Pool[*grpc.ClientConn]
+	poolsMu  sync.Mutex
+	closed   bool
+	inFlight sync.WaitGroup
 }
 
 // Connection returns a connection and release function.
 func (c *PuddleConnectionProvider) Connection(ctx context.Context, endpoint *Endpoint) (*grpc.ClientConn, func(), error) {
-	pool := c.obtainOrCreatePool(fmt.Sprintf("%s:%d", endpoint.host, endpoint.port))
+	pool, err := c.obtainOrCreatePool(fmt.Sprintf("%s:%d", endpoint.host, endpoint.port))
+	if err != nil {
+		return nil, nil, err
+	}
 
 	res, err := pool.Acquire(ctx)
 	if err != nil {
+		c.inFlight.Done()
+
 		return nil, nil, errors.Wrap(err, "failed to obtain connection")
 	}
 
-	return res.Value(), res.Release, nil
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			res.Release()
+			c.inFlight.Done()
+		})
+	}
+
+	return res.Value(), release, nil
+}
+
+// Close waits for connections in use to be released, or for the context to
+// be done, and then releases the pools used by the provider.  Pools that are
+// no longer used by any provider are closed; connections still in use when
+// the context is done are closed as they are released.
+func (c *PuddleConnectionProvider) Close(ctx context.Context) error {
+	c.poolsMu.Lock()
+	if c.closed {
+		c.poolsMu.Unlock()
+
+		return nil
+	}
+	c.closed = true
+	pools := c.pools
+	c.pools = nil
+	c.poolsMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		c.inFlight.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		for key := range pools {
+			releasePool(key, false)
+		}
+
+		return nil
+	case <-ctx.Done():
+		// Closing a pool blocks until its connections are released, so
+		// leave the pools to close in the background.
+		for key := range pools {
+			releasePool(key, true)
+		}
+
+		return errors.Wrap(ctx.Err(), "connections still in use")
+	}
+}
+
+// poolKey returns the key of the pool for the given address.
+func (c *PuddleConnectionProvider) poolKey(address string) poolKey {
+	var credentialsID any = c.credentialsSource
+	if credentialsID == nil {
+		credentialsID = c.credentials
+	}
+	if credentialsID != nil && !reflect.TypeOf(credentialsID).Comparable() {
+		// Cannot identify the credentials, so do not share the pool.
+		credentialsID = c
+	}
+
+	return poolKey{
+		address:         address,
+		credentials:     credentialsID,
+		poolConnections: c.poolConnections,
+	}
 }
 
-func (c *PuddleConnectionProvider) obtainOrCreatePool(address string) *puddle.Pool[*grpc.ClientConn] {
-	connectionPoolsMu.RLock()
-	pool, exists := connectionPools[address]
-	connectionPoolsMu.RUnlock()
+// obtainOrCreatePool obtains the pool for the given address, creating it if
+// required, and registers a connection as in flight.
+func (c *PuddleConnectionProvider) obtainOrCreatePool(address string) (*puddle.Pool[*grpc.ClientConn], error) {
+	c.poolsMu.Lock()
+	defer c.poolsMu.Unlock()
+
+	if c.closed {
+		return nil, errors.New("connection provider closed")
+	}
+	c.inFlight.Add(1)
+
+	if c.pools == nil {
+		c.pools = make(map[poolKey]*puddle.Pool[*grpc.ClientConn])
+	}
+	key := c.poolKey(address)
+	pool, exists := c.pools[key]
 	if !exists {
+		pool = c.acquirePool(key)
+		c.pools[key] = pool
+	}
+
+	return pool, nil
+}
+
+// acquirePool obtains the shared pool for the given key, creating it if
+// required, and notes that it is used by the provider.
+func (c *PuddleConnectionProvider) acquirePool(key poolKey) *puddle.Pool[*grpc.ClientConn] {
+	sharedPoolsMu.Lock()
+	defer sharedPoolsMu.Unlock()
+
+	shared, exists := sharedPools[key]
+	if !exists {
+		address := key.address
 		constructor := func(ctx context.Context) (*grpc.ClientConn, error) {
 			conn, err := grpc.DialContext(ctx, address, []grpc.DialOption{
 				grpc.WithTransportCredentials(c.credentials),
@@ -87,15 +227,43 @@ func (c *PuddleConnectionProvider) obtainOrCreatePool(address string) *puddle.Po
 		}
 		// Ignoring error, can only happen if MaxSize < 1 and we check for this
 		// already in parseAndCheckParameters.
-		pool, _ = puddle.NewPool(&puddle.Config[*grpc.ClientConn]{
+		pool, _ := puddle.NewPool(&puddle.Config[*grpc.ClientConn]{
 			Constructor: constructor,
 			Destructor:  destructor,
 			MaxSize:     c.poolConnections,
 		})
-		connectionPoolsMu.Lock()
-		connectionPools[address] = pool
-		connectionPoolsMu.Unlock()
+		shared = &sharedPool{
+			pool: pool,
+		}
+		sharedPools[key] = shared
+	}
+	shared.refs++
+
+	return shared.pool
+}
+
+// releasePool notes that a provider no longer uses the shared pool for the
+// given key, closing the pool if it is no longer used by any provider.
+func releasePool(key poolKey, background bool) {
+	sharedPoolsMu.Lock()
+	shared, exists := sharedPools[key]
+	if !exists {
+		sharedPoolsMu.Unlock()
+
+		return
 	}
+	shared.refs--
+	if shared.refs > 0 {
+		sharedPoolsMu.Unlock()
 
-	return pool
+		return
+	}
+	delete(sharedPools, key)
+	sharedPoolsMu.Unlock()
+
+	if background {
+		go shared.pool.Close()
+	} else {
+		shared.pool.Close()
+	}
 }
@@ -0,0 +1,132 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestPuddleConnectionProviderPools(t *testing.T) {
+	ctx := context.Background()
+	endpoint := &Endpoint{host: "localhost", port: 12345}
+
+	// Providers with the same credentials and settings share pools.
+	provider1 := &PuddleConnectionProvider{poolConnections: 1, credentials: insecure.NewCredentials()}
+	provider2 := &PuddleConnectionProvider{poolConnections: 1, credentials: insecure.NewCredentials()}
+	conn1, release1, err := provider1.Connection(ctx, endpoint)
+	require.NoError(t, err)
+	release1()
+	conn2, release2, err := provider2.Connection(ctx, endpoint)
+	require.NoError(t, err)
+	require.Same(t, conn1, conn2)
+	release2()
+
+	// Providers with different credentials or settings do not.
+	tlsCredentials := credentials.NewTLS(nil)
+	provider3 := &PuddleConnectionProvider{poolConnections: 2, credentials: insecure.NewCredentials()}
+	provider4 := &PuddleConnectionProvider{poolConnections: 1, credentials: tlsCredentials.Clone(), credentialsSource: tlsCredentials}
+	provider5 := &PuddleConnectionProvider{poolConnections: 1, credentials: tlsCredentials.Clone(), credentialsSource: tlsCredentials}
+	conn3, release3, err := provider3.Connection(ctx, endpoint)
+	require.NoError(t, err)
+	require.NotSame(t, conn1, conn3)
+	release3()
+	conn4, release4, err := provider4.Connection(ctx, endpoint)
+	require.NoError(t, err)
+	require.NotSame(t, conn1, conn4)
+	release4()
+	// Credentials cloned from the same source are the same credentials.
+	conn5, release5, err := provider5.Connection(ctx, endpoint)
+	require.NoError(t, err)
+	require.Same(t, conn4, conn5)
+	release5()
+
+	// Closing a provider leaves shared pools open for the other providers.
+	require.NoError(t, provider1.Close(ctx))
+	conn6, release6, err := provider2.Connection(ctx, endpoint)
+	require.NoError(t, err)
+	require.Same(t, conn1, conn6)
+	release6()
+
+	// Pools are closed once no provider uses them.
+	for _, provider := range []*PuddleConnectionProvider{provider2, provider3, provider4, provider5} {
+		require.NoError(t, provider.Close(ctx))
+		sharedPoolsMu.Lock()
+		_, exists := sharedPools[provider.poolKey("localhost:12345")]
+		sharedPoolsMu.Unlock()
+		require.Equal(t, provider == provider4, exists)
+	}
+}
+
+func TestPuddleConnectionProviderCloseDrains(t *testing.T) {
+	ctx := context.Background()
+	endpoint := &Endpoint{host: "localhost", port: 12345}
+	provider := &PuddleConnectionProvider{poolConnections: 1, credentials: insecure.NewCredentials()}
+
+	_, release, err := provider.Connection(ctx, endpoint)
+	require.NoError(t, err)
+
+	closed := make(chan error)
+	go func() {
+		closed <- provider.Close(ctx)
+	}()
+	select {
+	case <-closed:
+		require.Fail(t, "close returned with connection in use")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	release()
+	require.NoError(t, <-closed)
+
+	_, _, err = provider.Connection(ctx, endpoint)
+	require.EqualError(t, err, "connection provider closed")
+
+	// Closing again is a no-op.
+	require.NoError(t, provider.Close(ctx))
+}
+
+func TestPuddleConnectionProviderCloseTimeout(t *testing.T) {
+	ctx := context.Background()
+	endpoint := &Endpoint{host: "localhost", port: 12345}
+	provider := &PuddleConnectionProvider{poolConnections: 1, credentials: insecure.NewCredentials()}
+
+	_, release, err := provider.Connection(ctx, endpoint)
+	require.NoError(t, err)
+	defer release()
+
+	closeCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	require.EqualError(t, provider.Close(closeCtx), "connections still in use: context deadline exceeded")
+}
+
+func TestWalletClose(t *testing.T) {
+	ctx := context.Background()
+	w, err := Open(ctx,
+		WithName("Test wallet"),
+		WithCredentials(credentials.NewTLS(nil)),
+		WithEndpoints([]*Endpoint{{host: "localhost", port: 12345}}),
+		WithHealthCheckInterval(time.Hour),
+	)
+	require.NoError(t, err)
+	require.NoError(t, w.(WalletCloser).Close(ctx))
+
+	_, err = w.(*wallet).List(ctx, "")
+	require.ErrorContains(t, err, "connection provider closed")
+}
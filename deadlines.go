@@ -0,0 +1,53 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// dutyDeadline returns the deadline for signing a duty at the given slot,
+// which is the end of the slot.  It returns false if the wallet does not
+// have slot timing, or the slot is too far in the future to have a deadline.
+func (w *wallet) dutyDeadline(slot uint64) (time.Time, bool) {
+	if w.slotDuration == 0 {
+		return time.Time{}, false
+	}
+	if slot >= uint64(math.MaxInt64/w.slotDuration)-1 {
+		return time.Time{}, false
+	}
+
+	return w.genesisTime.Add(time.Duration(slot+1) * w.slotDuration), true
+}
+
+// dutyContext returns a context that is done at the deadline for signing a
+// duty at the given slot.  An error is returned if the deadline has already
+// passed.
+func (w *wallet) dutyContext(ctx context.Context, slot uint64) (context.Context, context.CancelFunc, error) {
+	deadline, exists := w.dutyDeadline(slot)
+	if !exists {
+		return ctx, func() {}, nil
+	}
+	if !time.Now().Before(deadline) {
+		return nil, nil, errors.Wrapf(ErrDeadlinePassed, "slot %d", slot)
+	}
+
+	ctx, cancelFunc := context.WithDeadline(ctx, deadline)
+
+	return ctx, cancelFunc, nil
+}
@@ -0,0 +1,136 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	mock "github.com/wealdtech/go-eth2-wallet-dirk/mock"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestSlotTimingParameters(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params []dirk.Parameter
+		err    string
+	}{
+		{
+			name:   "SlotDurationInvalid",
+			params: []dirk.Parameter{dirk.WithGenesisTime(time.Now()), dirk.WithSlotDuration(-time.Second)},
+			err:    "problem with parameters: invalid slot duration specified",
+		},
+		{
+			name:   "GenesisTimeMissing",
+			params: []dirk.Parameter{dirk.WithSlotDuration(12 * time.Second)},
+			err:    "problem with parameters: no genesis time specified",
+		},
+		{
+			name:   "SlotDurationMissing",
+			params: []dirk.Parameter{dirk.WithGenesisTime(time.Now())},
+			err:    "problem with parameters: no slot duration specified",
+		},
+		{
+			name:   "Good",
+			params: []dirk.Parameter{dirk.WithGenesisTime(time.Now()), dirk.WithSlotDuration(12 * time.Second)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := dirk.Open(ctx, append([]dirk.Parameter{
+				dirk.WithName("Test wallet"),
+				dirk.WithCredentials(credentials.NewTLS(nil)),
+				dirk.WithEndpoints([]*dirk.Endpoint{dirk.NewEndpoint("localhost", 12345)}),
+			}, test.params...)...)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDutyDeadlines(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddAccount("Wallet", "Account 1", []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+
+	// Slot 10 is the current slot, and ends in 1.5s.
+	slotDuration := 2 * time.Second
+	genesisTime := time.Now().Add(-10*slotDuration - slotDuration/4)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithGenesisTime(genesisTime), dirk.WithSlotDuration(slotDuration))
+	account1, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+	account2, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 2")
+	require.NoError(t, err)
+
+	signAttestation := func(account e2wtypes.Account, slot uint64) error {
+		_, err := account.(e2wtypes.AccountProtectingSigner).SignBeaconAttestation(ctx,
+			slot,
+			0,
+			bytes.Repeat([]byte{0x01}, 32),
+			0,
+			bytes.Repeat([]byte{0x02}, 32),
+			slot/32+1,
+			bytes.Repeat([]byte{0x03}, 32),
+			bytes.Repeat([]byte{0x04}, 32),
+		)
+
+		return err
+	}
+
+	// Requests for slots that have ended are rejected without contacting Dirk.
+	for _, account := range []e2wtypes.Account{account1, account2} {
+		err = signAttestation(account, 9)
+		require.ErrorIs(t, err, dirk.ErrDeadlinePassed)
+		require.EqualError(t, err, "slot 9: duty deadline passed")
+		_, err = account.(e2wtypes.AccountProtectingSigner).SignBeaconProposal(ctx,
+			9,
+			1,
+			bytes.Repeat([]byte{0x01}, 32),
+			bytes.Repeat([]byte{0x02}, 32),
+			bytes.Repeat([]byte{0x03}, 32),
+			bytes.Repeat([]byte{0x04}, 32),
+		)
+		require.ErrorIs(t, err, dirk.ErrDeadlinePassed)
+	}
+	require.Zero(t, requests(cluster, "SignBeaconAttestation"))
+	require.Zero(t, requests(cluster, "SignBeaconProposal"))
+
+	// Requests for the current slot are signed.
+	require.NoError(t, signAttestation(account1, 10))
+	require.NoError(t, signAttestation(account2, 10))
+
+	// Requests for the current slot are abandoned at the end of the slot.
+	faultInjector := mock.NewFaultInjector(1)
+	faultInjector.Add(&mock.Fault{Method: "SignBeaconAttestation", Latency: 5 * time.Second})
+	cluster.SetFaultInjector(faultInjector)
+	started := time.Now()
+	currentSlot := uint64(time.Since(genesisTime) / slotDuration)
+	require.Error(t, signAttestation(account1, currentSlot))
+	require.Less(t, time.Since(started), slotDuration)
+}
@@ -19,6 +19,7 @@ import (
 	"sync"
 
 	"github.com/google/uuid"
+	"github.com/herumi/bls-eth-go-binary/bls"
 	"github.com/pkg/errors"
 	e2types "github.com/wealdtech/go-eth2-types/v2"
 	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
@@ -34,8 +35,12 @@ type distributedAccount struct {
 	signingThreshold uint32
 	participants     map[uint64]*Endpoint
 	participantConns map[uint64]*grpc.ClientConn
-	version          uint
-	mutex            *sync.RWMutex
+	// participantPubKeys are the public key shares of the participants,
+	// obtained and checked against the composite public key when listing,
+	// or when signing for participants that were unreachable when listing.
+	participantPubKeys map[uint64]*bls.PublicKey
+	version            uint
+	mutex              *sync.RWMutex
 }
 
 func newDistributedAccount(wallet *wallet,
@@ -48,16 +53,17 @@ func newDistributedAccount(wallet *wallet,
 	version uint,
 ) *distributedAccount {
 	return &distributedAccount{
-		wallet:           wallet,
-		id:               id,
-		name:             name,
-		pubKey:           pubKey,
-		compositePubKey:  compositePubKey,
-		signingThreshold: signingThreshold,
-		participants:     participants,
-		participantConns: make(map[uint64]*grpc.ClientConn),
-		version:          version,
-		mutex:            new(sync.RWMutex),
+		wallet:             wallet,
+		id:                 id,
+		name:               name,
+		pubKey:             pubKey,
+		compositePubKey:    compositePubKey,
+		signingThreshold:   signingThreshold,
+		participants:       participants,
+		participantConns:   make(map[uint64]*grpc.ClientConn),
+		participantPubKeys: make(map[uint64]*bls.PublicKey),
+		version:            version,
+		mutex:              new(sync.RWMutex),
 	}
 }
 
@@ -96,6 +102,34 @@ func (a *distributedAccount) Participants() map[uint64]string {
 	return participantsCopy
 }
 
+// participantPubKey provides the public key share for the given participant, if known.
+func (a *distributedAccount) participantPubKey(id uint64) *bls.PublicKey {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	return a.participantPubKeys[id]
+}
+
+// knownParticipantPubKeys provides the public key shares that are known, by participant.
+func (a *distributedAccount) knownParticipantPubKeys() map[uint64]*bls.PublicKey {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	pubKeys := make(map[uint64]*bls.PublicKey, len(a.participantPubKeys))
+	for id, pubKey := range a.participantPubKeys {
+		pubKeys[id] = pubKey
+	}
+
+	return pubKeys
+}
+
+// setParticipantPubKey sets the public key share for the given participant.
+func (a *distributedAccount) setParticipantPubKey(id uint64, pubKey *bls.PublicKey) {
+	a.mutex.Lock()
+	a.participantPubKeys[id] = pubKey
+	a.mutex.Unlock()
+}
+
 // Wallet provides the wallet for the account.
 func (a *distributedAccount) Wallet() e2wtypes.Wallet {
 	return a.wallet
@@ -150,6 +184,24 @@ func (a *distributedAccount) SignGenericMulti(ctx context.Context, accounts []e2
 	return sigs, nil
 }
 
+// SignGenericMultiResults signs multiple generic data roots, returning the
+// result for each.
+func (a *distributedAccount) SignGenericMultiResults(ctx context.Context,
+	accounts []e2wtypes.Account,
+	data [][]byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	res, err := a.SignMultiResultsGRPC(ctx, accounts, data, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
 // SignBeaconProposal signs a beacon proposal with protection.
 func (a *distributedAccount) SignBeaconProposal(ctx context.Context,
 	slot uint64,
@@ -178,6 +230,10 @@ func (a *distributedAccount) SignBeaconAttestation(ctx context.Context,
 	targetRoot []byte,
 	domain []byte,
 ) (e2types.Signature, error) {
+	if a.wallet.attestationCoalescer != nil {
+		return a.wallet.attestationCoalescer.sign(ctx, a, slot, committeeIndex, blockRoot, sourceEpoch, sourceRoot, targetEpoch, targetRoot, domain)
+	}
+
 	sig, err := a.SignBeaconAttestationGRPC(ctx, slot, committeeIndex, blockRoot, sourceEpoch, sourceRoot, targetEpoch, targetRoot, domain)
 	if err != nil {
 		return nil, err
@@ -205,3 +261,124 @@ func (a *distributedAccount) SignBeaconAttestations(ctx context.Context,
 
 	return sigs, nil
 }
+
+// SignBeaconAttestationsResults signs multiple beacon attestations with
+// protection, returning the result for each.
+func (a *distributedAccount) SignBeaconAttestationsResults(ctx context.Context,
+	slot uint64,
+	accounts []e2wtypes.Account,
+	committeeIndices []uint64,
+	blockRoot []byte,
+	sourceEpoch uint64,
+	sourceRoot []byte,
+	targetEpoch uint64,
+	targetRoot []byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	res, err := a.SignBeaconAttestationsResultsGRPC(ctx, slot, accounts, committeeIndices, blockRoot, sourceEpoch, sourceRoot, targetEpoch, targetRoot, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// SignSyncCommitteeMessage signs a sync committee message for a beacon block root.
+func (a *distributedAccount) SignSyncCommitteeMessage(ctx context.Context,
+	beaconBlockRoot []byte,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signSyncCommitteeMessage(ctx, a, beaconBlockRoot, forkVersion, genesisValidatorsRoot)
+}
+
+// SignSyncCommitteeSelectionProof signs a proof of selection as a sync committee aggregator.
+func (a *distributedAccount) SignSyncCommitteeSelectionProof(ctx context.Context,
+	slot uint64,
+	subcommitteeIndex uint64,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signSyncCommitteeSelectionProof(ctx, a, slot, subcommitteeIndex, forkVersion, genesisValidatorsRoot)
+}
+
+// SignContributionAndProof signs a sync committee contribution and proof.
+func (a *distributedAccount) SignContributionAndProof(ctx context.Context,
+	contributionAndProof *ContributionAndProof,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signContributionAndProof(ctx, a, contributionAndProof, forkVersion, genesisValidatorsRoot)
+}
+
+// SignSelectionProof signs a proof of selection as an attestation aggregator.
+func (a *distributedAccount) SignSelectionProof(ctx context.Context,
+	slot uint64,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signSelectionProof(ctx, a, slot, forkVersion, genesisValidatorsRoot)
+}
+
+// SignAggregateAndProof signs an aggregate and proof.
+func (a *distributedAccount) SignAggregateAndProof(ctx context.Context,
+	aggregateAndProof *AggregateAndProof,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signAggregateAndProof(ctx, a, aggregateAndProof, forkVersion, genesisValidatorsRoot)
+}
+
+// SignRANDAOReveal signs a RANDAO reveal for an epoch.
+func (a *distributedAccount) SignRANDAOReveal(ctx context.Context,
+	epoch uint64,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signRANDAOReveal(ctx, a, epoch, forkVersion, genesisValidatorsRoot)
+}
+
+// SignVoluntaryExit signs a voluntary exit.
+func (a *distributedAccount) SignVoluntaryExit(ctx context.Context,
+	epoch uint64,
+	validatorIndex uint64,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signVoluntaryExit(ctx, a, epoch, validatorIndex, forkVersion, genesisValidatorsRoot)
+}
+
+// SignValidatorRegistration signs a validator registration.
+func (a *distributedAccount) SignValidatorRegistration(ctx context.Context,
+	registration *ValidatorRegistration,
+	genesisForkVersion []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signValidatorRegistration(ctx, a, registration, genesisForkVersion)
+}
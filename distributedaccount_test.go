@@ -13,682 +13,448 @@
 
 package dirk_test
 
-// Disabled because it results in a link back to Dirk repository for
-//	"github.com/attestantio/dirk/testing/daemon"
-//	"github.com/attestantio/dirk/testing/resources"
-// func TestCreateDistributedAccount(t *testing.T) {
-// 	err := e2types.InitBLS()
-// 	require.NoError(t, err)
-//
-// 	ctx, cancel := context.WithCancel(context.Background())
-// 	defer cancel()
-// 	rand.Seed(time.Now().UnixNano())
-// 	// #nosec G404
-// 	port1 := uint32(12000 + rand.Intn(4000))
-// 	// #nosec G404
-// 	port2 := uint32(12000 + rand.Intn(4000))
-// 	// #nosec G404
-// 	port3 := uint32(12000 + rand.Intn(4000))
-// 	peersMap := map[uint64]string{
-// 		1: fmt.Sprintf("signer-test01:%d", port1),
-// 		2: fmt.Sprintf("signer-test02:%d", port2),
-// 		3: fmt.Sprintf("signer-test03:%d", port3),
-// 	}
-//
-// 	_, path1, err := daemon.New(ctx, "", 1, port1, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path1)
-//
-// 	_, path2, err := daemon.New(ctx, "", 2, port2, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path2)
-//
-// 	_, path3, err := daemon.New(ctx, "", 3, port3, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path3)
-//
-// 	endpoints := []*dirk.Endpoint{
-// 		dirk.NewEndpoint("signer-test01", port1),
-// 		dirk.NewEndpoint("signer-test02", port2),
-// 		dirk.NewEndpoint("signer-test03", port3),
-// 	}
-//
-// 	credentials, err := dirk.Credentials(ctx,
-// 		resources.ClientTest01Crt,
-// 		resources.ClientTest01Key,
-// 		resources.CACrt,
-// 	)
-// 	require.NoError(t, err)
-//
-// 	wallet, err := dirk.OpenWallet(ctx, "Wallet 3", credentials, endpoints)
-// 	require.NoError(t, err)
-//
-// 	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, nil))
-//
-// 	accountCreator, isAccountCreator := wallet.(e2wtypes.WalletDistributedAccountCreator)
-// 	require.True(t, isAccountCreator)
-//
-// 	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-// 	defer cancel()
-// 	_, err = accountCreator.CreateDistributedAccount(ctx, "Test account", 3, 2, []byte("pass"))
-// 	require.NoError(t, err)
-//
-// 	require.NoError(t, wallet.(e2wtypes.WalletLocker).Lock(ctx))
-//
-// 	// Fetch the account to ensure it exists.
-// 	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Test account")
-// 	require.NoError(t, err)
-// 	require.NotNil(t, account)
-// 	require.NotNil(t, account.ID())
-// 	require.NotNil(t, account.Name())
-// 	require.NotNil(t, account.PublicKey())
-// 	require.NotNil(t, account.(e2wtypes.AccountCompositePublicKeyProvider).CompositePublicKey())
-// 	require.NotNil(t, account.(e2wtypes.DistributedAccount).SigningThreshold())
-// 	require.NotNil(t, account.(e2wtypes.DistributedAccount).Participants())
-// 	require.NotNil(t, account.(e2wtypes.AccountWalletProvider).Wallet())
-// }
-//
-// func TestUnlockDistributedAccount(t *testing.T) {
-// 	err := e2types.InitBLS()
-// 	require.NoError(t, err)
-//
-// 	ctx, cancel := context.WithCancel(context.Background())
-// 	defer cancel()
-// 	rand.Seed(time.Now().UnixNano())
-// 	// #nosec G404
-// 	port1 := uint32(12000 + rand.Intn(4000))
-// 	// #nosec G404
-// 	port2 := uint32(12000 + rand.Intn(4000))
-// 	// #nosec G404
-// 	port3 := uint32(12000 + rand.Intn(4000))
-// 	peersMap := map[uint64]string{
-// 		1: fmt.Sprintf("signer-test01:%d", port1),
-// 		2: fmt.Sprintf("signer-test02:%d", port2),
-// 		3: fmt.Sprintf("signer-test03:%d", port3),
-// 	}
-//
-// 	_, path1, err := daemon.New(ctx, "", 1, port1, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path1)
-//
-// 	_, path2, err := daemon.New(ctx, "", 2, port2, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path2)
-//
-// 	_, path3, err := daemon.New(ctx, "", 3, port3, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path3)
-//
-// 	endpoints := []*dirk.Endpoint{
-// 		dirk.NewEndpoint("signer-test01", port1),
-// 		dirk.NewEndpoint("signer-test02", port2),
-// 		dirk.NewEndpoint("signer-test03", port3),
-// 	}
-//
-// 	credentials, err := dirk.Credentials(ctx,
-// 		resources.ClientTest01Crt,
-// 		resources.ClientTest01Key,
-// 		resources.CACrt,
-// 	)
-// 	require.NoError(t, err)
-//
-// 	wallet, err := dirk.OpenWallet(ctx, "Wallet 3", credentials, endpoints)
-// 	require.NoError(t, err)
-//
-// 	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, nil))
-//
-// 	accountCreator, isAccountCreator := wallet.(e2wtypes.WalletDistributedAccountCreator)
-// 	require.True(t, isAccountCreator)
-//
-// 	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-// 	defer cancel()
-// 	_, err = accountCreator.CreateDistributedAccount(ctx, "Test account", 3, 2, []byte("pass"))
-// 	require.NoError(t, err)
-//
-// 	require.NoError(t, wallet.(e2wtypes.WalletLocker).Lock(ctx))
-//
-// 	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Test account")
-// 	require.NoError(t, err)
-//
-// 	// Unlock with incorrect passphrase.
-// 	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-// 	defer cancel()
-// 	err = account.(e2wtypes.AccountLocker).Unlock(ctx, []byte("bad"))
-// 	assert.EqualError(t, err, "unlock attempt failed")
-//
-// 	// Unlock with correct passphrase.
-// 	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-// 	defer cancel()
-// 	err = account.(e2wtypes.AccountLocker).Unlock(ctx, []byte("pass"))
-// 	require.NoError(t, err)
-//
-// 	unlocked, err := account.(e2wtypes.AccountLocker).IsUnlocked(ctx)
-// 	require.NoError(t, err)
-// 	require.True(t, unlocked)
-//
-// 	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-// 	defer cancel()
-// 	assert.NoError(t, account.(e2wtypes.AccountLocker).Lock(ctx))
-// }
-//
-// func TestDistributedSignGeneric(t *testing.T) {
-// 	err := e2types.InitBLS()
-// 	require.NoError(t, err)
-//
-// 	ctx, cancel := context.WithCancel(context.Background())
-// 	defer cancel()
-// 	rand.Seed(time.Now().UnixNano())
-// 	// #nosec G404
-// 	port1 := uint32(12000 + rand.Intn(4000))
-// 	// #nosec G404
-// 	port2 := uint32(12000 + rand.Intn(4000))
-// 	// #nosec G404
-// 	port3 := uint32(12000 + rand.Intn(4000))
-// 	peersMap := map[uint64]string{
-// 		1: fmt.Sprintf("signer-test01:%d", port1),
-// 		2: fmt.Sprintf("signer-test02:%d", port2),
-// 		3: fmt.Sprintf("signer-test03:%d", port3),
-// 	}
-//
-// 	_, path1, err := daemon.New(ctx, "", 1, port1, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path1)
-//
-// 	_, path2, err := daemon.New(ctx, "", 2, port2, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path2)
-//
-// 	_, path3, err := daemon.New(ctx, "", 3, port3, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path3)
-//
-// 	endpoints := []*dirk.Endpoint{
-// 		dirk.NewEndpoint("signer-test01", port1),
-// 		dirk.NewEndpoint("signer-test02", port2),
-// 		dirk.NewEndpoint("signer-test03", port3),
-// 	}
-//
-// 	credentials, err := dirk.Credentials(ctx,
-// 		resources.ClientTest01Crt,
-// 		resources.ClientTest01Key,
-// 		resources.CACrt,
-// 	)
-// 	require.NoError(t, err)
-//
-// 	wallet, err := dirk.OpenWallet(ctx, "Wallet 3", credentials, endpoints)
-// 	require.NoError(t, err)
-//
-// 	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, nil))
-//
-// 	accountCreator, isAccountCreator := wallet.(e2wtypes.WalletDistributedAccountCreator)
-// 	require.True(t, isAccountCreator)
-// 	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-// 	defer cancel()
-// 	account, err := accountCreator.CreateDistributedAccount(ctx, "Test account", 3, 2, []byte("pass"))
-// 	require.NoError(t, err)
-//
-// 	tests := []struct {
-// 		name   string
-// 		data   []byte
-// 		domain []byte
-// 		err    string
-// 	}{
-// 		{
-// 			name: "ProposerDomain",
-// 			data: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			domain: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			err: "failed to obtain signature: not enough signatures: 0 signed, 3 denied, 0 failed, 0 errored",
-// 		},
-// 		{
-// 			name: "AttesterDomain",
-// 			data: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			domain: []byte{
-// 				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			err: "failed to obtain signature: not enough signatures: 0 signed, 3 denied, 0 failed, 0 errored",
-// 		},
-// 		{
-// 			name: "DataLengthIncorrect",
-// 			data: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			domain: []byte{
-// 				0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			err: "data must be 32 bytes in length",
-// 		},
-// 		{
-// 			name: "Good",
-// 			data: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			domain: []byte{
-// 				0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 		},
-// 	}
-//
-// 	for _, test := range tests {
-// 		t.Run(test.name, func(t *testing.T) {
-// 			sig, err := account.(e2wtypes.AccountProtectingSigner).SignGeneric(ctx, test.data, test.domain)
-// 			if test.err != "" {
-// 				require.EqualError(t, err, test.err)
-// 			} else {
-// 				require.NoError(t, err)
-// 				// Cannot compare against a hard-coded signature because distributed accounts are generated at test time.
-// 				require.NotNil(t, sig)
-// 			}
-// 		})
-// 	}
-// }
-//
-// func TestDistributedSignBeaconProposal(t *testing.T) {
-// 	err := e2types.InitBLS()
-// 	require.NoError(t, err)
-//
-// 	ctx, cancel := context.WithCancel(context.Background())
-// 	defer cancel()
-// 	rand.Seed(time.Now().UnixNano())
-// 	// #nosec G404
-// 	port1 := uint32(12000 + rand.Intn(4000))
-// 	// #nosec G404
-// 	port2 := uint32(12000 + rand.Intn(4000))
-// 	// #nosec G404
-// 	port3 := uint32(12000 + rand.Intn(4000))
-// 	peersMap := map[uint64]string{
-// 		1: fmt.Sprintf("signer-test01:%d", port1),
-// 		2: fmt.Sprintf("signer-test02:%d", port2),
-// 		3: fmt.Sprintf("signer-test03:%d", port3),
-// 	}
-//
-// 	_, path1, err := daemon.New(ctx, "", 1, port1, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path1)
-//
-// 	_, path2, err := daemon.New(ctx, "", 2, port2, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path2)
-//
-// 	_, path3, err := daemon.New(ctx, "", 3, port3, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path3)
-//
-// 	endpoints := []*dirk.Endpoint{
-// 		dirk.NewEndpoint("signer-test01", port1),
-// 		dirk.NewEndpoint("signer-test02", port2),
-// 		dirk.NewEndpoint("signer-test03", port3),
-// 	}
-//
-// 	credentials, err := dirk.Credentials(ctx,
-// 		resources.ClientTest01Crt,
-// 		resources.ClientTest01Key,
-// 		resources.CACrt,
-// 	)
-// 	require.NoError(t, err)
-//
-// 	wallet, err := dirk.OpenWallet(ctx, "Wallet 3", credentials, endpoints)
-// 	require.NoError(t, err)
-//
-// 	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, nil))
-//
-// 	accountCreator, isAccountCreator := wallet.(e2wtypes.WalletDistributedAccountCreator)
-// 	require.True(t, isAccountCreator)
-// 	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-// 	defer cancel()
-// 	account, err := accountCreator.CreateDistributedAccount(ctx, "Test account", 3, 2, []byte("pass"))
-// 	require.NoError(t, err)
-//
-// 	tests := []struct {
-// 		name          string
-// 		slot          uint64
-// 		proposerIndex uint64
-// 		parentRoot    []byte
-// 		stateRoot     []byte
-// 		bodyRoot      []byte
-// 		domain        []byte
-// 		err           string
-// 	}{
-// 		{
-// 			name:          "Good",
-// 			slot:          1,
-// 			proposerIndex: 1,
-// 			parentRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			stateRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			bodyRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			domain: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 		},
-// 		{
-// 			name:          "Repeat",
-// 			slot:          1,
-// 			proposerIndex: 1,
-// 			parentRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			stateRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			bodyRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			domain: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			err: "failed to obtain signature: not enough signatures: 0 signed, 3 denied, 0 failed, 0 errored",
-// 		},
-// 	}
-//
-// 	for _, test := range tests {
-// 		t.Run(test.name, func(t *testing.T) {
-// 			sig, err := account.(e2wtypes.AccountProtectingSigner).SignBeaconProposal(ctx, test.slot, test.proposerIndex, test.parentRoot, test.stateRoot, test.bodyRoot, test.domain)
-// 			if test.err != "" {
-// 				require.EqualError(t, err, test.err)
-// 			} else {
-// 				require.NoError(t, err)
-// 				// Cannot compare against a hard-coded signature because distributed accounts are generated at test time.
-// 				require.NotNil(t, sig)
-// 			}
-// 		})
-// 	}
-// }
-//
-// func TestDistributedSignBeaconAttestation(t *testing.T) {
-// 	err := e2types.InitBLS()
-// 	require.NoError(t, err)
-//
-// 	ctx, cancel := context.WithCancel(context.Background())
-// 	defer cancel()
-// 	rand.Seed(time.Now().UnixNano())
-// 	// #nosec G404
-// 	port1 := uint32(12000 + rand.Intn(4000))
-// 	// #nosec G404
-// 	port2 := uint32(12000 + rand.Intn(4000))
-// 	// #nosec G404
-// 	port3 := uint32(12000 + rand.Intn(4000))
-// 	peersMap := map[uint64]string{
-// 		1: fmt.Sprintf("signer-test01:%d", port1),
-// 		2: fmt.Sprintf("signer-test02:%d", port2),
-// 		3: fmt.Sprintf("signer-test03:%d", port3),
-// 	}
-//
-// 	_, path1, err := daemon.New(ctx, "", 1, port1, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path1)
-//
-// 	_, path2, err := daemon.New(ctx, "", 2, port2, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path2)
-//
-// 	_, path3, err := daemon.New(ctx, "", 3, port3, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path3)
-//
-// 	endpoints := []*dirk.Endpoint{
-// 		dirk.NewEndpoint("signer-test01", port1),
-// 		dirk.NewEndpoint("signer-test02", port2),
-// 		dirk.NewEndpoint("signer-test03", port3),
-// 	}
-//
-// 	credentials, err := dirk.Credentials(ctx,
-// 		resources.ClientTest01Crt,
-// 		resources.ClientTest01Key,
-// 		resources.CACrt,
-// 	)
-// 	require.NoError(t, err)
-//
-// 	wallet, err := dirk.OpenWallet(ctx, "Wallet 3", credentials, endpoints)
-// 	require.NoError(t, err)
-//
-// 	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, nil))
-//
-// 	accountCreator, isAccountCreator := wallet.(e2wtypes.WalletDistributedAccountCreator)
-// 	require.True(t, isAccountCreator)
-// 	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-// 	defer cancel()
-// 	account, err := accountCreator.CreateDistributedAccount(ctx, "Test account", 3, 2, []byte("pass"))
-// 	require.NoError(t, err)
-//
-// 	tests := []struct {
-// 		name           string
-// 		slot           uint64
-// 		committeeIndex uint64
-// 		blockRoot      []byte
-// 		sourceEpoch    uint64
-// 		sourceRoot     []byte
-// 		targetEpoch    uint64
-// 		targetRoot     []byte
-// 		domain         []byte
-// 		err            string
-// 	}{
-// 		{
-// 			name:           "Good",
-// 			slot:           1,
-// 			committeeIndex: 1,
-// 			blockRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			sourceEpoch: 0,
-// 			sourceRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			targetEpoch: 1,
-// 			targetRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			domain: []byte{
-// 				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 		},
-// 		{
-// 			name:           "Repeat",
-// 			slot:           1,
-// 			committeeIndex: 1,
-// 			blockRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			sourceEpoch: 0,
-// 			sourceRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			targetEpoch: 1,
-// 			targetRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			domain: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			err: "failed to obtain signature: not enough signatures: 0 signed, 3 denied, 0 failed, 0 errored",
-// 		},
-// 	}
-//
-// 	for _, test := range tests {
-// 		t.Run(test.name, func(t *testing.T) {
-// 			sig, err := account.(e2wtypes.AccountProtectingSigner).SignBeaconAttestation(ctx, test.slot, test.committeeIndex, test.blockRoot, test.sourceEpoch, test.sourceRoot, test.targetEpoch, test.targetRoot, test.domain)
-// 			if test.err != "" {
-// 				require.EqualError(t, err, test.err)
-// 			} else {
-// 				require.NoError(t, err)
-// 				// Cannot compare against a hard-coded signature because distributed accounts are generated at test time.
-// 				require.NotNil(t, sig)
-// 			}
-// 		})
-// 	}
-// }
-//
-// func TestDistributedSignBeaconAttestations(t *testing.T) {
-// 	err := e2types.InitBLS()
-// 	require.NoError(t, err)
-//
-// 	ctx, cancel := context.WithCancel(context.Background())
-// 	defer cancel()
-// 	rand.Seed(time.Now().UnixNano())
-// 	// #nosec G404
-// 	port1 := uint32(12000 + rand.Intn(4000))
-// 	// #nosec G404
-// 	port2 := uint32(12000 + rand.Intn(4000))
-// 	// #nosec G404
-// 	port3 := uint32(12000 + rand.Intn(4000))
-// 	peersMap := map[uint64]string{
-// 		1: fmt.Sprintf("signer-test01:%d", port1),
-// 		2: fmt.Sprintf("signer-test02:%d", port2),
-// 		3: fmt.Sprintf("signer-test03:%d", port3),
-// 	}
-//
-// 	_, path1, err := daemon.New(ctx, "", 1, port1, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path1)
-//
-// 	_, path2, err := daemon.New(ctx, "", 2, port2, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path2)
-//
-// 	_, path3, err := daemon.New(ctx, "", 3, port3, peersMap)
-// 	require.NoError(t, err)
-// 	defer os.RemoveAll(path3)
-//
-// 	endpoints := []*dirk.Endpoint{
-// 		dirk.NewEndpoint("signer-test01", port1),
-// 		dirk.NewEndpoint("signer-test02", port2),
-// 		dirk.NewEndpoint("signer-test03", port3),
-// 	}
-//
-// 	credentials, err := dirk.Credentials(ctx,
-// 		resources.ClientTest01Crt,
-// 		resources.ClientTest01Key,
-// 		resources.CACrt,
-// 	)
-// 	require.NoError(t, err)
-//
-// 	wallet, err := dirk.OpenWallet(ctx, "Wallet 3", credentials, endpoints)
-// 	require.NoError(t, err)
-//
-// 	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, nil))
-//
-// 	accountCreator, isAccountCreator := wallet.(e2wtypes.WalletDistributedAccountCreator)
-// 	require.True(t, isAccountCreator)
-// 	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
-// 	defer cancel()
-// 	account1, err := accountCreator.CreateDistributedAccount(ctx, "Test account 1", 3, 2, []byte("pass"))
-// 	require.NoError(t, err)
-// 	account2, err := accountCreator.CreateDistributedAccount(ctx, "Test account 2", 3, 2, []byte("pass"))
-// 	require.NoError(t, err)
-//
-// 	tests := []struct {
-// 		name             string
-// 		slot             uint64
-// 		accounts         []e2wtypes.Account
-// 		committeeIndices []uint64
-// 		blockRoot        []byte
-// 		sourceEpoch      uint64
-// 		sourceRoot       []byte
-// 		targetEpoch      uint64
-// 		targetRoot       []byte
-// 		domain           []byte
-// 		duplicates       bool
-// 	}{
-// 		{
-// 			name:             "Good",
-// 			slot:             1,
-// 			accounts:         []e2wtypes.Account{account1, account2},
-// 			committeeIndices: []uint64{1, 2},
-// 			blockRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			sourceEpoch: 0,
-// 			sourceRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			targetEpoch: 1,
-// 			targetRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			domain: []byte{
-// 				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 		},
-// 		{
-// 			name:             "Repeat2",
-// 			slot:             1,
-// 			accounts:         []e2wtypes.Account{account1, account2},
-// 			committeeIndices: []uint64{1, 2},
-// 			blockRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			sourceEpoch: 0,
-// 			sourceRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			targetEpoch: 1,
-// 			targetRoot: []byte{
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			domain: []byte{
-// 				0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 				0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
-// 			},
-// 			duplicates: true,
-// 		},
-// 	}
-//
-// 	for _, test := range tests {
-// 		t.Run(test.name, func(t *testing.T) {
-// 			sigs, err := account1.(e2wtypes.AccountProtectingMultiSigner).SignBeaconAttestations(ctx, test.slot, test.accounts, test.committeeIndices, test.blockRoot, test.sourceEpoch, test.sourceRoot, test.targetEpoch, test.targetRoot, test.domain)
-// 			require.NoError(t, err)
-// 			require.Equal(t, len(test.accounts), len(sigs))
-// 			if test.duplicates {
-// 				// We don't receive an error for duplicates, we have indivdiual signatures return nil.
-// 				for i := range sigs {
-// 					require.Nil(t, sigs[i])
-// 				}
-// 			} else {
-// 				for i := range sigs {
-// 					require.NotNil(t, sigs[i])
-// 				}
-// 			}
-// 		})
-// 	}
-// }
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+func TestCreateDistributedAccount(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet 3")
+
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Unlock(ctx, nil))
+
+	accountCreator, isAccountCreator := wallet.(e2wtypes.WalletDistributedAccountCreator)
+	require.True(t, isAccountCreator)
+
+	_, err := accountCreator.CreateDistributedAccount(ctx, "Test account", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+
+	require.NoError(t, wallet.(e2wtypes.WalletLocker).Lock(ctx))
+
+	// Fetch the account to ensure it exists.
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Test account")
+	require.NoError(t, err)
+	require.NotNil(t, account)
+	require.NotNil(t, account.ID())
+	require.Equal(t, "Test account", account.Name())
+	require.NotNil(t, account.PublicKey())
+	require.NotNil(t, account.(e2wtypes.AccountCompositePublicKeyProvider).CompositePublicKey())
+	require.Equal(t, uint32(2), account.(e2wtypes.DistributedAccount).SigningThreshold())
+	require.Len(t, account.(e2wtypes.DistributedAccount).Participants(), 3)
+	require.NotNil(t, account.(e2wtypes.AccountWalletProvider).Wallet())
+}
+
+func TestUnlockDistributedAccount(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddDistributedAccount("Wallet 3", "Account 1", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet 3")
+
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+
+	// Unlock with incorrect passphrase.
+	err = account.(e2wtypes.AccountLocker).Unlock(ctx, []byte("bad"))
+	require.EqualError(t, err, "unlock attempt failed")
+
+	// Unlock with correct passphrase.
+	require.NoError(t, account.(e2wtypes.AccountLocker).Unlock(ctx, []byte("pass")))
+
+	unlocked, err := account.(e2wtypes.AccountLocker).IsUnlocked(ctx)
+	require.NoError(t, err)
+	require.True(t, unlocked)
+
+	// Locked accounts cannot sign.
+	require.NoError(t, account.(e2wtypes.AccountLocker).Lock(ctx))
+	_, err = account.(e2wtypes.AccountProtectingSigner).SignGeneric(ctx, bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x03}, 32))
+	require.True(t, errors.Is(err, dirk.ErrDenied))
+}
+
+func TestDistributedSignGeneric(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	compositePubKeyBytes, err := cluster.AddDistributedAccount("Wallet 3", "Account 1", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	compositePubKey, err := e2types.BLSPublicKeyFromBytes(compositePubKeyBytes)
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet 3")
+
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		domain []byte
+		down   []uint64
+		err    string
+	}{
+		{
+			name:   "ProposerDomain",
+			data:   bytes.Repeat([]byte{0x01}, 32),
+			domain: append([]byte{0x00, 0x00, 0x00, 0x00}, bytes.Repeat([]byte{0x02}, 28)...),
+			err:    "failed to obtain signature: not enough signatures: 0 signed, 3 denied, 0 failed, 0 errored, 0 invalid",
+		},
+		{
+			name:   "AttesterDomain",
+			data:   bytes.Repeat([]byte{0x01}, 32),
+			domain: append([]byte{0x01, 0x00, 0x00, 0x00}, bytes.Repeat([]byte{0x02}, 28)...),
+			err:    "failed to obtain signature: not enough signatures: 0 signed, 3 denied, 0 failed, 0 errored, 0 invalid",
+		},
+		{
+			name:   "DataLengthIncorrect",
+			data:   bytes.Repeat([]byte{0x01}, 31),
+			domain: bytes.Repeat([]byte{0x03}, 32),
+			err:    "data must be 32 bytes in length",
+		},
+		{
+			name:   "Good",
+			data:   bytes.Repeat([]byte{0x01}, 32),
+			domain: bytes.Repeat([]byte{0x03}, 32),
+		},
+		{
+			name:   "OneServerDown",
+			data:   bytes.Repeat([]byte{0x01}, 32),
+			domain: bytes.Repeat([]byte{0x03}, 32),
+			down:   []uint64{2},
+		},
+		{
+			name:   "TwoServersDown",
+			data:   bytes.Repeat([]byte{0x01}, 32),
+			domain: bytes.Repeat([]byte{0x03}, 32),
+			down:   []uint64{1, 3},
+			err:    "failed to obtain signature: not enough signatures: 1 signed, 0 denied, 0 failed, 2 errored, 0 invalid",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, id := range test.down {
+				cluster.Server(id).SetDown(true)
+			}
+			defer func() {
+				for _, id := range test.down {
+					cluster.Server(id).SetDown(false)
+				}
+			}()
+
+			sig, err := account.(e2wtypes.AccountProtectingSigner).SignGeneric(ctx, test.data, test.domain)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.True(t, sig.Verify(genericSigningRoot(test.data, test.domain), compositePubKey))
+			}
+		})
+	}
+}
+
+func TestDistributedSignGenericMulti(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddDistributedAccount("Wallet 3", "Account 1", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet 3", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet 3")
+
+	account1, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+	account2, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 2")
+	require.NoError(t, err)
+
+	data := [][]byte{
+		bytes.Repeat([]byte{0x01}, 32),
+		bytes.Repeat([]byte{0x02}, 32),
+	}
+	domain := bytes.Repeat([]byte{0x03}, 32)
+	sigs, err := account1.(e2wtypes.AccountProtectingMultiSigner).SignGenericMulti(ctx, []e2wtypes.Account{account1, account2}, data, domain)
+	require.NoError(t, err)
+	require.Len(t, sigs, 2)
+	require.True(t, sigs[0].Verify(genericSigningRoot(data[0], domain), account1.(e2wtypes.AccountCompositePublicKeyProvider).CompositePublicKey()))
+	require.True(t, sigs[1].Verify(genericSigningRoot(data[1], domain), account2.(e2wtypes.AccountCompositePublicKeyProvider).CompositePublicKey()))
+}
+
+func TestDistributedSignUnreachableWhenListing(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	compositePubKeyBytes, err := cluster.AddDistributedAccount("Wallet 3", "Account 1", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	compositePubKey, err := e2types.BLSPublicKeyFromBytes(compositePubKeyBytes)
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet 3")
+
+	// Server 3 is down when the account is listed, so its public key share is not known.
+	cluster.Server(3).SetDown(true)
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+	cluster.Server(3).SetDown(false)
+
+	// Server 1 is down when signing, so the share of server 3 is required.
+	cluster.Server(1).SetDown(true)
+	defer cluster.Server(1).SetDown(false)
+	data := bytes.Repeat([]byte{0x01}, 32)
+	domain := bytes.Repeat([]byte{0x03}, 32)
+	sig, err := account.(e2wtypes.AccountProtectingSigner).SignGeneric(ctx, data, domain)
+	require.NoError(t, err)
+	require.True(t, sig.Verify(genericSigningRoot(data, domain), compositePubKey))
+
+	sigs, err := account.(e2wtypes.AccountProtectingMultiSigner).SignGenericMulti(ctx, []e2wtypes.Account{account}, [][]byte{bytes.Repeat([]byte{0x02}, 32)}, domain)
+	require.NoError(t, err)
+	require.Len(t, sigs, 1)
+	require.True(t, sigs[0].Verify(genericSigningRoot(bytes.Repeat([]byte{0x02}, 32), domain), compositePubKey))
+}
+
+func TestDistributedSignUnreachable(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 5)
+	compositePubKeyBytes, err := cluster.AddDistributedAccount("Wallet 3", "Account 1", 5, 3, []byte("pass"))
+	require.NoError(t, err)
+	compositePubKey, err := e2types.BLSPublicKeyFromBytes(compositePubKeyBytes)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		unreachable []string
+		err         string
+	}{
+		{
+			name:        "Minority",
+			unreachable: []string{"signer-test01:12001", "signer-test04:12004"},
+		},
+		{
+			name:        "Majority",
+			unreachable: []string{"signer-test01:12001", "signer-test02:12002", "signer-test04:12004"},
+			err:         "failed to obtain signature: not enough signatures: 2 signed, 0 denied, 0 failed, 3 errored, 0 invalid",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			wallet := openClusterWallet(ctx, t, cluster, "Wallet 3")
+			account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+			require.NoError(t, err)
+			connectionProvider := &unreachableConnectionProvider{
+				clusterConnectionProvider: clusterConnectionProvider{cluster: cluster},
+				unreachable:               make(map[string]bool),
+			}
+			for _, endpoint := range test.unreachable {
+				connectionProvider.unreachable[endpoint] = true
+			}
+			wallet.(interface {
+				SetConnectionProvider(connectionProvider dirk.ConnectionProvider)
+			}).SetConnectionProvider(connectionProvider)
+
+			data := bytes.Repeat([]byte{byte(i)}, 32)
+			domain := bytes.Repeat([]byte{0x03}, 32)
+			sig, err := account.(e2wtypes.AccountProtectingSigner).SignGeneric(ctx, data, domain)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.True(t, sig.Verify(genericSigningRoot(data, domain), compositePubKey))
+			}
+
+			// Multiple signing tolerates unreachable participants in the same way.
+			sigs, err := account.(e2wtypes.AccountProtectingMultiSigner).SignGenericMulti(ctx, []e2wtypes.Account{account}, [][]byte{bytes.Repeat([]byte{byte(i + 0x10)}, 32)}, domain)
+			require.NoError(t, err)
+			require.Len(t, sigs, 1)
+			if test.err != "" {
+				require.Nil(t, sigs[0])
+			} else {
+				require.NotNil(t, sigs[0])
+			}
+		})
+	}
+}
+
+func TestDistributedSignBeaconProposal(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddDistributedAccount("Wallet 3", "Account 1", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet 3")
+
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		slot uint64
+		err  string
+	}{
+		{
+			name: "Good",
+			slot: 1,
+		},
+		{
+			name: "Repeat",
+			slot: 1,
+			err:  "failed to obtain signature: not enough signatures: 0 signed, 3 denied, 0 failed, 0 errored, 0 invalid",
+		},
+		{
+			name: "Next",
+			slot: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sig, err := account.(e2wtypes.AccountProtectingSigner).SignBeaconProposal(ctx,
+				test.slot,
+				1,
+				bytes.Repeat([]byte{0x01}, 32),
+				bytes.Repeat([]byte{0x02}, 32),
+				bytes.Repeat([]byte{0x03}, 32),
+				bytes.Repeat([]byte{0x04}, 32),
+			)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, sig)
+			}
+		})
+	}
+}
+
+func TestDistributedSignBeaconAttestation(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddDistributedAccount("Wallet 3", "Account 1", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet 3")
+
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		slot        uint64
+		sourceEpoch uint64
+		targetEpoch uint64
+		err         string
+	}{
+		{
+			name:        "Good",
+			slot:        1,
+			sourceEpoch: 0,
+			targetEpoch: 1,
+		},
+		{
+			name:        "Repeat",
+			slot:        1,
+			sourceEpoch: 0,
+			targetEpoch: 1,
+			err:         "failed to obtain signature: not enough signatures: 0 signed, 3 denied, 0 failed, 0 errored, 0 invalid",
+		},
+		{
+			name:        "Next",
+			slot:        33,
+			sourceEpoch: 1,
+			targetEpoch: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sig, err := account.(e2wtypes.AccountProtectingSigner).SignBeaconAttestation(ctx,
+				test.slot,
+				1,
+				bytes.Repeat([]byte{0x01}, 32),
+				test.sourceEpoch,
+				bytes.Repeat([]byte{0x02}, 32),
+				test.targetEpoch,
+				bytes.Repeat([]byte{0x03}, 32),
+				bytes.Repeat([]byte{0x04}, 32),
+			)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, sig)
+			}
+		})
+	}
+}
+
+func TestDistributedSignBeaconAttestations(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddDistributedAccount("Wallet 3", "Account 1", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet 3", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet 3")
+
+	account1, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+	account2, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 2")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		slot             uint64
+		accounts         []e2wtypes.Account
+		committeeIndices []uint64
+		sourceEpoch      uint64
+		targetEpoch      uint64
+		duplicates       bool
+	}{
+		{
+			name:             "Good",
+			slot:             1,
+			accounts:         []e2wtypes.Account{account1, account2},
+			committeeIndices: []uint64{1, 2},
+			sourceEpoch:      0,
+			targetEpoch:      1,
+		},
+		{
+			name:             "Repeat",
+			slot:             1,
+			accounts:         []e2wtypes.Account{account1, account2},
+			committeeIndices: []uint64{1, 2},
+			sourceEpoch:      0,
+			targetEpoch:      1,
+			duplicates:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sigs, err := account1.(e2wtypes.AccountProtectingMultiSigner).SignBeaconAttestations(ctx,
+				test.slot,
+				test.accounts,
+				test.committeeIndices,
+				bytes.Repeat([]byte{0x01}, 32),
+				test.sourceEpoch,
+				bytes.Repeat([]byte{0x02}, 32),
+				test.targetEpoch,
+				bytes.Repeat([]byte{0x03}, 32),
+				bytes.Repeat([]byte{0x04}, 32),
+			)
+			require.NoError(t, err)
+			require.Len(t, sigs, len(test.accounts))
+			if test.duplicates {
+				// We don't receive an error for duplicates, we have individual signatures return nil.
+				for i := range sigs {
+					require.Nil(t, sigs[i])
+				}
+			} else {
+				for i := range sigs {
+					require.NotNil(t, sigs[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// EndpointSelection is the policy used to pick endpoints for requests that
+// can be serviced by any of the wallet's endpoints.
+type EndpointSelection int
+
+const (
+	// EndpointSelectionOrdered tries endpoints in the order in which they were supplied.
+	EndpointSelectionOrdered EndpointSelection = iota
+	// EndpointSelectionRoundRobin starts each request at the next endpoint in turn.
+	EndpointSelectionRoundRobin
+	// EndpointSelectionLeastLatency tries endpoints in order of their recent latency.
+	EndpointSelectionLeastLatency
+)
+
+// String implements the stringer interface.
+func (s EndpointSelection) String() string {
+	switch s {
+	case EndpointSelectionOrdered:
+		return "ordered"
+	case EndpointSelectionRoundRobin:
+		return "round-robin"
+	case EndpointSelectionLeastLatency:
+		return "least-latency"
+	default:
+		return "unknown"
+	}
+}
+
+// failedRequestLatency is the latency recorded for a request that failed, to
+// move the endpoint down the list for least-latency selection.
+const failedRequestLatency = 10 * time.Second
+
+// endpointSelector orders endpoints according to an endpoint selection policy.
+type endpointSelector struct {
+	policy    EndpointSelection
+	endpoints []*Endpoint
+	next      atomic.Uint64
+
+	latenciesMu sync.RWMutex
+	latencies   map[string]time.Duration
+	// hedged holds the latencies assumed for endpoints that had not responded
+	// when requests to participants were hedged.  They are kept apart from
+	// measured latencies so that they do not affect endpoint selection.
+	hedged map[string]time.Duration
+}
+
+// newEndpointSelector creates a new endpoint selector.
+func newEndpointSelector(policy EndpointSelection, endpoints []*Endpoint) *endpointSelector {
+	return &endpointSelector{
+		policy:    policy,
+		endpoints: endpoints,
+		latencies: make(map[string]time.Duration),
+		hedged:    make(map[string]time.Duration),
+	}
+}
+
+// order returns the endpoints in the order in which they should be tried.
+func (s *endpointSelector) order() []*Endpoint {
+	res := make([]*Endpoint, len(s.endpoints))
+	switch s.policy {
+	case EndpointSelectionRoundRobin:
+		offset := int((s.next.Add(1) - 1) % uint64(len(s.endpoints)))
+		copy(res, s.endpoints[offset:])
+		copy(res[len(s.endpoints)-offset:], s.endpoints[:offset])
+	case EndpointSelectionLeastLatency:
+		copy(res, s.endpoints)
+		s.latenciesMu.RLock()
+		// Endpoints without a recorded latency sort first, so that they are measured.
+		sort.SliceStable(res, func(i, j int) bool {
+			return s.latencies[res[i].String()] < s.latencies[res[j].String()]
+		})
+		s.latenciesMu.RUnlock()
+	default:
+		copy(res, s.endpoints)
+	}
+
+	return res
+}
+
+// observe records the outcome of a request to an endpoint.
+func (s *endpointSelector) observe(endpoint *Endpoint, latency time.Duration, err error) {
+	if err != nil {
+		latency = failedRequestLatency
+	}
+
+	key := endpoint.String()
+	s.latenciesMu.Lock()
+	if current, exists := s.latencies[key]; exists {
+		// Exponentially weighted moving average, favouring recent requests.
+		s.latencies[key] = (current + 3*latency) / 4
+	} else {
+		s.latencies[key] = latency
+	}
+	// A measured latency supersedes any assumed when hedging.
+	delete(s.hedged, key)
+	s.latenciesMu.Unlock()
+}
+
+// observeHedged records that an endpoint had not responded to a request to a
+// participant within the given hedge delay.
+func (s *endpointSelector) observeHedged(endpoint *Endpoint, hedgeDelay time.Duration) {
+	key := endpoint.String()
+	s.latenciesMu.Lock()
+	if s.hedged[key] < hedgeDelay {
+		s.hedged[key] = hedgeDelay
+	}
+	s.latenciesMu.Unlock()
+}
+
+// participantLatency returns the latency of an endpoint for ordering
+// participants, which is at least any latency assumed when hedging.
+func (s *endpointSelector) participantLatency(endpoint *Endpoint) time.Duration {
+	key := endpoint.String()
+	s.latenciesMu.RLock()
+	defer s.latenciesMu.RUnlock()
+
+	return max(s.latencies[key], s.hedged[key])
+}
+
+// slashableOperations are the operations whose requests must not be passed
+// to another endpoint once they may have reached an endpoint, as each
+// endpoint has its own slashing protection and both could sign.
+var slashableOperations = map[string]bool{
+	"proposal":     true,
+	"attestation":  true,
+	"attestations": true,
+}
+
+// tryEndpoints carries out a request against the wallet's endpoints, in the
+// order given by the endpoint selection policy.  The request is passed to the
+// next endpoint only if the current endpoint could not be contacted or did not
+// return a response; once an endpoint returns a response, whatever its state,
+// the request has been processed and must not be retried elsewhere.  Requests
+// for slashable operations are only passed on if the connection could not be
+// obtained or the endpoint was unavailable, as any other failure may have
+// happened after the endpoint signed.
+// The request returns the state of the response, which is used for metrics.
+// It returns the endpoint that returned the response.
+func (w *wallet) tryEndpoints(ctx context.Context,
+	operation string,
+	request func(ctx context.Context, conn *grpc.ClientConn) (pb.ResponseState, error),
+) (
+	*Endpoint,
+	error,
+) {
+	if len(w.endpoints) == 0 {
+		return nil, errors.New("wallet has no endpoints")
+	}
+
+	var err error
+	for _, endpoint := range w.availableEndpoints() {
+		started := time.Now()
+		var conn *grpc.ClientConn
+		var release func()
+		conn, release, err = w.connectionProvider.Connection(ctx, endpoint)
+		if err != nil {
+			w.log.Debug().Stringer("endpoint", endpoint).Err(err).Msg("Failed to obtain connection")
+			w.endpointSelector.observe(endpoint, time.Since(started), err)
+			observeRequest(operation, endpoint, time.Since(started), requestOutcome(pb.ResponseState_UNKNOWN, err))
+			err = transportError(endpoint, err)
+			w.endpointFailed(ctx, endpoint, err)
+
+			continue
+		}
+
+		var state pb.ResponseState
+		state, err = request(ctx, conn)
+		release()
+		w.endpointSelector.observe(endpoint, time.Since(started), err)
+		observeRequest(operation, endpoint, time.Since(started), requestOutcome(state, err))
+		if err == nil {
+			return endpoint, nil
+		}
+		w.log.Debug().Stringer("endpoint", endpoint).Err(err).Msg("Request to endpoint failed")
+		unavailable := status.Code(err) == codes.Unavailable
+		err = transportError(endpoint, err)
+		w.endpointFailed(ctx, endpoint, err)
+
+		if ctx.Err() != nil {
+			// No time left to try other endpoints.
+			break
+		}
+		if slashableOperations[operation] && !unavailable {
+			// The endpoint may have signed, so another endpoint must not.
+			break
+		}
+	}
+
+	return nil, err
+}
+
+// availableEndpoints returns the endpoints in the order in which they should
+// be tried, skipping those that are down.  If all endpoints are down they are
+// all returned, as a down endpoint may have recovered since it was last checked.
+func (w *wallet) availableEndpoints() []*Endpoint {
+	endpoints := w.endpointSelector.order()
+	available := make([]*Endpoint, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		if !w.endpointDown(endpoint) {
+			available = append(available, endpoint)
+		}
+	}
+	if len(available) == 0 {
+		return endpoints
+	}
+
+	return available
+}
+
+// multisignState returns a single state for a multiple signing response: the
+// first state that is not success, or success if all requests succeeded.
+func multisignState(resp *pb.MultisignResponse) pb.ResponseState {
+	if len(resp.GetResponses()) == 0 {
+		return pb.ResponseState_UNKNOWN
+	}
+	for _, response := range resp.GetResponses() {
+		if response.GetState() != pb.ResponseState_SUCCEEDED {
+			return response.GetState()
+		}
+	}
+
+	return pb.ResponseState_SUCCEEDED
+}
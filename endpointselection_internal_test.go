@@ -0,0 +1,91 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	mock "github.com/wealdtech/go-eth2-wallet-dirk/mock"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestEndpointSelectorOrder(t *testing.T) {
+	endpoints := []*Endpoint{
+		{host: "host1", port: 1},
+		{host: "host2", port: 2},
+		{host: "host3", port: 3},
+	}
+
+	ordered := newEndpointSelector(EndpointSelectionOrdered, endpoints)
+	for range 3 {
+		require.Equal(t, endpoints, ordered.order())
+	}
+
+	roundRobin := newEndpointSelector(EndpointSelectionRoundRobin, endpoints)
+	require.Equal(t, []*Endpoint{endpoints[0], endpoints[1], endpoints[2]}, roundRobin.order())
+	require.Equal(t, []*Endpoint{endpoints[1], endpoints[2], endpoints[0]}, roundRobin.order())
+	require.Equal(t, []*Endpoint{endpoints[2], endpoints[0], endpoints[1]}, roundRobin.order())
+	require.Equal(t, []*Endpoint{endpoints[0], endpoints[1], endpoints[2]}, roundRobin.order())
+
+	leastLatency := newEndpointSelector(EndpointSelectionLeastLatency, endpoints)
+	require.Equal(t, endpoints, leastLatency.order())
+	leastLatency.observe(endpoints[0], 50*time.Millisecond, nil)
+	leastLatency.observe(endpoints[1], 10*time.Millisecond, nil)
+	// Endpoint 3 has no latency recorded, so is tried first.
+	require.Equal(t, []*Endpoint{endpoints[2], endpoints[1], endpoints[0]}, leastLatency.order())
+	leastLatency.observe(endpoints[2], time.Millisecond, errors.New("failed"))
+	require.Equal(t, []*Endpoint{endpoints[1], endpoints[0], endpoints[2]}, leastLatency.order())
+
+	// Latencies assumed when hedging only affect the ordering of participants.
+	leastLatency.observeHedged(endpoints[1], time.Second)
+	require.Equal(t, []*Endpoint{endpoints[1], endpoints[0], endpoints[2]}, leastLatency.order())
+	require.Equal(t, time.Second, leastLatency.participantLatency(endpoints[1]))
+	require.Equal(t, 50*time.Millisecond, leastLatency.participantLatency(endpoints[0]))
+	leastLatency.observe(endpoints[1], 10*time.Millisecond, nil)
+	require.Equal(t, 10*time.Millisecond, leastLatency.participantLatency(endpoints[1]))
+}
+
+func TestEndpointSelectionParameter(t *testing.T) {
+	ctx := context.Background()
+	_, err := Open(ctx,
+		WithName("Test wallet"),
+		WithCredentials(credentials.NewTLS(nil)),
+		WithEndpoints([]*Endpoint{{host: "localhost", port: 12345}}),
+		WithEndpointSelection(EndpointSelection(99)),
+	)
+	require.EqualError(t, err, "problem with parameters: unknown endpoint selection specified")
+}
+
+func TestListFailover(t *testing.T) {
+	require.NoError(t, e2types.InitBLS())
+	ctx := context.Background()
+	// Endpoints are served by the server at index (port % 2).
+	connectionProvider, err := NewBufConnectionProvider(ctx, []pb.ListerServer{&mock.ErroringListerServer{}, &mock.MockListerServer{}})
+	require.NoError(t, err)
+	w, err := OpenWallet(ctx, "Test wallet", credentials.NewTLS(nil), []*Endpoint{
+		{host: "localhost", port: 12344},
+		{host: "localhost", port: 12345},
+	})
+	require.NoError(t, err)
+	w.(*wallet).SetConnectionProvider(connectionProvider)
+	accounts, err := w.(*wallet).List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, accounts, 8)
+}
@@ -0,0 +1,300 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+)
+
+var (
+	// ErrDenied is matched by errors returned when Dirk denies a request,
+	// for example due to slashing protection.
+	ErrDenied = errors.New("request denied")
+	// ErrFailed is matched by errors returned when Dirk fails to process a request.
+	ErrFailed = errors.New("request failed")
+	// ErrUnknownState is matched by errors returned when Dirk returns an unknown state for a request.
+	ErrUnknownState = errors.New("request returned unknown state")
+	// ErrInsufficientSignatures is matched by errors returned when not enough
+	// signature shares are obtained to recover a composite signature.
+	ErrInsufficientSignatures = errors.New("not enough signatures")
+	// ErrTransport is matched by errors returned when Dirk cannot be contacted.
+	ErrTransport = errors.New("transport error")
+	// ErrInvalidCompositeSignature is matched by errors returned when a
+	// composite signature fails verification.
+	ErrInvalidCompositeSignature = errors.New("composite signature invalid")
+	// ErrDeadlinePassed is matched by errors returned when a request to sign
+	// a duty is made after the end of the duty's slot.
+	ErrDeadlinePassed = errors.New("duty deadline passed")
+	// ErrSlashable is matched by errors returned when local slashing
+	// protection refuses a request.
+	ErrSlashable = errors.New("request slashable")
+	// ErrInvalidAccount is matched by errors returned when an account
+	// listed by Dirk is malformed.
+	ErrInvalidAccount = errors.New("invalid account")
+)
+
+// CompositeSignatureError is returned when a composite signature recovered
+// from signature shares fails verification against the composite public key
+// of its account.
+type CompositeSignatureError struct {
+	// Account is the name of the account.
+	Account string
+	// Root is the signing root over which the signature was generated.
+	Root []byte
+}
+
+// Error implements the error interface.
+func (e *CompositeSignatureError) Error() string {
+	return fmt.Sprintf("composite signature for %s failed verification against root %#x", e.Account, e.Root)
+}
+
+// Is returns true if the target is ErrInvalidCompositeSignature.
+func (*CompositeSignatureError) Is(target error) bool {
+	return target == ErrInvalidCompositeSignature
+}
+
+// DeniedError is returned when Dirk denies a request.
+type DeniedError struct {
+	// Operation is the operation that was denied.
+	Operation string
+	// Endpoint is the endpoint that denied the request.
+	Endpoint string
+	// Message is the message returned by the endpoint, if any.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *DeniedError) Error() string {
+	return withMessage(fmt.Sprintf("request to %s denied", e.Operation), e.Message)
+}
+
+// Is returns true if the target is ErrDenied.
+func (*DeniedError) Is(target error) bool {
+	return target == ErrDenied
+}
+
+// FailedError is returned when Dirk fails to process a request.
+type FailedError struct {
+	// Operation is the operation that failed.
+	Operation string
+	// Endpoint is the endpoint that failed the request.
+	Endpoint string
+	// Message is the message returned by the endpoint, if any.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *FailedError) Error() string {
+	return withMessage(fmt.Sprintf("request to %s failed", e.Operation), e.Message)
+}
+
+// Is returns true if the target is ErrFailed.
+func (*FailedError) Is(target error) bool {
+	return target == ErrFailed
+}
+
+// UnknownStateError is returned when Dirk returns an unknown state for a request.
+type UnknownStateError struct {
+	// Operation is the operation that returned the unknown state.
+	Operation string
+	// Endpoint is the endpoint that returned the unknown state.
+	Endpoint string
+	// Message is the message returned by the endpoint, if any.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *UnknownStateError) Error() string {
+	return withMessage(fmt.Sprintf("request to %s returned unknown state", e.Operation), e.Message)
+}
+
+// Is returns true if the target is ErrUnknownState.
+func (*UnknownStateError) Is(target error) bool {
+	return target == ErrUnknownState
+}
+
+// TransportError is returned when an endpoint cannot be contacted, or a
+// request to it fails without returning a response.
+type TransportError struct {
+	// Endpoint is the endpoint that could not be contacted.
+	Endpoint string
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *TransportError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if the target is ErrTransport.
+func (*TransportError) Is(target error) bool {
+	return target == ErrTransport
+}
+
+// ThresholdError is returned when not enough signature shares are obtained
+// from the participants of a distributed account to recover a composite
+// signature.  The number of participants with each outcome is the length of
+// the relevant list of participant IDs.
+type ThresholdError struct {
+	// Required is the number of signature shares required.
+	Required int
+	// Signed are the IDs of participants that returned valid signature shares.
+	Signed []uint64
+	// Denied are the IDs of participants that denied the request.
+	Denied []uint64
+	// Failed are the IDs of participants that failed, or returned an unknown state for, the request.
+	Failed []uint64
+	// Errored are the IDs of participants that could not be contacted.
+	Errored []uint64
+	// Invalid are the IDs of participants that returned invalid signature shares.
+	Invalid []uint64
+	// Report is the outcome of the request for each participant.
+	Report *SigningReport
+}
+
+// Error implements the error interface.
+func (e *ThresholdError) Error() string {
+	return fmt.Sprintf("not enough signatures: %d signed, %d denied, %d failed, %d errored, %d invalid",
+		len(e.Signed), len(e.Denied), len(e.Failed), len(e.Errored), len(e.Invalid))
+}
+
+// Is returns true if the target is ErrInsufficientSignatures, or if the
+// target is ErrDenied and at least one participant denied the request.
+func (e *ThresholdError) Is(target error) bool {
+	switch target {
+	case ErrInsufficientSignatures:
+		return true
+	case ErrDenied:
+		return len(e.Denied) > 0
+	default:
+		return false
+	}
+}
+
+// InvalidAccountError is returned for an account listed by Dirk that is
+// malformed, for example with an invalid public key, UUID or participants.
+type InvalidAccountError struct {
+	// Name is the name of the account as listed.
+	Name string
+	// Err is the reason the account is malformed.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *InvalidAccountError) Error() string {
+	return fmt.Sprintf("account %s invalid: %v", e.Name, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *InvalidAccountError) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if the target is ErrInvalidAccount.
+func (*InvalidAccountError) Is(target error) bool {
+	return target == ErrInvalidAccount
+}
+
+// AccountListError is returned when some of the accounts listed by Dirk are
+// malformed.  It is returned alongside the accounts that are valid.
+type AccountListError struct {
+	// Invalid are the errors for the malformed accounts.
+	Invalid []*InvalidAccountError
+}
+
+// Error implements the error interface.
+func (e *AccountListError) Error() string {
+	msgs := make([]string, len(e.Invalid))
+	for i := range e.Invalid {
+		msgs[i] = e.Invalid[i].Error()
+	}
+
+	return fmt.Sprintf("%d invalid accounts: %s", len(e.Invalid), strings.Join(msgs, "; "))
+}
+
+// Is returns true if the target is ErrInvalidAccount.
+func (*AccountListError) Is(target error) bool {
+	return target == ErrInvalidAccount
+}
+
+// ListingDisagreementError is the reason an account is invalid when the
+// endpoints that list it disagree on its details, or too few endpoints list it.
+type ListingDisagreementError struct {
+	// Attribute is the attribute on which the endpoints disagree: "type",
+	// "public key", "composite public key", "signing threshold",
+	// "participants", or "presence" if fewer than the listing quorum of
+	// endpoints list the account.
+	Attribute string
+	// Endpoints are the endpoints that list the account.
+	Endpoints []string
+}
+
+// Error implements the error interface.
+func (e *ListingDisagreementError) Error() string {
+	return fmt.Sprintf("endpoints %s disagree on %s", strings.Join(e.Endpoints, ", "), e.Attribute)
+}
+
+// isAccountListError returns true if the error reports malformed accounts,
+// in which case the valid accounts returned alongside it can be used.
+func isAccountListError(err error) bool {
+	var listErr *AccountListError
+
+	return errors.As(err, &listErr)
+}
+
+// stateError returns a typed error for a request that did not succeed.
+func stateError(operation string, endpoint *Endpoint, state pb.ResponseState, message string) error {
+	var endpointStr string
+	if endpoint != nil {
+		endpointStr = endpoint.String()
+	}
+
+	switch state {
+	case pb.ResponseState_DENIED:
+		return &DeniedError{Operation: operation, Endpoint: endpointStr, Message: message}
+	case pb.ResponseState_FAILED:
+		return &FailedError{Operation: operation, Endpoint: endpointStr, Message: message}
+	default:
+		return &UnknownStateError{Operation: operation, Endpoint: endpointStr, Message: message}
+	}
+}
+
+// transportError returns a typed error for a request that could not be made.
+func transportError(endpoint *Endpoint, err error) error {
+	var endpointStr string
+	if endpoint != nil {
+		endpointStr = endpoint.String()
+	}
+
+	return &TransportError{Endpoint: endpointStr, Err: err}
+}
+
+// withMessage appends a message to an error string, if present.
+func withMessage(msg string, message string) string {
+	if message == "" {
+		return msg
+	}
+
+	return fmt.Sprintf("%s: %s", msg, message)
+}
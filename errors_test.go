@@ -0,0 +1,130 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk_test
+
+import (
+	"errors"
+	"testing"
+
+	pkgerrors "github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+)
+
+func TestCompositeSignatureError(t *testing.T) {
+	err := pkgerrors.Wrap(&dirk.CompositeSignatureError{
+		Account: "Test account",
+		Root:    []byte{0x01, 0x02},
+	}, "failed to obtain signature")
+	require.EqualError(t, err, "failed to obtain signature: composite signature for Test account failed verification against root 0x0102")
+	require.True(t, errors.Is(err, dirk.ErrInvalidCompositeSignature))
+
+	var compositeSignatureErr *dirk.CompositeSignatureError
+	require.True(t, errors.As(err, &compositeSignatureErr))
+	require.Equal(t, "Test account", compositeSignatureErr.Account)
+}
+
+func TestStateErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		msg      string
+		sentinel error
+	}{
+		{
+			name:     "Denied",
+			err:      &dirk.DeniedError{Operation: "obtain signature", Endpoint: "host:12345"},
+			msg:      "request to obtain signature denied",
+			sentinel: dirk.ErrDenied,
+		},
+		{
+			name:     "DeniedWithMessage",
+			err:      &dirk.DeniedError{Operation: "generate account", Message: "not permitted"},
+			msg:      "request to generate account denied: not permitted",
+			sentinel: dirk.ErrDenied,
+		},
+		{
+			name:     "Failed",
+			err:      &dirk.FailedError{Operation: "obtain signatures"},
+			msg:      "request to obtain signatures failed",
+			sentinel: dirk.ErrFailed,
+		},
+		{
+			name:     "UnknownState",
+			err:      &dirk.UnknownStateError{Operation: "obtain signature"},
+			msg:      "request to obtain signature returned unknown state",
+			sentinel: dirk.ErrUnknownState,
+		},
+		{
+			name:     "Transport",
+			err:      &dirk.TransportError{Endpoint: "host:12345", Err: errors.New("connection refused")},
+			msg:      "connection refused",
+			sentinel: dirk.ErrTransport,
+		},
+	}
+
+	sentinels := []error{dirk.ErrDenied, dirk.ErrFailed, dirk.ErrUnknownState, dirk.ErrTransport, dirk.ErrInsufficientSignatures}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := pkgerrors.Wrap(test.err, "failed to obtain signature")
+			require.EqualError(t, err, "failed to obtain signature: "+test.msg)
+			for _, sentinel := range sentinels {
+				require.Equal(t, sentinel == test.sentinel, errors.Is(err, sentinel), sentinel.Error())
+			}
+		})
+	}
+}
+
+func TestThresholdError(t *testing.T) {
+	err := pkgerrors.Wrap(&dirk.ThresholdError{
+		Required: 3,
+		Signed:   []uint64{1, 2},
+		Denied:   []uint64{3},
+		Errored:  []uint64{4, 5},
+	}, "failed to obtain signature")
+	require.EqualError(t, err, "failed to obtain signature: not enough signatures: 2 signed, 1 denied, 0 failed, 2 errored, 0 invalid")
+	require.True(t, errors.Is(err, dirk.ErrInsufficientSignatures))
+	require.True(t, errors.Is(err, dirk.ErrDenied))
+	require.False(t, errors.Is(err, dirk.ErrTransport))
+
+	var thresholdErr *dirk.ThresholdError
+	require.True(t, errors.As(err, &thresholdErr))
+	require.Equal(t, []uint64{4, 5}, thresholdErr.Errored)
+
+	err = &dirk.ThresholdError{
+		Required: 3,
+		Signed:   []uint64{1, 2},
+		Failed:   []uint64{3},
+	}
+	require.True(t, errors.Is(err, dirk.ErrInsufficientSignatures))
+	require.False(t, errors.Is(err, dirk.ErrDenied))
+}
+
+func TestAccountListError(t *testing.T) {
+	cause := errors.New("no participants")
+	err := pkgerrors.Wrap(&dirk.AccountListError{
+		Invalid: []*dirk.InvalidAccountError{
+			{Name: "Wallet/Account 1", Err: errors.New("public key 0x01 invalid")},
+			{Name: "Wallet/Account 2", Err: cause},
+		},
+	}, "failed to obtain accounts")
+	require.EqualError(t, err, "failed to obtain accounts: 2 invalid accounts: account Wallet/Account 1 invalid: public key 0x01 invalid; account Wallet/Account 2 invalid: no participants")
+	require.True(t, errors.Is(err, dirk.ErrInvalidAccount))
+
+	var listErr *dirk.AccountListError
+	require.True(t, errors.As(err, &listErr))
+	require.Len(t, listErr.Invalid, 2)
+	require.True(t, errors.Is(listErr.Invalid[1], dirk.ErrInvalidAccount))
+	require.True(t, errors.Is(listErr.Invalid[1], cause))
+}
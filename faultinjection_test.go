@@ -0,0 +1,293 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	mock "github.com/wealdtech/go-eth2-wallet-dirk/mock"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestThresholdSignFaults(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 5)
+	_, err := cluster.AddDistributedAccount("Wallet", "Account", 5, 3, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithTimeout(2*time.Second))
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+
+	denied := pb.ResponseState_DENIED
+	unavailable := status.Error(codes.Unavailable, "connection dropped")
+
+	tests := []struct {
+		name   string
+		faults []*mock.Fault
+		err    string
+	}{
+		{
+			name: "None",
+		},
+		{
+			name: "SlowMinority",
+			faults: []*mock.Fault{
+				{Endpoint: "signer-test01:12001", Method: "Sign", Latency: time.Minute},
+				{Endpoint: "signer-test02:12002", Method: "Sign", Latency: time.Minute},
+			},
+		},
+		{
+			name: "SlowMajority",
+			faults: []*mock.Fault{
+				{Endpoint: "signer-test01:12001", Method: "Sign", Latency: time.Minute},
+				{Endpoint: "signer-test02:12002", Method: "Sign", Latency: time.Minute},
+				{Endpoint: "signer-test03:12003", Method: "Sign", Latency: time.Minute},
+			},
+			err: "failed to obtain signature: context done: context deadline exceeded",
+		},
+		{
+			name: "DroppedMinority",
+			faults: []*mock.Fault{
+				{Endpoint: "signer-test04:12004", Err: unavailable},
+				{Endpoint: "signer-test05:12005", Err: unavailable},
+			},
+		},
+		{
+			name: "DroppedMajority",
+			faults: []*mock.Fault{
+				{Endpoint: "signer-test03:12003", Method: "Sign", Err: unavailable},
+				{Endpoint: "signer-test04:12004", Method: "Sign", Err: unavailable},
+				{Endpoint: "signer-test05:12005", Method: "Sign", Err: unavailable},
+			},
+			err: "failed to obtain signature: not enough signatures: 2 signed, 0 denied, 0 failed, 3 errored, 0 invalid",
+		},
+		{
+			name: "CorruptMinority",
+			faults: []*mock.Fault{
+				{Endpoint: "signer-test01:12001", CorruptSignatures: true},
+				{Endpoint: "signer-test05:12005", CorruptSignatures: true},
+			},
+		},
+		{
+			name: "CorruptMajority",
+			faults: []*mock.Fault{
+				{Endpoint: "signer-test01:12001", CorruptSignatures: true},
+				{Endpoint: "signer-test02:12002", CorruptSignatures: true},
+				{Endpoint: "signer-test05:12005", CorruptSignatures: true},
+			},
+			err: "failed to obtain signature: not enough signatures: 2 signed, 0 denied, 0 failed, 0 errored, 3 invalid",
+		},
+		{
+			name: "DeniedMajority",
+			faults: []*mock.Fault{
+				{Endpoint: "signer-test02:12002", Method: "Sign", State: &denied},
+				{Endpoint: "signer-test03:12003", Method: "Sign", State: &denied},
+				{Endpoint: "signer-test04:12004", Method: "Sign", State: &denied},
+			},
+			err: "failed to obtain signature: not enough signatures: 2 signed, 3 denied, 0 failed, 0 errored, 0 invalid",
+		},
+		{
+			name: "Mixed",
+			faults: []*mock.Fault{
+				{Endpoint: "signer-test01:12001", Method: "Sign", Latency: time.Minute},
+				{Endpoint: "signer-test02:12002", Method: "Sign", CorruptSignatures: true},
+				{Endpoint: "signer-test03:12003", Method: "Sign", State: &denied},
+				{Endpoint: "signer-test04:12004", Method: "Sign", Err: unavailable},
+			},
+			err: "failed to obtain signature: context done: context deadline exceeded",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			faultInjector := mock.NewFaultInjector(1)
+			for _, fault := range test.faults {
+				faultInjector.Add(fault)
+			}
+			cluster.SetFaultInjector(faultInjector)
+			defer cluster.SetFaultInjector(nil)
+
+			data := bytes.Repeat([]byte{byte(i)}, 32)
+			domain := bytes.Repeat([]byte{0x03}, 32)
+			sig, err := account.(e2wtypes.AccountProtectingSigner).SignGeneric(ctx, data, domain)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.True(t, sig.Verify(genericSigningRoot(data, domain), account.(e2wtypes.AccountCompositePublicKeyProvider).CompositePublicKey()))
+			}
+		})
+	}
+}
+
+func TestThresholdMultiSignFaults(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddDistributedAccount("Wallet", "Account 1", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithTimeout(2*time.Second))
+	account1, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+	account2, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 2")
+	require.NoError(t, err)
+
+	faultInjector := mock.NewFaultInjector(1)
+	faultInjector.Add(&mock.Fault{Endpoint: "signer-test01:12001", Method: "Multisign", CorruptSignatures: true})
+	faultInjector.Add(&mock.Fault{Endpoint: "signer-test02:12002", Method: "Multisign", Err: status.Error(codes.Unavailable, "connection dropped")})
+	cluster.SetFaultInjector(faultInjector)
+
+	data := [][]byte{
+		bytes.Repeat([]byte{0x01}, 32),
+		bytes.Repeat([]byte{0x02}, 32),
+	}
+	domain := bytes.Repeat([]byte{0x03}, 32)
+	sigs, err := account1.(e2wtypes.AccountProtectingMultiSigner).SignGenericMulti(ctx, []e2wtypes.Account{account1, account2}, data, domain)
+	require.NoError(t, err)
+	require.Len(t, sigs, 2)
+	// Only one participant returned valid signature shares, so no signatures can be recovered.
+	require.Nil(t, sigs[0])
+	require.Nil(t, sigs[1])
+
+	// Remove the dropped connections, leaving enough valid signature shares.
+	faultInjector.Clear()
+	faultInjector.Add(&mock.Fault{Endpoint: "signer-test01:12001", Method: "Multisign", CorruptSignatures: true})
+	sigs, err = account1.(e2wtypes.AccountProtectingMultiSigner).SignGenericMulti(ctx, []e2wtypes.Account{account1, account2}, data, domain)
+	require.NoError(t, err)
+	require.Len(t, sigs, 2)
+	require.True(t, sigs[0].Verify(genericSigningRoot(data[0], domain), account1.(e2wtypes.AccountCompositePublicKeyProvider).CompositePublicKey()))
+	require.True(t, sigs[1].Verify(genericSigningRoot(data[1], domain), account2.(e2wtypes.AccountCompositePublicKeyProvider).CompositePublicKey()))
+}
+
+func TestSlashableFailover(t *testing.T) {
+	ctx := context.Background()
+	domain := bytes.Repeat([]byte{0x04}, 32)
+
+	tests := []struct {
+		name string
+		err  error
+		// failover is true if the request is expected to be passed to the second endpoint.
+		failover bool
+	}{
+		{
+			name:     "Unavailable",
+			err:      status.Error(codes.Unavailable, "connection dropped"),
+			failover: true,
+		},
+		{
+			name: "Internal",
+			err:  status.Error(codes.Internal, "connection reset"),
+		},
+		{
+			name: "DeadlineExceeded",
+			err:  status.Error(codes.DeadlineExceeded, "deadline exceeded"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := newCluster(t, 2)
+			_, err := cluster.AddAccount("Wallet", "Account", []byte("pass"))
+			require.NoError(t, err)
+			wallet := openClusterWallet(ctx, t, cluster, "Wallet")
+			account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+			require.NoError(t, err)
+
+			faultInjector := mock.NewFaultInjector(1)
+			faultInjector.Add(&mock.Fault{Endpoint: "signer-test01:12001", Err: test.err})
+			cluster.SetFaultInjector(faultInjector)
+
+			// Non-slashable requests always fail over.
+			_, err = account.(e2wtypes.AccountProtectingSigner).SignGeneric(ctx, bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x03}, 32))
+			require.NoError(t, err)
+
+			// Slashable requests only fail over if the first endpoint was unavailable.
+			_, err = account.(e2wtypes.AccountProtectingSigner).SignBeaconProposal(ctx,
+				1,
+				1,
+				bytes.Repeat([]byte{0x01}, 32),
+				bytes.Repeat([]byte{0x02}, 32),
+				bytes.Repeat([]byte{0x03}, 32),
+				domain,
+			)
+			_, attestationErr := account.(e2wtypes.AccountProtectingSigner).SignBeaconAttestation(ctx,
+				1,
+				0,
+				bytes.Repeat([]byte{0x01}, 32),
+				0,
+				bytes.Repeat([]byte{0x02}, 32),
+				1,
+				bytes.Repeat([]byte{0x03}, 32),
+				domain,
+			)
+			if test.failover {
+				require.NoError(t, err)
+				require.NoError(t, attestationErr)
+				require.Equal(t, uint64(1), cluster.Server(2).Requests("SignBeaconProposal"))
+				require.Equal(t, uint64(1), cluster.Server(2).Requests("SignBeaconAttestation"))
+			} else {
+				require.ErrorContains(t, err, test.err.Error())
+				require.ErrorContains(t, attestationErr, test.err.Error())
+				require.Equal(t, uint64(0), cluster.Server(2).Requests("SignBeaconProposal"))
+				require.Equal(t, uint64(0), cluster.Server(2).Requests("SignBeaconAttestation"))
+			}
+		})
+	}
+}
+
+func TestFaultInjectionDeterministic(t *testing.T) {
+	ctx := context.Background()
+
+	// outcomes returns the success or failure of a series of signing requests
+	// made with a fault injector with the given seed.
+	outcomes := func(seed int64) []bool {
+		cluster := newCluster(t, 1)
+		_, err := cluster.AddAccount("Wallet", "Account", []byte("pass"))
+		require.NoError(t, err)
+		wallet := openClusterWallet(ctx, t, cluster, "Wallet")
+		account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+		require.NoError(t, err)
+
+		faultInjector := mock.NewFaultInjector(seed)
+		faultInjector.Add(&mock.Fault{
+			Method:      "Sign",
+			Probability: 0.5,
+			Err:         status.Error(codes.Unavailable, "connection dropped"),
+		})
+		cluster.SetFaultInjector(faultInjector)
+
+		res := make([]bool, 32)
+		for i := range res {
+			_, err := account.(e2wtypes.AccountProtectingSigner).SignGeneric(ctx, bytes.Repeat([]byte{byte(i)}, 32), bytes.Repeat([]byte{0x03}, 32))
+			res[i] = err == nil
+		}
+
+		return res
+	}
+
+	first := outcomes(1)
+	require.Contains(t, first, true)
+	require.Contains(t, first, false)
+	require.Equal(t, first, outcomes(1))
+	require.NotEqual(t, first, outcomes(2))
+}
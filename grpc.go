@@ -14,6 +14,7 @@
 package dirk
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
@@ -21,8 +22,10 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/herumi/bls-eth-go-binary/bls"
@@ -62,6 +65,7 @@ func ComposeCredentials(ctx context.Context, certPath string, keyPath string, ca
 }
 
 // Credentials composes a set of transport credentials given a client certificate and an optional CA certificate.
+// The returned credentials implement CertificateInfo.
 func Credentials(_ context.Context, clientCert []byte, clientKey []byte, caCert []byte) (credentials.TransportCredentials, error) {
 	clientPair, err := tls.X509KeyPair(clientCert, clientKey)
 	if err != nil {
@@ -73,17 +77,33 @@ func Credentials(_ context.Context, clientCert []byte, clientKey []byte, caCert
 		MinVersion:   tls.VersionTLS13,
 	}
 
+	clientX509Cert, err := x509.ParseCertificate(clientPair.Certificate[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse client certificate")
+	}
+
+	var caX509Certs []*x509.Certificate
 	if caCert != nil {
 		cp := x509.NewCertPool()
 		if !cp.AppendCertsFromPEM(caCert) {
 			return nil, errors.New("failed to add CA certificate")
 		}
 		tlsCfg.RootCAs = cp
+		caX509Certs = parseCertificates(caCert)
 	}
 
-	return credentials.NewTLS(tlsCfg), nil
+	return &tlsCredentials{
+		TransportCredentials: credentials.NewTLS(tlsCfg),
+		clientCert:           clientX509Cert,
+		caCerts:              caX509Certs,
+	}, nil
 }
 
+// List lists the accounts in the wallet that match the path.  Accounts that
+// are malformed are omitted, and reported by an *AccountListError returned
+// alongside the valid accounts.  Distributed accounts are malformed if the
+// public key shares of their participants cannot be obtained, or do not
+// recover their composite public key.
 func (w *wallet) List(ctx context.Context, accountPath string) ([]e2wtypes.Account, error) {
 	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "List", trace.WithAttributes(
 		attribute.String("wallet", w.Name()),
@@ -102,44 +122,43 @@ func (w *wallet) List(ctx context.Context, accountPath string) ([]e2wtypes.Accou
 		return nil, errors.New("wallet has no endpoints")
 	}
 
+	req := &pb.ListAccountsRequest{
+		Paths: []string{
+			path,
+		},
+	}
 	var resp *pb.ListAccountsResponse
-	var err error
+	invalid := make([]*InvalidAccountError, 0)
 	ctx, cancelFunc := context.WithTimeout(ctx, w.timeout)
 	defer cancelFunc()
-	for i := range len(w.endpoints) {
-		var conn *grpc.ClientConn
-		var release func()
-		conn, release, err = w.connectionProvider.Connection(ctx, w.endpoints[i])
+	if w.listingQuorum > 0 {
+		var err error
+		resp, invalid, err = w.listQuorum(ctx, req)
 		if err != nil {
-			w.log.Debug().Stringer("endpoint", w.endpoints[i]).Str("path", path).Err(err).Msg("Failed to obtain connection")
-			continue
+			return nil, errors.Wrap(err, "failed to access dirk")
 		}
+	} else {
+		endpoint, err := w.tryEndpoints(ctx, "list", func(ctx context.Context, conn *grpc.ClientConn) (pb.ResponseState, error) {
+			var err error
+			resp, err = pb.NewListerClient(conn).ListAccounts(ctx, req)
 
-		listerClient := pb.NewListerClient(conn)
-		req := &pb.ListAccountsRequest{
-			Paths: []string{
-				path,
-			},
+			return resp.GetState(), err
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to access dirk")
 		}
-		resp, err = listerClient.ListAccounts(ctx, req)
-		release()
-		if err == nil {
-			// Success.
-			break
+		if resp.GetState() != pb.ResponseState_SUCCEEDED {
+			return nil, stateError("list wallet accounts", endpoint, resp.GetState(), "")
 		}
-		w.log.Debug().Stringer("endpoint", w.endpoints[i]).Str("path", path).Err(err).Msg("Failed to list accounts")
-	}
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to access dirk")
-	}
-	if resp.GetState() != pb.ResponseState_SUCCEEDED {
-		return nil, fmt.Errorf("request to list wallet accounts returned state %v", resp.GetState())
 	}
 	span.AddEvent("Obtained accounts")
 
 	// sem := semaphore.NewWeighted(int64(runtime.GOMAXPROCS(0)))
 	var wg sync.WaitGroup
 	accounts := make([]e2wtypes.Account, 0)
+	// Distributed accounts are also tracked, along with their listed names, to obtain their public key shares.
+	distributedAccounts := make([]*distributedAccount, 0)
+	distributedAccountNames := make(map[*distributedAccount]string)
 	var accountsMu sync.Mutex
 	for _, respAccount := range resp.GetAccounts() {
 		wg.Add(1)
@@ -147,12 +166,13 @@ func (w *wallet) List(ctx context.Context, accountPath string) ([]e2wtypes.Accou
 			defer wg.Done()
 
 			account, err := w.obtainAccount(respAccount)
-			if err != nil {
-				w.log.Error().Err(err).Msg("Failed to obtain account")
-			}
 
 			mu.Lock()
-			accounts = append(accounts, account)
+			if err != nil {
+				invalid = append(invalid, &InvalidAccountError{Name: respAccount.GetName(), Err: err})
+			} else {
+				accounts = append(accounts, account)
+			}
 			mu.Unlock()
 		}(respAccount, &wg, &accountsMu)
 	}
@@ -162,18 +182,47 @@ func (w *wallet) List(ctx context.Context, accountPath string) ([]e2wtypes.Accou
 			defer wg.Done()
 
 			account, err := w.obtainDistributedAccount(respAccount)
-			if err != nil {
-				w.log.Error().Err(err).Msg("Failed to obtain distributed account")
-			}
 
 			mu.Lock()
-			accounts = append(accounts, account)
+			if err != nil {
+				invalid = append(invalid, &InvalidAccountError{Name: respAccount.GetName(), Err: err})
+			} else {
+				accounts = append(accounts, account)
+				if distributedAccount, isDistributed := account.(*distributedAccount); isDistributed {
+					distributedAccounts = append(distributedAccounts, distributedAccount)
+					distributedAccountNames[distributedAccount] = respAccount.GetName()
+				}
+			}
 			mu.Unlock()
 		}(respAccount, &wg, &accountsMu)
 	}
 	wg.Wait()
 	span.AddEvent("Processed accounts")
 
+	if len(distributedAccounts) > 0 {
+		shareKeyErrs := w.obtainShareKeys(ctx, distributedAccounts)
+		if len(shareKeyErrs) > 0 {
+			validAccounts := make([]e2wtypes.Account, 0, len(accounts))
+			for _, account := range accounts {
+				distributedAccount, isDistributed := account.(*distributedAccount)
+				if !isDistributed || shareKeyErrs[distributedAccount] == nil {
+					validAccounts = append(validAccounts, account)
+
+					continue
+				}
+				invalid = append(invalid, &InvalidAccountError{Name: distributedAccountNames[distributedAccount], Err: shareKeyErrs[distributedAccount]})
+			}
+			accounts = validAccounts
+		}
+		span.AddEvent("Obtained public key shares")
+	}
+
+	if len(invalid) > 0 {
+		sort.Slice(invalid, func(i, j int) bool { return invalid[i].Name < invalid[j].Name })
+
+		return accounts, &AccountListError{Invalid: invalid}
+	}
+
 	return accounts, nil
 }
 
@@ -185,25 +234,24 @@ func (w *wallet) UnlockAccount(ctx context.Context, accountName string, passphra
 	))
 	defer span.End()
 
-	conn, release, err := w.connectionProvider.Connection(ctx, w.endpoints[0])
-	if err != nil {
-		return false, errors.Wrap(err, "failed to connect to endpoint")
-	}
-	defer release()
-
-	accountManagerClient := pb.NewAccountManagerClient(conn)
 	req := &pb.UnlockAccountRequest{
 		Account:    fmt.Sprintf("%s/%s", w.Name(), accountName),
 		Passphrase: passphrase,
 	}
+	var resp *pb.UnlockAccountResponse
 	ctx, cancelFunc := context.WithTimeout(ctx, w.timeout)
 	defer cancelFunc()
-	resp, err := accountManagerClient.Unlock(ctx, req)
+	endpoint, err := w.tryEndpoints(ctx, "unlock", func(ctx context.Context, conn *grpc.ClientConn) (pb.ResponseState, error) {
+		var err error
+		resp, err = pb.NewAccountManagerClient(conn).Unlock(ctx, req)
+
+		return resp.GetState(), err
+	})
 	if err != nil {
 		return false, errors.Wrap(err, "failed to access dirk")
 	}
 	if resp.GetState() == pb.ResponseState_FAILED {
-		return false, errors.New("request to unlock account failed")
+		return false, stateError("unlock account", endpoint, resp.GetState(), "")
 	}
 
 	return resp.GetState() == pb.ResponseState_SUCCEEDED, nil
@@ -217,24 +265,23 @@ func (w *wallet) LockAccount(ctx context.Context, accountName string) error {
 	))
 	defer span.End()
 
-	conn, release, err := w.connectionProvider.Connection(ctx, w.endpoints[0])
-	if err != nil {
-		return errors.Wrap(err, "failed to connect to endpoint")
-	}
-	defer release()
-
-	accountManagerClient := pb.NewAccountManagerClient(conn)
 	req := &pb.LockAccountRequest{
 		Account: fmt.Sprintf("%s/%s", w.Name(), accountName),
 	}
+	var resp *pb.LockAccountResponse
 	ctx, cancelFunc := context.WithTimeout(ctx, w.timeout)
 	defer cancelFunc()
-	resp, err := accountManagerClient.Lock(ctx, req)
+	endpoint, err := w.tryEndpoints(ctx, "lock", func(ctx context.Context, conn *grpc.ClientConn) (pb.ResponseState, error) {
+		var err error
+		resp, err = pb.NewAccountManagerClient(conn).Lock(ctx, req)
+
+		return resp.GetState(), err
+	})
 	if err != nil {
 		return errors.Wrap(err, "failed to access dirk")
 	}
 	if resp.GetState() == pb.ResponseState_FAILED {
-		return errors.New("request to lock account failed")
+		return stateError("lock account", endpoint, resp.GetState(), "")
 	}
 
 	return nil
@@ -264,29 +311,21 @@ func (a *account) SignGRPC(ctx context.Context,
 		Domain: domain,
 	}
 
-	conn, release, err := a.wallet.connectionProvider.Connection(ctx, a.wallet.endpoints[0])
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to connect to endpoint")
-	}
-	defer release()
-
-	client := pb.NewSignerClient(conn)
-	if client == nil {
-		return nil, errors.New("failed to set up signing client")
-	}
+	var resp *pb.SignResponse
 	ctx, cancelFunc := context.WithTimeout(ctx, a.wallet.timeout)
 	defer cancelFunc()
-	resp, err := client.Sign(ctx, req)
+	endpoint, err := a.wallet.tryEndpoints(ctx, "sign", func(ctx context.Context, conn *grpc.ClientConn) (pb.ResponseState, error) {
+		var err error
+		resp, err = pb.NewSignerClient(conn).Sign(ctx, req)
+
+		return resp.GetState(), err
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain signature")
 	}
-	if resp.GetState() == pb.ResponseState_FAILED {
-		span.SetStatus(codes.Error, "Failed to request signature bytes")
-		return nil, errors.New("request to obtain signature failed")
-	}
-	if resp.GetState() == pb.ResponseState_DENIED {
-		span.SetStatus(codes.Error, "Request signature bytes denied")
-		return nil, errors.New("request to obtain signature denied")
+	if resp.GetState() != pb.ResponseState_SUCCEEDED {
+		span.SetStatus(codes.Error, "Request for signature bytes did not succeed")
+		return nil, stateError("obtain signature", endpoint, resp.GetState(), "")
 	}
 	span.AddEvent("Obtained signature bytes")
 
@@ -347,7 +386,29 @@ func (a *account) SignMultiGRPC(ctx context.Context,
 	[]e2types.Signature,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "SignMultiGRPC", trace.WithAttributes(
+	res, err := a.SignMultiResultsGRPC(ctx, accounts, data, domain)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range res.Items {
+		if item.Err != nil {
+			return nil, item.Err
+		}
+	}
+
+	return res.Signatures(), nil
+}
+
+// SignMultiResultsGRPC signs data over GRPC, returning the result for each item.
+func (a *account) SignMultiResultsGRPC(ctx context.Context,
+	accounts []e2wtypes.Account,
+	data [][]byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "SignMultiResultsGRPC", trace.WithAttributes(
 		attribute.String("wallet", a.wallet.Name()),
 		attribute.String("account", a.Name()),
 	))
@@ -378,40 +439,26 @@ func (a *account) SignMultiGRPC(ctx context.Context,
 			Domain: domain,
 		}
 	}
-	endpoint := a.wallet.endpoints[0]
-	conn, release, err := a.wallet.connectionProvider.Connection(ctx, endpoint)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to connect to endpoint")
-	}
-	defer release()
-
-	client := pb.NewSignerClient(conn)
-	if client == nil {
-		return nil, errors.New("failed to set up signing client")
-	}
+	var resp *pb.MultisignResponse
 	ctx, cancelFunc := context.WithTimeout(ctx, a.wallet.timeout)
 	defer cancelFunc()
-	resp, err := client.Multisign(ctx, req)
+	endpoint, err := a.wallet.tryEndpoints(ctx, "multisign", func(ctx context.Context, conn *grpc.ClientConn) (pb.ResponseState, error) {
+		var err error
+		resp, err = pb.NewSignerClient(conn).Multisign(ctx, req)
+
+		return multisignState(resp), err
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain signatures")
 	}
 
-	sigs := make([]e2types.Signature, len(accounts))
-	for i, response := range resp.GetResponses() {
-		if response.GetState() == pb.ResponseState_FAILED {
-			return nil, errors.New("request to obtain signatures failed")
-		}
-		if response.GetState() == pb.ResponseState_DENIED {
-			return nil, errors.New("request to obtain signatures denied")
-		}
-		sigs[i], err = e2types.BLSSignatureFromBytes(response.GetSignature())
-		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("invalid signature received from %v", endpoint))
-		}
+	res, err := multiSignResult(endpoint, resp, len(accounts))
+	if err != nil {
+		return nil, err
 	}
 	span.AddEvent("Generated signatures from bytes")
 
-	return sigs, nil
+	return res, nil
 }
 
 // SignMultiGRPC signs data over GRPC.
@@ -423,7 +470,24 @@ func (a *distributedAccount) SignMultiGRPC(ctx context.Context,
 	[]e2types.Signature,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "SignMultiGRPC", trace.WithAttributes(
+	res, err := a.SignMultiResultsGRPC(ctx, accounts, data, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Signatures(), nil
+}
+
+// SignMultiResultsGRPC signs data over GRPC, returning the result for each item.
+func (a *distributedAccount) SignMultiResultsGRPC(ctx context.Context,
+	accounts []e2wtypes.Account,
+	data [][]byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "SignMultiResultsGRPC", trace.WithAttributes(
 		attribute.String("wallet", a.wallet.Name()),
 		attribute.String("account", a.Name()),
 	))
@@ -443,13 +507,13 @@ func (a *distributedAccount) SignMultiGRPC(ctx context.Context,
 		Requests: make([]*pb.SignRequest, len(accounts)),
 	}
 
-	thresholds := make([]uint32, len(accounts))
+	distributedAccounts := make([]*distributedAccount, len(accounts))
 	for i := range accounts {
 		assertedAccount, isAccount := accounts[i].(*distributedAccount)
 		if !isAccount {
 			return nil, errors.New("account not of required type")
 		}
-		thresholds[i] = assertedAccount.signingThreshold
+		distributedAccounts[i] = assertedAccount
 		req.Requests[i] = &pb.SignRequest{
 			Id:     &pb.SignRequest_Account{Account: fmt.Sprintf("%s/%s", assertedAccount.wallet.Name(), accounts[i].Name())},
 			Data:   data[i],
@@ -459,12 +523,12 @@ func (a *distributedAccount) SignMultiGRPC(ctx context.Context,
 
 	ctx, cancelFunc := context.WithTimeout(ctx, a.wallet.timeout)
 	defer cancelFunc()
-	sigs, err := a.thresholdMultiSign(ctx, req, thresholds)
+	res, err := a.thresholdMultiSign(ctx, req, distributedAccounts)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain signature")
 	}
 
-	return sigs, nil
+	return res, nil
 }
 
 // SignBeaconProposalGRPC signs a beacon chain proposal over GRPC.
@@ -486,6 +550,16 @@ func (a *account) SignBeaconProposalGRPC(ctx context.Context,
 	))
 	defer span.End()
 
+	ctx, dutyCancelFunc, err := a.wallet.dutyContext(ctx, slot)
+	if err != nil {
+		return nil, err
+	}
+	defer dutyCancelFunc()
+
+	if err := a.wallet.reserveProposal(ctx, a, slot); err != nil {
+		return nil, err
+	}
+
 	req := &pb.SignBeaconProposalRequest{
 		Id: &pb.SignBeaconProposalRequest_Account{Account: fmt.Sprintf("%s/%s", a.wallet.Name(), a.Name())},
 		Data: &pb.BeaconBlockHeader{
@@ -498,28 +572,20 @@ func (a *account) SignBeaconProposalGRPC(ctx context.Context,
 		Domain: domain,
 	}
 
-	endpoint := a.wallet.endpoints[0]
-	conn, release, err := a.wallet.connectionProvider.Connection(ctx, endpoint)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to connect to endpoint")
-	}
-	defer release()
-
-	client := pb.NewSignerClient(conn)
-	if client == nil {
-		return nil, errors.New("failed to set up signing client")
-	}
+	var resp *pb.SignResponse
 	ctx, cancelFunc := context.WithTimeout(ctx, a.wallet.timeout)
 	defer cancelFunc()
-	resp, err := client.SignBeaconProposal(ctx, req)
+	endpoint, err := a.wallet.tryEndpoints(ctx, "proposal", func(ctx context.Context, conn *grpc.ClientConn) (pb.ResponseState, error) {
+		var err error
+		resp, err = pb.NewSignerClient(conn).SignBeaconProposal(ctx, req)
+
+		return resp.GetState(), err
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain signature")
 	}
-	if resp.GetState() == pb.ResponseState_FAILED {
-		return nil, errors.New("request to obtain signature failed")
-	}
-	if resp.GetState() == pb.ResponseState_DENIED {
-		return nil, errors.New("request to obtain signature denied")
+	if resp.GetState() != pb.ResponseState_SUCCEEDED {
+		return nil, stateError("obtain signature", endpoint, resp.GetState(), "")
 	}
 
 	sig, err := e2types.BLSSignatureFromBytes(resp.GetSignature())
@@ -552,6 +618,16 @@ func (a *distributedAccount) SignBeaconProposalGRPC(ctx context.Context,
 	))
 	defer span.End()
 
+	ctx, dutyCancelFunc, err := a.wallet.dutyContext(ctx, slot)
+	if err != nil {
+		return nil, err
+	}
+	defer dutyCancelFunc()
+
+	if err := a.wallet.reserveProposal(ctx, a, slot); err != nil {
+		return nil, err
+	}
+
 	req := &pb.SignBeaconProposalRequest{
 		Id: &pb.SignBeaconProposalRequest_Account{Account: fmt.Sprintf("%s/%s", a.wallet.Name(), a.Name())},
 		Data: &pb.BeaconBlockHeader{
@@ -595,6 +671,16 @@ func (a *account) SignBeaconAttestationGRPC(ctx context.Context,
 	))
 	defer span.End()
 
+	ctx, dutyCancelFunc, err := a.wallet.dutyContext(ctx, slot)
+	if err != nil {
+		return nil, err
+	}
+	defer dutyCancelFunc()
+
+	if err := a.wallet.reserveAttestation(ctx, a, sourceEpoch, targetEpoch); err != nil {
+		return nil, err
+	}
+
 	req := &pb.SignBeaconAttestationRequest{
 		Id: &pb.SignBeaconAttestationRequest_Account{Account: fmt.Sprintf("%s/%s", a.wallet.Name(), a.Name())},
 		Data: &pb.AttestationData{
@@ -613,28 +699,20 @@ func (a *account) SignBeaconAttestationGRPC(ctx context.Context,
 		Domain: domain,
 	}
 
-	endpoint := a.wallet.endpoints[0]
-	conn, release, err := a.wallet.connectionProvider.Connection(ctx, endpoint)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to connect to endpoint")
-	}
-	defer release()
-
-	client := pb.NewSignerClient(conn)
-	if client == nil {
-		return nil, errors.New("failed to set up signing client")
-	}
+	var resp *pb.SignResponse
 	ctx, cancelFunc := context.WithTimeout(ctx, a.wallet.timeout)
 	defer cancelFunc()
-	resp, err := client.SignBeaconAttestation(ctx, req)
+	endpoint, err := a.wallet.tryEndpoints(ctx, "attestation", func(ctx context.Context, conn *grpc.ClientConn) (pb.ResponseState, error) {
+		var err error
+		resp, err = pb.NewSignerClient(conn).SignBeaconAttestation(ctx, req)
+
+		return resp.GetState(), err
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain signature")
 	}
-	if resp.GetState() == pb.ResponseState_FAILED {
-		return nil, errors.New("request to obtain signature failed")
-	}
-	if resp.GetState() == pb.ResponseState_DENIED {
-		return nil, errors.New("request to obtain signature denied")
+	if resp.GetState() != pb.ResponseState_SUCCEEDED {
+		return nil, stateError("obtain signature", endpoint, resp.GetState(), "")
 	}
 
 	sig, err := e2types.BLSSignatureFromBytes(resp.GetSignature())
@@ -669,6 +747,16 @@ func (a *distributedAccount) SignBeaconAttestationGRPC(ctx context.Context,
 	))
 	defer span.End()
 
+	ctx, dutyCancelFunc, err := a.wallet.dutyContext(ctx, slot)
+	if err != nil {
+		return nil, err
+	}
+	defer dutyCancelFunc()
+
+	if err := a.wallet.reserveAttestation(ctx, a, sourceEpoch, targetEpoch); err != nil {
+		return nil, err
+	}
+
 	req := &pb.SignBeaconAttestationRequest{
 		Id: &pb.SignBeaconAttestationRequest_Account{Account: fmt.Sprintf("%s/%s", a.wallet.Name(), a.Name())},
 		Data: &pb.AttestationData{
@@ -710,12 +798,49 @@ func (a *account) SignBeaconAttestationsGRPC(ctx context.Context,
 	[]e2types.Signature,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "SignBeaconAttestationsGRPC", trace.WithAttributes(
+	res, err := a.SignBeaconAttestationsResultsGRPC(ctx, slot, accounts, committeeIndices, blockRoot, sourceEpoch, sourceRoot, targetEpoch, targetRoot, domain)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range res.Items {
+		if item.Err != nil {
+			return nil, item.Err
+		}
+	}
+
+	return res.Signatures(), nil
+}
+
+// SignBeaconAttestationsResultsGRPC signs multiple beacon chain attestations
+// over GRPC, returning the result for each attestation.  Attestations refused
+// by the wallet's local slashing protection, if any, are not sent.
+func (a *account) SignBeaconAttestationsResultsGRPC(ctx context.Context,
+	slot uint64,
+	accounts []e2wtypes.Account,
+	committeeIndices []uint64,
+	blockRoot []byte,
+	sourceEpoch uint64,
+	sourceRoot []byte,
+	targetEpoch uint64,
+	targetRoot []byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "SignBeaconAttestationsResultsGRPC", trace.WithAttributes(
 		attribute.Int64("slot", Uint64ToInt64(slot)),
 		attribute.Int("accounts", len(accounts)),
 	))
 	defer span.End()
 
+	// Check the request before recording it with local slashing protection.
+	ctx, dutyCancelFunc, err := a.wallet.dutyContext(ctx, slot)
+	if err != nil {
+		return nil, err
+	}
+	defer dutyCancelFunc()
+
 	// Ensure these really are all accounts.
 	for i := range accounts {
 		if _, isAccount := accounts[i].(*account); !isAccount {
@@ -723,6 +848,28 @@ func (a *account) SignBeaconAttestationsGRPC(ctx context.Context,
 		}
 	}
 
+	return a.wallet.protectAttestations(ctx, accounts, committeeIndices, sourceEpoch, targetEpoch, func(accounts []e2wtypes.Account, committeeIndices []uint64) (*MultiSignResult, error) {
+		return a.signBeaconAttestationsResultsGRPC(ctx, slot, accounts, committeeIndices, blockRoot, sourceEpoch, sourceRoot, targetEpoch, targetRoot, domain)
+	})
+}
+
+// signBeaconAttestationsResultsGRPC signs multiple beacon chain attestations
+// over GRPC without local slashing protection, once their duty deadline and
+// account types have been checked.
+func (a *account) signBeaconAttestationsResultsGRPC(ctx context.Context,
+	slot uint64,
+	accounts []e2wtypes.Account,
+	committeeIndices []uint64,
+	blockRoot []byte,
+	sourceEpoch uint64,
+	sourceRoot []byte,
+	targetEpoch uint64,
+	targetRoot []byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
 	req := &pb.SignBeaconAttestationsRequest{
 		Requests: make([]*pb.SignBeaconAttestationRequest, len(accounts)),
 	}
@@ -750,39 +897,20 @@ func (a *account) SignBeaconAttestationsGRPC(ctx context.Context,
 		}
 	}
 
-	endpoint := a.wallet.endpoints[0]
-	conn, release, err := a.wallet.connectionProvider.Connection(ctx, endpoint)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to connect to endpoint")
-	}
-	defer release()
-
-	client := pb.NewSignerClient(conn)
-	if client == nil {
-		return nil, errors.New("failed to set up signing client")
-	}
+	var resp *pb.MultisignResponse
 	ctx, cancelFunc := context.WithTimeout(ctx, a.wallet.timeout)
 	defer cancelFunc()
-	resp, err := client.SignBeaconAttestations(ctx, req)
+	endpoint, err := a.wallet.tryEndpoints(ctx, "attestations", func(ctx context.Context, conn *grpc.ClientConn) (pb.ResponseState, error) {
+		var err error
+		resp, err = pb.NewSignerClient(conn).SignBeaconAttestations(ctx, req)
+
+		return multisignState(resp), err
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain signatures")
 	}
 
-	sigs := make([]e2types.Signature, len(accounts))
-	for i, response := range resp.GetResponses() {
-		if response.GetState() == pb.ResponseState_FAILED {
-			return nil, errors.New("request to obtain signatures failed")
-		}
-		if response.GetState() == pb.ResponseState_DENIED {
-			return nil, errors.New("request to obtain signatures denied")
-		}
-		sigs[i], err = e2types.BLSSignatureFromBytes(response.GetSignature())
-		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("invalid signature received from %v", endpoint))
-		}
-	}
-
-	return sigs, nil
+	return multiSignResult(endpoint, resp, len(accounts))
 }
 
 // SignBeaconAttestationsGRPC signs multiple beacon chain attestations over GRPC.
@@ -800,12 +928,44 @@ func (a *distributedAccount) SignBeaconAttestationsGRPC(ctx context.Context,
 	[]e2types.Signature,
 	error,
 ) {
-	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "SignBeaconAttestationsGRPC", trace.WithAttributes(
+	res, err := a.SignBeaconAttestationsResultsGRPC(ctx, slot, accounts, committeeIndices, blockRoot, sourceEpoch, sourceRoot, targetEpoch, targetRoot, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.Signatures(), nil
+}
+
+// SignBeaconAttestationsResultsGRPC signs multiple beacon chain attestations
+// over GRPC, returning the result for each attestation.  Attestations refused
+// by the wallet's local slashing protection, if any, are not sent.
+func (a *distributedAccount) SignBeaconAttestationsResultsGRPC(ctx context.Context,
+	slot uint64,
+	accounts []e2wtypes.Account,
+	committeeIndices []uint64,
+	blockRoot []byte,
+	sourceEpoch uint64,
+	sourceRoot []byte,
+	targetEpoch uint64,
+	targetRoot []byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "SignBeaconAttestationsResultsGRPC", trace.WithAttributes(
 		attribute.Int64("slot", Uint64ToInt64(slot)),
 		attribute.Int("accounts", len(accounts)),
 	))
 	defer span.End()
 
+	// Check the request before recording it with local slashing protection.
+	ctx, dutyCancelFunc, err := a.wallet.dutyContext(ctx, slot)
+	if err != nil {
+		return nil, err
+	}
+	defer dutyCancelFunc()
+
 	// Ensure these really are all distributed accounts.
 	for i := range accounts {
 		if _, isAccount := accounts[i].(*distributedAccount); !isAccount {
@@ -813,7 +973,29 @@ func (a *distributedAccount) SignBeaconAttestationsGRPC(ctx context.Context,
 		}
 	}
 
-	thresholds := make([]uint32, len(accounts))
+	return a.wallet.protectAttestations(ctx, accounts, committeeIndices, sourceEpoch, targetEpoch, func(accounts []e2wtypes.Account, committeeIndices []uint64) (*MultiSignResult, error) {
+		return a.signBeaconAttestationsResultsGRPC(ctx, slot, accounts, committeeIndices, blockRoot, sourceEpoch, sourceRoot, targetEpoch, targetRoot, domain)
+	})
+}
+
+// signBeaconAttestationsResultsGRPC signs multiple beacon chain attestations
+// over GRPC without local slashing protection, once their duty deadline and
+// account types have been checked.
+func (a *distributedAccount) signBeaconAttestationsResultsGRPC(ctx context.Context,
+	slot uint64,
+	accounts []e2wtypes.Account,
+	committeeIndices []uint64,
+	blockRoot []byte,
+	sourceEpoch uint64,
+	sourceRoot []byte,
+	targetEpoch uint64,
+	targetRoot []byte,
+	domain []byte,
+) (
+	*MultiSignResult,
+	error,
+) {
+	distributedAccounts := make([]*distributedAccount, len(accounts))
 	req := &pb.SignBeaconAttestationsRequest{
 		Requests: make([]*pb.SignBeaconAttestationRequest, len(accounts)),
 	}
@@ -822,7 +1004,7 @@ func (a *distributedAccount) SignBeaconAttestationsGRPC(ctx context.Context,
 		if !isAccount {
 			return nil, errors.New("account not of required type")
 		}
-		thresholds[i] = account.signingThreshold
+		distributedAccounts[i] = account
 		req.Requests[i] = &pb.SignBeaconAttestationRequest{
 			Id: &pb.SignBeaconAttestationRequest_Account{Account: fmt.Sprintf("%s/%s", account.wallet.Name(), accounts[i].Name())},
 			Data: &pb.AttestationData{
@@ -842,12 +1024,12 @@ func (a *distributedAccount) SignBeaconAttestationsGRPC(ctx context.Context,
 		}
 	}
 
-	sigs, err := a.thresholdSignBeaconAttestations(ctx, req, thresholds)
+	res, err := a.thresholdSignBeaconAttestations(ctx, req, distributedAccounts)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to obtain signatures")
 	}
 
-	return sigs, nil
+	return res, nil
 }
 
 // GenerateDistributedAccount generates a distributed account.
@@ -866,37 +1048,29 @@ func (w *wallet) GenerateDistributedAccount(ctx context.Context,
 	))
 	defer span.End()
 
-	conn, release, err := w.connectionProvider.Connection(ctx, w.endpoints[0])
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to connect to endpoint")
-	}
-	defer release()
-
-	accountClient := pb.NewAccountManagerClient(conn)
 	req := &pb.GenerateRequest{
 		Account:          fmt.Sprintf("%s/%s", w.Name(), accountName),
 		Participants:     participants,
 		SigningThreshold: signingThreshold,
 		Passphrase:       passphrase,
 	}
+	var resp *pb.GenerateResponse
 	ctx, cancelFunc := context.WithTimeout(ctx, w.timeout)
 	defer cancelFunc()
-	resp, err := accountClient.Generate(ctx, req)
+	endpoint, err := w.tryEndpoints(ctx, "generate", func(ctx context.Context, conn *grpc.ClientConn) (pb.ResponseState, error) {
+		var err error
+		resp, err = pb.NewAccountManagerClient(conn).Generate(ctx, req)
+
+		return resp.GetState(), err
+	})
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to access dirk")
 	}
-
-	switch resp.GetState() {
-	case pb.ResponseState_SUCCEEDED:
-		// Good.
-	case pb.ResponseState_DENIED:
-		return nil, fmt.Errorf("generate request denied: %s", resp.GetMessage())
-	case pb.ResponseState_FAILED:
-		return nil, fmt.Errorf("generate request failed: %s", resp.GetMessage())
-	case pb.ResponseState_UNKNOWN:
-		return nil, fmt.Errorf("generate request unexpected response: %s", resp.GetMessage())
-	default:
-		return nil, fmt.Errorf("generate request failed: %s", resp.GetMessage())
+	if resp.GetState() != pb.ResponseState_SUCCEEDED {
+		return nil, stateError("generate account", endpoint, resp.GetState(), resp.GetMessage())
+	}
+	if w.accountCache != nil {
+		w.accountCache.invalidate()
 	}
 
 	// Fetch the account to ensure it has been created.
@@ -916,89 +1090,197 @@ func (a *distributedAccount) thresholdSign(ctx context.Context, req *pb.SignRequ
 	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "thresholdSign")
 	defer span.End()
 
-	clients := make(map[uint64]pb.SignerClient, len(a.Participants()))
+	root := signingRoot(req.GetData(), req.GetDomain())
 
-	for id, endpoint := range a.participants {
-		conn, release, err := a.wallet.connectionProvider.Connection(ctx, endpoint)
-		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("failed to connect to endpoint %v", endpoint))
-		}
-		defer release()
-		span.AddEvent("Obtained connection")
+	return a.thresholdSignRoot(ctx, "sign", root, func(ctx context.Context, client pb.SignerClient) (*pb.SignResponse, error) {
+		return client.Sign(ctx, req)
+	})
+}
 
-		clients[id] = pb.NewSignerClient(conn)
-		if clients[id] == nil {
-			return nil, fmt.Errorf("failed to set up signing client for %v", endpoint)
-		}
-	}
-	span.AddEvent("Obtained connections")
+// thresholdMultiSign handles signing multiple requests, with a threshold of responses.
+func (a *distributedAccount) thresholdMultiSign(ctx context.Context,
+	req *pb.MultisignRequest,
+	accounts []*distributedAccount,
+) (
+	*MultiSignResult,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "thresholdMultiSign")
+	defer span.End()
 
-	type thresholdSignResponse struct {
-		id   uint64
-		resp *pb.SignResponse
+	roots := make([][]byte, len(req.GetRequests()))
+	for i, request := range req.GetRequests() {
+		roots[i] = signingRoot(request.GetData(), request.GetDomain())
 	}
-	respChannel := make(chan *thresholdSignResponse, len(clients))
-	errChannel := make(chan error, len(clients))
 
-	span.AddEvent("Ready to contact servers")
-	ctx, cancelFunc := context.WithTimeout(ctx, a.wallet.timeout)
-	defer cancelFunc()
-	for id, client := range clients {
-		go func(client pb.SignerClient, id uint64, req *pb.SignRequest) {
-			resp, err := client.Sign(ctx, req)
-			span.AddEvent("Received response")
-			if err != nil {
-				errChannel <- err
-			} else {
-				respChannel <- &thresholdSignResponse{
-					id:   id,
-					resp: resp,
-				}
-			}
-			span.AddEvent("Processed response")
-		}(client, id, req)
-	}
-	span.AddEvent("Contacted all servers")
+	return a.thresholdMultiSignRoots(ctx, "multisign", accounts, roots, func(ctx context.Context, client pb.SignerClient) (*pb.MultisignResponse, error) {
+		return client.Multisign(ctx, req)
+	})
+}
 
-	// Wait for enough responses (or timeout)
-	signed := 0
-	denied := 0
-	failed := 0
-	errored := 0
-	ids := make([]bls.ID, a.signingThreshold)
-	signatures := make([]bls.Sign, a.signingThreshold)
-	for signed != int(a.signingThreshold) && signed+denied+failed+errored != len(clients) {
-		select {
-		case <-ctx.Done():
-			return nil, errors.New("context done")
-		case <-errChannel:
-			errored++
-		case resp := <-respChannel:
-			switch resp.resp.GetState() {
-			case pb.ResponseState_DENIED:
-				denied++
-			case pb.ResponseState_FAILED:
-				failed++
-			case pb.ResponseState_UNKNOWN:
-				// We consider unknown to be failed.
-				failed++
-			case pb.ResponseState_SUCCEEDED:
-				ids[signed] = *blsID(resp.id)
-				if err := signatures[signed].Deserialize(resp.resp.GetSignature()); err != nil {
-					return nil, errors.Wrap(err, fmt.Sprintf("invalid signature received from %d", resp.id))
-				}
-				signed++
-			}
-		}
-	}
+// thresholdSignBeaconAttestation handles signing, with a threshold of responses.
+func (a *distributedAccount) thresholdSignBeaconAttestation(ctx context.Context, req *pb.SignBeaconAttestationRequest) (e2types.Signature, error) {
+	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "thresholdSignBeaconAttestation")
+	defer span.End()
+
+	root := signingRoot(attestationDataRoot(req.GetData()), req.GetDomain())
+
+	return a.thresholdSignRoot(ctx, "attestation", root, func(ctx context.Context, client pb.SignerClient) (*pb.SignResponse, error) {
+		return client.SignBeaconAttestation(ctx, req)
+	})
+}
+
+// thresholdSignBeaconAttestations handles signing, with a threshold of responses.
+func (a *distributedAccount) thresholdSignBeaconAttestations(ctx context.Context,
+	req *pb.SignBeaconAttestationsRequest,
+	accounts []*distributedAccount,
+) (
+	*MultiSignResult,
+	error,
+) {
+	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "thresholdSignBeaconAttestations")
+	defer span.End()
+
+	roots := make([][]byte, len(req.GetRequests()))
+	for i, request := range req.GetRequests() {
+		roots[i] = signingRoot(attestationDataRoot(request.GetData()), request.GetDomain())
+	}
+
+	return a.thresholdMultiSignRoots(ctx, "attestations", accounts, roots, func(ctx context.Context, client pb.SignerClient) (*pb.MultisignResponse, error) {
+		return client.SignBeaconAttestations(ctx, req)
+	})
+}
+
+// thresholdSignBeaconProposal handles signing, with a threshold of responses.
+func (a *distributedAccount) thresholdSignBeaconProposal(ctx context.Context, req *pb.SignBeaconProposalRequest) (e2types.Signature, error) {
+	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "thresholdSignBeaconProposal")
+	defer span.End()
+
+	root := signingRoot(beaconBlockHeaderRoot(req.GetData()), req.GetDomain())
+
+	return a.thresholdSignRoot(ctx, "proposal", root, func(ctx context.Context, client pb.SignerClient) (*pb.SignResponse, error) {
+		return client.SignBeaconProposal(ctx, req)
+	})
+}
+
+// participantSigner requests a signature from a single participant.
+type participantSigner func(ctx context.Context, client pb.SignerClient) (*pb.SignResponse, error)
+
+// participantSignResponse is the response from a single participant to a signing request.
+type participantSignResponse struct {
+	id    uint64
+	state pb.ResponseState
+	// signature is the verified signature share, if the request succeeded.
+	signature *bls.Sign
+	// invalid is set if the participant returned a signature share that failed verification.
+	invalid bool
+	err     error
+	// latency is the time taken to obtain the response.
+	latency time.Duration
+}
+
+// thresholdSignRoot obtains signature shares over the given root from the
+// participants, verifies each of them against the participant's public key
+// share, and recovers the composite signature once the threshold is reached.
+// If enabled, the composite signature is verified against the composite
+// public key of the account before it is returned.
+func (a *distributedAccount) thresholdSignRoot(ctx context.Context,
+	operation string,
+	root []byte,
+	sign participantSigner,
+) (
+	e2types.Signature,
+	error,
+) {
+	span := trace.SpanFromContext(ctx)
+
+	candidates := make([]uint64, 0, len(a.participants))
+	skipped := make([]uint64, 0)
+	for id, endpoint := range a.participants {
+		if a.wallet.endpointDown(endpoint) {
+			// Endpoint is down, so do not wait for it.
+			skipped = append(skipped, id)
+
+			continue
+		}
+		candidates = append(candidates, id)
+	}
+
+	report := newSigningReport(a.name, operation, int(a.signingThreshold), a.participants)
+	for _, id := range skipped {
+		report.record(id, ParticipantOutcomeSkipped, 0, nil)
+	}
+	defer reportSigning(ctx, report)
+
+	ctx, cancelFunc := context.WithTimeout(ctx, a.wallet.timeout)
+	defer cancelFunc()
+
+	respChannel := make(chan *participantSignResponse, len(candidates))
+	launcher := a.wallet.newParticipantLauncher(a.participants, candidates, func(id uint64) {
+		report.launched(id)
+		go func() {
+			started := time.Now()
+			resp := a.participantSign(ctx, operation, id, root, sign)
+			resp.latency = time.Since(started)
+			respChannel <- resp
+		}()
+	})
+	launcher.launch(a.wallet.initialParticipants(int(a.signingThreshold), len(candidates)))
+	hedge, stopHedge := a.wallet.hedgeTimer()
+	defer stopHedge()
+	span.AddEvent("Contacted servers")
+
+	// Wait for enough responses (or context done).
+	outcome := &ThresholdError{
+		Required: int(a.signingThreshold),
+		Errored:  skipped,
+		Report:   report,
+	}
+	ids := make([]bls.ID, a.signingThreshold)
+	signatures := make([]bls.Sign, a.signingThreshold)
+	for len(outcome.Signed) != outcome.Required && len(launcher.outstanding()) > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "context done")
+		case <-hedge:
+			// Slow responses; contact the remaining participants.
+			hedge = nil
+			a.wallet.hedge(a.participants, launcher)
+		case resp := <-respChannel:
+			launcher.receive(resp.id)
+			switch {
+			case resp.err != nil:
+				outcome.Errored = append(outcome.Errored, resp.id)
+				report.record(resp.id, ParticipantOutcomeErrored, resp.latency, resp.err)
+			case resp.invalid:
+				outcome.Invalid = append(outcome.Invalid, resp.id)
+				report.record(resp.id, ParticipantOutcomeInvalid, resp.latency, errInvalidShare)
+			case resp.state == pb.ResponseState_DENIED:
+				outcome.Denied = append(outcome.Denied, resp.id)
+				report.record(resp.id, ParticipantOutcomeDenied, resp.latency, stateError(operation, a.participants[resp.id], resp.state, ""))
+			case resp.state == pb.ResponseState_SUCCEEDED:
+				ids[len(outcome.Signed)] = *blsID(resp.id)
+				signatures[len(outcome.Signed)] = *resp.signature
+				outcome.Signed = append(outcome.Signed, resp.id)
+				report.record(resp.id, ParticipantOutcomeSigned, resp.latency, nil)
+			default:
+				// We consider unknown to be failed.
+				outcome.Failed = append(outcome.Failed, resp.id)
+				report.record(resp.id, ParticipantOutcomeFailed, resp.latency, stateError(operation, a.participants[resp.id], resp.state, ""))
+			}
+			// Replace any participant that did not provide a signature share.
+			launcher.topUp(outcome.Required - len(outcome.Signed))
+		}
+	}
 	span.AddEvent("Received responses", trace.WithAttributes(
-		attribute.Int("signed", signed),
-		attribute.Int("denied", denied),
-		attribute.Int("failed", failed),
-		attribute.Int("errored", errored),
+		attribute.Int("signed", len(outcome.Signed)),
+		attribute.Int("denied", len(outcome.Denied)),
+		attribute.Int("failed", len(outcome.Failed)),
+		attribute.Int("errored", len(outcome.Errored)),
+		attribute.Int("invalid", len(outcome.Invalid)),
 	))
-	if signed != int(a.signingThreshold) {
-		return nil, fmt.Errorf("not enough signatures: %d signed, %d denied, %d failed, %d errored", signed, denied, failed, errored)
+	if len(outcome.Signed) != outcome.Required {
+		return nil, outcome
 	}
 
 	var signature bls.Sign
@@ -1007,144 +1289,305 @@ func (a *distributedAccount) thresholdSign(ctx context.Context, req *pb.SignRequ
 	}
 	span.AddEvent("Recovered signature")
 
-	//nolint:wrapcheck
-	return e2types.BLSSignatureFromSig(signature)
+	sig, err := e2types.BLSSignatureFromSig(signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid composite signature")
+	}
+	if a.wallet.verifyCompositeSignatures {
+		if !sig.Verify(root, a.compositePubKey) {
+			span.SetStatus(codes.Error, "Composite signature failed verification")
+
+			return nil, &CompositeSignatureError{
+				Account: a.name,
+				Root:    root,
+			}
+		}
+		span.AddEvent("Verified signature")
+	}
+
+	return sig, nil
 }
 
-// thresholdMultiSign handles signing multiple requests, with a threshold of responses.
-func (a *distributedAccount) thresholdMultiSign(ctx context.Context, req *pb.MultisignRequest, thresholds []uint32) ([]e2types.Signature, error) {
-	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "thresholdMultiSign")
-	defer span.End()
+// participantClient obtains a signer client for a participant, along with a
+// function to release its connection.
+func (a *distributedAccount) participantClient(ctx context.Context,
+	operation string,
+	id uint64,
+) (
+	pb.SignerClient,
+	func(),
+	error,
+) {
+	endpoint := a.participants[id]
+	started := time.Now()
+	conn, release, err := a.wallet.connectionProvider.Connection(ctx, endpoint)
+	if err != nil {
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			a.wallet.endpointSelector.observe(endpoint, time.Since(started), err)
+			observeRequest(operation, endpoint, time.Since(started), requestOutcome(pb.ResponseState_UNKNOWN, err))
+			a.wallet.endpointFailed(ctx, endpoint, transportError(endpoint, err))
+		}
 
-	clients := make(map[uint64]pb.SignerClient, len(a.Participants()))
-	for id, endpoint := range a.participants {
-		conn, release, err := a.wallet.connectionProvider.Connection(ctx, endpoint)
-		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("failed to connect to endpoint %v", endpoint))
+		return nil, nil, errors.Wrap(err, fmt.Sprintf("failed to connect to endpoint %v", endpoint))
+	}
+
+	return pb.NewSignerClient(conn), release, nil
+}
+
+// participantSign requests a signature share from a single participant and
+// verifies it against the participant's public key share.
+func (a *distributedAccount) participantSign(ctx context.Context,
+	operation string,
+	id uint64,
+	root []byte,
+	sign participantSigner,
+) *participantSignResponse {
+	res := &participantSignResponse{
+		id: id,
+	}
+
+	client, release, err := a.participantClient(ctx, operation, id)
+	if err != nil {
+		res.err = err
+
+		return res
+	}
+	defer release()
+
+	started := time.Now()
+	resp, err := sign(ctx, client)
+	if err != nil {
+		res.err = err
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			// Requests canceled because enough responses were received are not failures of the endpoint.
+			a.wallet.endpointSelector.observe(a.participants[id], time.Since(started), err)
+			observeRequest(operation, a.participants[id], time.Since(started), requestOutcome(pb.ResponseState_UNKNOWN, err))
+			a.wallet.endpointFailed(ctx, a.participants[id], transportError(a.participants[id], err))
 		}
-		defer release()
 
-		clients[id] = pb.NewSignerClient(conn)
-		if clients[id] == nil {
-			return nil, fmt.Errorf("failed to set up signing client for %v", endpoint)
+		return res
+	}
+	a.wallet.endpointSelector.observe(a.participants[id], time.Since(started), nil)
+	observeRequest(operation, a.participants[id], time.Since(started), requestOutcome(resp.GetState(), nil))
+	res.state = resp.GetState()
+	if res.state != pb.ResponseState_SUCCEEDED {
+		return res
+	}
+
+	if a.participantPubKey(id) == nil {
+		// The participant was unreachable when the account was listed.
+		if err := a.wallet.obtainParticipantShareKeys(ctx, id, []*distributedAccount{a}); err != nil {
+			res.err = err
+
+			return res
 		}
 	}
+	res.signature, res.invalid = verifyShare(resp.GetSignature(), a.participantPubKey(id), root)
+	if res.invalid {
+		a.wallet.log.Warn().Uint64("participant", id).Stringer("endpoint", a.participants[id]).Str("account", a.name).Msg("Participant returned invalid signature share")
+	}
+
+	return res
+}
 
-	type thresholdSignResponse struct {
-		id   uint64
-		resp *pb.MultisignResponse
+// participantMultiSigner requests multiple signatures from a single participant.
+type participantMultiSigner func(ctx context.Context, client pb.SignerClient) (*pb.MultisignResponse, error)
+
+// participantMultiSignResponse is the response from a single participant to a multiple signing request.
+type participantMultiSignResponse struct {
+	id     uint64
+	states []pb.ResponseState
+	// signatures are the verified signature shares, for those requests that succeeded.
+	signatures []*bls.Sign
+	// invalid is set for each request where the participant returned a signature share that failed verification.
+	invalid []bool
+	err     error
+	// latency is the time taken to obtain the response.
+	latency time.Duration
+}
+
+// thresholdMultiSignRoots obtains signature shares over the given roots from
+// the participants, verifies each of them against the participant's public
+// key share for the relevant account, and recovers the composite signatures.
+// Items whose composite signatures cannot be recovered, or fail verification
+// if enabled, are returned with the reason in the result.
+func (a *distributedAccount) thresholdMultiSignRoots(ctx context.Context,
+	operation string,
+	accounts []*distributedAccount,
+	roots [][]byte,
+	sign participantMultiSigner,
+) (
+	*MultiSignResult,
+	error,
+) {
+	span := trace.SpanFromContext(ctx)
+
+	candidates := make([]uint64, 0, len(a.participants))
+	skipped := make([]uint64, 0)
+	for id, endpoint := range a.participants {
+		if a.wallet.endpointDown(endpoint) {
+			// Endpoint is down, so do not wait for it.
+			skipped = append(skipped, id)
+
+			continue
+		}
+		candidates = append(candidates, id)
 	}
-	respChannel := make(chan *thresholdSignResponse, len(clients))
-	errChannel := make(chan error, len(clients))
+
+	reports := make([]*SigningReport, len(accounts))
+	for i := range accounts {
+		reports[i] = newSigningReport(accounts[i].name, operation, int(accounts[i].signingThreshold), accounts[i].participants)
+		for _, id := range skipped {
+			reports[i].record(id, ParticipantOutcomeSkipped, 0, nil)
+		}
+	}
+	defer reportSigning(ctx, reports...)
 
 	ctx, cancelFunc := context.WithTimeout(ctx, a.wallet.timeout)
 	defer cancelFunc()
-	for id, client := range clients {
-		go func(client pb.SignerClient, id uint64, req *pb.MultisignRequest) {
-			resp, err := client.Multisign(ctx, req)
-			if err != nil {
-				errChannel <- err
-			} else {
-				respChannel <- &thresholdSignResponse{
-					id:   id,
-					resp: resp,
-				}
-			}
-		}(client, id, req)
+
+	respChannel := make(chan *participantMultiSignResponse, len(candidates))
+	launcher := a.wallet.newParticipantLauncher(a.participants, candidates, func(id uint64) {
+		for i := range reports {
+			reports[i].launched(id)
+		}
+		go func() {
+			started := time.Now()
+			resp := a.participantMultiSign(ctx, operation, id, accounts, roots, sign)
+			resp.latency = time.Since(started)
+			respChannel <- resp
+		}()
+	})
+	maxThreshold := 0
+	for i := range accounts {
+		if int(accounts[i].signingThreshold) > maxThreshold {
+			maxThreshold = int(accounts[i].signingThreshold)
+		}
 	}
-	span.AddEvent("Contacted all servers")
+	launcher.launch(a.wallet.initialParticipants(maxThreshold, len(candidates)))
+	hedge, stopHedge := a.wallet.hedgeTimer()
+	defer stopHedge()
+	span.AddEvent("Contacted servers")
 
 	// Wait for enough responses (or context done).
-	responses := 0
-	signed := make([]int, len(thresholds))
-	denied := make([]int, len(thresholds))
-	failed := make([]int, len(thresholds))
-	errored := make([]int, len(thresholds))
-	ids := make([][]bls.ID, len(thresholds))
-	signatureBytes := make([][][]byte, len(thresholds))
-	for i := range ids {
-		ids[i] = make([]bls.ID, 0, len(clients))
-		signatureBytes[i] = make([][]byte, 0, len(clients))
-	}
-	for {
+	outcomes := make([]*ThresholdError, len(accounts))
+	for i := range accounts {
+		outcomes[i] = &ThresholdError{
+			Required: int(accounts[i].signingThreshold),
+			Errored:  append([]uint64{}, skipped...),
+			Report:   reports[i],
+		}
+	}
+	ids := make([][]bls.ID, len(accounts))
+	signatures := make([][]bls.Sign, len(accounts))
+	for i := range accounts {
+		ids[i] = make([]bls.ID, 0, len(candidates))
+		signatures[i] = make([]bls.Sign, 0, len(candidates))
+	}
+	for len(launcher.outstanding()) > 0 {
 		select {
 		case <-ctx.Done():
-			return nil, errors.New("context done")
-		case <-errChannel:
-			for i := range errored {
-				errored[i]++
-			}
-			responses++
+			return nil, errors.Wrap(ctx.Err(), "context done")
+		case <-hedge:
+			// Slow responses; contact the remaining participants.
+			hedge = nil
+			a.wallet.hedge(a.participants, launcher)
+
+			continue
 		case resp := <-respChannel:
-			responses++
-			for i, response := range resp.resp.GetResponses() {
-				switch response.GetState() {
-				case pb.ResponseState_DENIED:
-					denied[i]++
-				case pb.ResponseState_FAILED:
-					failed[i]++
-				case pb.ResponseState_UNKNOWN:
-					// We consider unknown to be failed.
-					failed[i]++
-				case pb.ResponseState_SUCCEEDED:
-					signed[i]++
-					ids[i] = append(ids[i], *blsID(resp.id))
-					signatureBytes[i] = append(signatureBytes[i], response.GetSignature())
+			launcher.receive(resp.id)
+			if resp.err != nil {
+				for i := range outcomes {
+					outcomes[i].Errored = append(outcomes[i].Errored, resp.id)
+					reports[i].record(resp.id, ParticipantOutcomeErrored, resp.latency, resp.err)
+				}
+			} else {
+				for i := range accounts {
+					switch {
+					case resp.invalid[i]:
+						outcomes[i].Invalid = append(outcomes[i].Invalid, resp.id)
+						reports[i].record(resp.id, ParticipantOutcomeInvalid, resp.latency, errInvalidShare)
+					case resp.states[i] == pb.ResponseState_DENIED:
+						outcomes[i].Denied = append(outcomes[i].Denied, resp.id)
+						reports[i].record(resp.id, ParticipantOutcomeDenied, resp.latency, stateError(operation, a.participants[resp.id], resp.states[i], ""))
+					case resp.states[i] == pb.ResponseState_SUCCEEDED:
+						outcomes[i].Signed = append(outcomes[i].Signed, resp.id)
+						ids[i] = append(ids[i], *blsID(resp.id))
+						signatures[i] = append(signatures[i], *resp.signatures[i])
+						reports[i].record(resp.id, ParticipantOutcomeSigned, resp.latency, nil)
+					default:
+						// We consider unknown to be failed.
+						outcomes[i].Failed = append(outcomes[i].Failed, resp.id)
+						reports[i].record(resp.id, ParticipantOutcomeFailed, resp.latency, stateError(operation, a.participants[resp.id], resp.states[i], ""))
+					}
 				}
 			}
 		}
 
-		// See if we have enough successful responses for all requests.
-		if responses == len(clients) {
-			// We have all the responses; done by definition.
-			break
-		}
-
 		// We could be done early if we have enough signatures.
-		done := true
-		for i := range ids {
-			if len(ids[i]) != int(thresholds[i]) {
-				done = false
-				break
+		needed := 0
+		for i := range accounts {
+			if missing := outcomes[i].Required - len(outcomes[i].Signed); missing > needed {
+				needed = missing
 			}
 		}
-		if done {
+		if needed == 0 {
 			break
 		}
+		// Replace any participant that did not provide all of its signature shares.
+		launcher.topUp(needed)
 	}
 	span.AddEvent("Received responses")
 
-	// Take the signature bytes, turn them in to real signatures, then
-	// recover the final signature from the components.
+	// Recover the final signatures from the components.
 	sem := semaphore.NewWeighted(int64(runtime.GOMAXPROCS(0)))
 	var wg sync.WaitGroup
-	res := make([]e2types.Signature, len(thresholds))
-	var err error
-	for i := range ids {
+	res := &MultiSignResult{
+		Items: make([]*MultiSignItemResult, len(accounts)),
+	}
+	for i := range accounts {
+		res.Items[i] = &MultiSignItemResult{}
+		threshold := outcomes[i].Required
+		if len(outcomes[i].Signed) < threshold {
+			// Not enough components to make the composite signature.
+			a.wallet.log.Debug().Str("account", accounts[i].name).Stringer("report", reports[i]).Msg("Not enough signatures")
+			res.Items[i].Err = outcomes[i]
+
+			continue
+		}
+
 		wg.Add(1)
-		go func(_ context.Context, _ *semaphore.Weighted, wg *sync.WaitGroup, i int) {
+		go func(ctx context.Context, sem *semaphore.Weighted, wg *sync.WaitGroup, i int) {
 			defer wg.Done()
-			if signed[i] != int(thresholds[i]) {
-				// Not enough components to make the composite signature.
+			if err := sem.Acquire(ctx, 1); err != nil {
+				res.Items[i].Err = errors.Wrap(err, "context done")
+
 				return
 			}
+			defer sem.Release(1)
 
-			components := make([]bls.Sign, thresholds[i])
-			for j := range thresholds[i] {
-				if err := components[j].Deserialize(signatureBytes[i][j]); err != nil {
-					return
-				}
-			}
 			var signature bls.Sign
-			if err := signature.Recover(components, ids[i][0:thresholds[i]]); err != nil {
-				// Invalid components.
+			if err := signature.Recover(signatures[i][0:threshold], ids[i][0:threshold]); err != nil {
+				res.Items[i].Err = errors.Wrap(err, "failed to recover composite signature")
+
 				return
 			}
-			res[i], err = e2types.BLSSignatureFromSig(signature)
+			sig, err := e2types.BLSSignatureFromSig(signature)
 			if err != nil {
-				// Invalid composite signature.
-				res[i] = nil
+				res.Items[i].Err = errors.Wrap(err, "invalid composite signature")
+
+				return
+			}
+			if a.wallet.verifyCompositeSignatures && !sig.Verify(roots[i], accounts[i].compositePubKey) {
+				a.wallet.log.Warn().Str("account", accounts[i].name).Msg("Composite signature failed verification")
+				res.Items[i].Err = &CompositeSignatureError{
+					Account: accounts[i].name,
+					Root:    roots[i],
+				}
+
+				return
 			}
+			res.Items[i].Signature = sig
 		}(ctx, sem, &wg, i)
 	}
 	wg.Wait()
@@ -1153,336 +1596,353 @@ func (a *distributedAccount) thresholdMultiSign(ctx context.Context, req *pb.Mul
 	return res, nil
 }
 
-// thresholdSignBeaconAttestation handles signing, with a threshold of responses.
-func (a *distributedAccount) thresholdSignBeaconAttestation(ctx context.Context, req *pb.SignBeaconAttestationRequest) (e2types.Signature, error) {
-	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "thresholdSignBeaconAttestation")
-	defer span.End()
+// participantMultiSign requests multiple signature shares from a single
+// participant and verifies them against the participant's public key shares.
+func (a *distributedAccount) participantMultiSign(ctx context.Context,
+	operation string,
+	id uint64,
+	accounts []*distributedAccount,
+	roots [][]byte,
+	sign participantMultiSigner,
+) *participantMultiSignResponse {
+	res := &participantMultiSignResponse{
+		id: id,
+	}
 
-	clients := make(map[uint64]pb.SignerClient, len(a.Participants()))
+	client, release, err := a.participantClient(ctx, operation, id)
+	if err != nil {
+		res.err = err
 
-	for id, endpoint := range a.participants {
-		conn, release, err := a.wallet.connectionProvider.Connection(ctx, endpoint)
-		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("failed to connect to endpoint %v", endpoint))
-		}
-		defer release()
+		return res
+	}
+	defer release()
 
-		clients[id] = pb.NewSignerClient(conn)
-		if clients[id] == nil {
-			return nil, fmt.Errorf("failed to set up signing client for %v", endpoint)
+	started := time.Now()
+	resp, err := sign(ctx, client)
+	if err != nil {
+		res.err = err
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			// Requests canceled because enough responses were received are not failures of the endpoint.
+			a.wallet.endpointSelector.observe(a.participants[id], time.Since(started), err)
+			observeRequest(operation, a.participants[id], time.Since(started), requestOutcome(pb.ResponseState_UNKNOWN, err))
+			a.wallet.endpointFailed(ctx, a.participants[id], transportError(a.participants[id], err))
 		}
-	}
 
-	type thresholdSignResponse struct {
-		id   uint64
-		resp *pb.SignResponse
+		return res
 	}
-	respChannel := make(chan *thresholdSignResponse, len(clients))
-	errChannel := make(chan error, len(clients))
+	a.wallet.endpointSelector.observe(a.participants[id], time.Since(started), nil)
+	observeRequest(operation, a.participants[id], time.Since(started), requestOutcome(multisignState(resp), nil))
+	if len(resp.GetResponses()) != len(accounts) {
+		res.err = fmt.Errorf("received %d responses for %d requests", len(resp.GetResponses()), len(accounts))
 
-	ctx, cancelFunc := context.WithTimeout(ctx, a.wallet.timeout)
-	defer cancelFunc()
-	for id, client := range clients {
-		go func(client pb.SignerClient, id uint64, req *pb.SignBeaconAttestationRequest) {
-			resp, err := client.SignBeaconAttestation(ctx, req)
-			if err != nil {
-				errChannel <- err
-			} else {
-				respChannel <- &thresholdSignResponse{
-					id:   id,
-					resp: resp,
-				}
-			}
-		}(client, id, req)
+		return res
 	}
-	span.AddEvent("Contacted all servers")
 
-	// Wait for enough responses (or context done).
-	signed := 0
-	denied := 0
-	failed := 0
-	errored := 0
-	ids := make([]bls.ID, a.signingThreshold)
-	signatures := make([]bls.Sign, a.signingThreshold)
-	for signed != int(a.signingThreshold) && signed+denied+failed+errored != len(clients) {
-		select {
-		case <-ctx.Done():
-			return nil, errors.New("context done")
-		case <-errChannel:
-			errored++
-		case resp := <-respChannel:
-			switch resp.resp.GetState() {
-			case pb.ResponseState_DENIED:
-				denied++
-			case pb.ResponseState_FAILED:
-				failed++
-			case pb.ResponseState_UNKNOWN:
-				// We consider unknown to be failed.
-				failed++
-			case pb.ResponseState_SUCCEEDED:
-				ids[signed] = *blsID(resp.id)
-				if err := signatures[signed].Deserialize(resp.resp.GetSignature()); err != nil {
-					return nil, errors.Wrap(err, fmt.Sprintf("invalid signature received from %d", resp.id))
-				}
-				signed++
-			}
+	// Obtain any public key shares that are not known because the
+	// participant was unreachable when the accounts were listed.
+	unknown := make([]*distributedAccount, 0)
+	for i, response := range resp.GetResponses() {
+		if response.GetState() == pb.ResponseState_SUCCEEDED && accounts[i].participantPubKey(id) == nil {
+			unknown = append(unknown, accounts[i])
 		}
 	}
-	span.AddEvent("Received responses", trace.WithAttributes(
-		attribute.Int("signed", signed),
-		attribute.Int("denied", denied),
-		attribute.Int("failed", failed),
-		attribute.Int("errored", errored),
-	))
-	if signed != int(a.signingThreshold) {
-		return nil, fmt.Errorf("not enough signatures: %d signed, %d denied, %d failed, %d errored", signed, denied, failed, errored)
+	if len(unknown) > 0 {
+		if err := a.wallet.obtainParticipantShareKeys(ctx, id, unknown); err != nil {
+			res.err = err
+
+			return res
+		}
 	}
 
-	var signature bls.Sign
-	if err := signature.Recover(signatures, ids); err != nil {
-		return nil, errors.Wrap(err, "failed to recover composite signature")
+	res.states = make([]pb.ResponseState, len(accounts))
+	res.signatures = make([]*bls.Sign, len(accounts))
+	res.invalid = make([]bool, len(accounts))
+	for i, response := range resp.GetResponses() {
+		res.states[i] = response.GetState()
+		if res.states[i] != pb.ResponseState_SUCCEEDED {
+			continue
+		}
+		res.signatures[i], res.invalid[i] = verifyShare(response.GetSignature(), accounts[i].participantPubKey(id), roots[i])
+		if res.invalid[i] {
+			a.wallet.log.Warn().Uint64("participant", id).Stringer("endpoint", a.participants[id]).Str("account", accounts[i].name).Msg("Participant returned invalid signature share")
+		}
 	}
-	span.AddEvent("Recovered signature")
 
-	//nolint:wrapcheck
-	return e2types.BLSSignatureFromSig(signature)
+	return res
 }
 
-// thresholdSignBeaconAttestations handles signing, with a threshold of responses.
-func (a *distributedAccount) thresholdSignBeaconAttestations(ctx context.Context, req *pb.SignBeaconAttestationsRequest, thresholds []uint32) ([]e2types.Signature, error) {
-	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "thresholdSignBeaconAttestations")
-	defer span.End()
+// multiSignResult turns a multiple signing response from a single endpoint in
+// to the result for each item.
+func multiSignResult(endpoint *Endpoint, resp *pb.MultisignResponse, items int) (*MultiSignResult, error) {
+	if len(resp.GetResponses()) != items {
+		return nil, fmt.Errorf("received %d responses for %d requests from %v", len(resp.GetResponses()), items, endpoint)
+	}
 
-	clients := make(map[uint64]pb.SignerClient, len(a.Participants()))
-	for id, endpoint := range a.participants {
-		conn, release, err := a.wallet.connectionProvider.Connection(ctx, endpoint)
-		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("failed to connect to endpoint %v", endpoint))
+	res := &MultiSignResult{
+		Items: make([]*MultiSignItemResult, items),
+	}
+	for i, response := range resp.GetResponses() {
+		res.Items[i] = &MultiSignItemResult{}
+		if response.GetState() != pb.ResponseState_SUCCEEDED {
+			res.Items[i].Err = stateError("obtain signatures", endpoint, response.GetState(), "")
+
+			continue
 		}
-		defer release()
+		sig, err := e2types.BLSSignatureFromBytes(response.GetSignature())
+		if err != nil {
+			res.Items[i].Err = errors.Wrap(err, fmt.Sprintf("invalid signature received from %v", endpoint))
 
-		clients[id] = pb.NewSignerClient(conn)
-		if clients[id] == nil {
-			return nil, fmt.Errorf("failed to set up signing client for %v", endpoint)
+			continue
 		}
+		res.Items[i].Signature = sig
 	}
 
-	type thresholdSignResponse struct {
-		id   uint64
-		resp *pb.MultisignResponse
+	return res, nil
+}
+
+// verifyShare verifies a signature share against a public key share.
+// It returns the signature share if valid, or a flag noting that it is invalid.
+// Shares cannot be verified if the public key share is not known, so are
+// invalid; callers obtain unknown public key shares before verifying.
+func verifyShare(data []byte, pubKey *bls.PublicKey, root []byte) (*bls.Sign, bool) {
+	if pubKey == nil {
+		return nil, true
 	}
-	respChannel := make(chan *thresholdSignResponse, len(clients))
-	errChannel := make(chan error, len(clients))
 
-	ctx, cancelFunc := context.WithTimeout(ctx, a.wallet.timeout)
-	defer cancelFunc()
-	for id, client := range clients {
-		go func(client pb.SignerClient, id uint64, req *pb.SignBeaconAttestationsRequest) {
-			resp, err := client.SignBeaconAttestations(ctx, req)
-			if err != nil {
-				errChannel <- err
-			} else {
-				respChannel <- &thresholdSignResponse{
-					id:   id,
-					resp: resp,
-				}
-			}
-		}(client, id, req)
+	var signature bls.Sign
+	if err := signature.Deserialize(data); err != nil {
+		return nil, true
+	}
+	if !signature.VerifyByte(pubKey, root) {
+		return nil, true
 	}
-	span.AddEvent("Contacted all servers")
 
-	// Wait for enough responses (or context done).
-	responses := 0
-	signed := make([]int, len(thresholds))
-	denied := make([]int, len(thresholds))
-	failed := make([]int, len(thresholds))
-	errored := make([]int, len(thresholds))
-	ids := make([][]bls.ID, len(thresholds))
-	signatureBytes := make([][][]byte, len(thresholds))
-	for i := range ids {
-		ids[i] = make([]bls.ID, 0, len(clients))
-		signatureBytes[i] = make([][]byte, 0, len(clients))
-	}
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, errors.New("context done")
-		case <-errChannel:
-			for i := range errored {
-				errored[i]++
-			}
-			responses++
-		case resp := <-respChannel:
-			responses++
-			for i, response := range resp.resp.GetResponses() {
-				switch response.GetState() {
-				case pb.ResponseState_DENIED:
-					denied[i]++
-				case pb.ResponseState_FAILED:
-					failed[i]++
-				case pb.ResponseState_UNKNOWN:
-					// We consider unknown to be failed.
-					failed[i]++
-				case pb.ResponseState_SUCCEEDED:
-					signed[i]++
-					ids[i] = append(ids[i], *blsID(resp.id))
-					signatureBytes[i] = append(signatureBytes[i], response.GetSignature())
-				}
-			}
-		}
+	return &signature, false
+}
 
-		// See if we have enough successful responses for all requests.
-		if responses == len(clients) {
-			// We have all the responses; done by definition.
-			break
-		}
+// shareKeyRequest is a request for the public key share of a participant in
+// a distributed account.
+type shareKeyRequest struct {
+	account *distributedAccount
+	id      uint64
+}
 
-		// We could be done early if we have enough signatures.
-		done := true
-		for i := range ids {
-			if len(ids[i]) != int(thresholds[i]) {
-				done = false
-				break
+// obtainShareKeys obtains the public key shares of the participants in the
+// given accounts that are not already known, with a single request to each
+// participant.  Shares are only stored once they are checked against the
+// composite public key of their account; accounts for which enough checked
+// shares cannot be obtained are returned with the reason.
+func (w *wallet) obtainShareKeys(ctx context.Context,
+	accounts []*distributedAccount,
+) map[*distributedAccount]error {
+	endpoints := make(map[string]*Endpoint)
+	requests := make(map[string][]*shareKeyRequest)
+	for _, account := range accounts {
+		for id, endpoint := range account.participants {
+			if account.participantPubKey(id) != nil || w.endpointDown(endpoint) {
+				continue
 			}
-		}
-		if done {
-			break
+			endpoints[endpoint.String()] = endpoint
+			requests[endpoint.String()] = append(requests[endpoint.String()], &shareKeyRequest{account: account, id: id})
 		}
 	}
-	span.AddEvent("Received responses")
 
-	// Take the signature bytes, turn them in to real signatures, then
-	// recover the final signature from the components.
-	sem := semaphore.NewWeighted(int64(runtime.GOMAXPROCS(0)))
+	obtained := make(map[*distributedAccount]map[uint64]*bls.PublicKey)
+	var obtainedMu sync.Mutex
 	var wg sync.WaitGroup
-	res := make([]e2types.Signature, len(thresholds))
-	var err error
-	for i := range ids {
+	for key, endpoint := range endpoints {
 		wg.Add(1)
-		go func(_ context.Context, _ *semaphore.Weighted, wg *sync.WaitGroup, i int) {
+		go func(endpoint *Endpoint, requests []*shareKeyRequest) {
 			defer wg.Done()
-			if signed[i] != int(thresholds[i]) {
-				// Not enough components to make the composite signature.
-				return
-			}
 
-			components := make([]bls.Sign, thresholds[i])
-			for j := range thresholds[i] {
-				if err := components[j].Deserialize(signatureBytes[i][j]); err != nil {
-					return
-				}
-			}
-			var signature bls.Sign
-			if err := signature.Recover(components, ids[i][0:thresholds[i]]); err != nil {
-				// Invalid components.
+			pubKeys, err := w.participantShareKeys(ctx, endpoint, requests)
+			if err != nil {
+				w.log.Debug().Stringer("endpoint", endpoint).Err(err).Msg("Failed to obtain public key shares")
+
 				return
 			}
-			res[i], err = e2types.BLSSignatureFromSig(signature)
-			if err != nil {
-				// Invalid composite signature.
-				res[i] = nil
+			obtainedMu.Lock()
+			for i, request := range requests {
+				if pubKeys[i] == nil {
+					continue
+				}
+				if _, exists := obtained[request.account]; !exists {
+					obtained[request.account] = make(map[uint64]*bls.PublicKey)
+				}
+				obtained[request.account][request.id] = pubKeys[i]
 			}
-		}(ctx, sem, &wg, i)
+			obtainedMu.Unlock()
+		}(endpoint, requests[key])
 	}
 	wg.Wait()
-	span.AddEvent("Recovered signatures")
 
-	return res, nil
-}
+	res := make(map[*distributedAccount]error)
+	for _, account := range accounts {
+		known := account.knownParticipantPubKeys()
+		if len(known) >= int(account.signingThreshold) {
+			for id, pubKey := range obtained[account] {
+				w.addShareKey(account, id, pubKey)
+			}
 
-// thresholdSignBeaconProposal handles signing, with a threshold of responses.
-func (a *distributedAccount) thresholdSignBeaconProposal(ctx context.Context, req *pb.SignBeaconProposalRequest) (e2types.Signature, error) {
-	ctx, span := otel.Tracer("wealdtech.go-eth2-wallet-dirk").Start(ctx, "thresholdSignBeaconProposal")
-	defer span.End()
+			continue
+		}
 
-	clients := make(map[uint64]pb.SignerClient, len(a.Participants()))
+		for id, pubKey := range obtained[account] {
+			known[id] = pubKey
+		}
+		if err := checkShareKeys(account.compositePubKey, account.signingThreshold, known); err != nil {
+			res[account] = err
 
-	for id, endpoint := range a.participants {
-		conn, release, err := a.wallet.connectionProvider.Connection(ctx, endpoint)
-		if err != nil {
-			return nil, errors.Wrap(err, fmt.Sprintf("failed to connect to endpoint %v", endpoint))
+			continue
 		}
-		defer release()
+		for id, pubKey := range known {
+			account.setParticipantPubKey(id, pubKey)
+		}
+	}
 
-		clients[id] = pb.NewSignerClient(conn)
-		if clients[id] == nil {
-			return nil, fmt.Errorf("failed to set up signing client for %v", endpoint)
+	return res
+}
+
+// obtainParticipantShareKeys obtains the public key shares of a single
+// participant in the given accounts that are not already known, for example
+// because the participant was unreachable when the accounts were listed.
+// The accounts must share the participant's endpoint.
+func (w *wallet) obtainParticipantShareKeys(ctx context.Context,
+	id uint64,
+	accounts []*distributedAccount,
+) error {
+	requests := make([]*shareKeyRequest, 0, len(accounts))
+	for _, account := range accounts {
+		if account.participantPubKey(id) == nil {
+			requests = append(requests, &shareKeyRequest{account: account, id: id})
 		}
 	}
+	if len(requests) == 0 {
+		return nil
+	}
 
-	type multiSignResponse struct {
-		id   uint64
-		resp *pb.SignResponse
+	pubKeys, err := w.participantShareKeys(ctx, accounts[0].participants[id], requests)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain public key shares")
+	}
+	for i, request := range requests {
+		if pubKeys[i] != nil {
+			w.addShareKey(request.account, request.id, pubKeys[i])
+		}
 	}
-	respChannel := make(chan *multiSignResponse, len(clients))
-	errChannel := make(chan error, len(clients))
 
-	ctx, cancelFunc := context.WithTimeout(ctx, a.wallet.timeout)
-	defer cancelFunc()
-	for id, client := range clients {
-		go func(client pb.SignerClient, id uint64, req *pb.SignBeaconProposalRequest) {
-			resp, err := client.SignBeaconProposal(ctx, req)
-			if err != nil {
-				errChannel <- err
-			} else {
-				respChannel <- &multiSignResponse{
-					id:   id,
-					resp: resp,
-				}
-			}
-		}(client, id, req)
+	return nil
+}
+
+// addShareKey stores a public key share of a participant in an account if,
+// along with the known shares of the account, it recovers the composite
+// public key.  The known shares have already been checked, so a share that
+// fails is that of the participant.
+func (w *wallet) addShareKey(account *distributedAccount, id uint64, pubKey *bls.PublicKey) {
+	pubKeys := account.knownParticipantPubKeys()
+	pubKeys[id] = pubKey
+	if err := checkShareKeys(account.compositePubKey, account.signingThreshold, pubKeys); err != nil {
+		w.log.Warn().Uint64("participant", id).Stringer("endpoint", account.participants[id]).Str("account", account.name).Err(err).Msg("Participant returned invalid public key share")
+
+		return
 	}
-	span.AddEvent("Contacted all servers")
+	account.setParticipantPubKey(id, pubKey)
+}
 
-	// Wait for enough responses (or context done).
-	signed := 0
-	denied := 0
-	failed := 0
-	errored := 0
-	ids := make([]bls.ID, a.signingThreshold)
-	signatures := make([]bls.Sign, a.signingThreshold)
-	for signed != int(a.signingThreshold) && signed+denied+failed+errored != len(clients) {
-		select {
-		case <-ctx.Done():
-			return nil, errors.New("context done")
-		case <-errChannel:
-			errored++
-		case resp := <-respChannel:
-			switch resp.resp.GetState() {
-			case pb.ResponseState_DENIED:
-				denied++
-			case pb.ResponseState_FAILED:
-				failed++
-			case pb.ResponseState_UNKNOWN:
-				// We consider unknown to be failed.
-				failed++
-			case pb.ResponseState_SUCCEEDED:
-				ids[signed] = *blsID(resp.id)
-				if err := signatures[signed].Deserialize(resp.resp.GetSignature()); err != nil {
-					return nil, errors.Wrap(err, fmt.Sprintf("invalid signature received from %d", resp.id))
-				}
-				signed++
+// participantShareKeys obtains the public key shares held by a participant
+// for the given requests.  Shares that the participant does not hold are nil.
+func (w *wallet) participantShareKeys(ctx context.Context,
+	endpoint *Endpoint,
+	requests []*shareKeyRequest,
+) (
+	[]*bls.PublicKey,
+	error,
+) {
+	paths := make([]string, len(requests))
+	for i, request := range requests {
+		paths[i] = fmt.Sprintf("%s/%s", w.Name(), request.account.Name())
+	}
+
+	conn, release, err := w.connectionProvider.Connection(ctx, endpoint)
+	if err != nil {
+		return nil, errors.Wrap(transportError(endpoint, err), fmt.Sprintf("failed to connect to endpoint %v", endpoint))
+	}
+	defer release()
+
+	resp, err := pb.NewListerClient(conn).ListAccounts(ctx, &pb.ListAccountsRequest{
+		Paths: paths,
+	})
+	if err != nil {
+		return nil, errors.Wrap(transportError(endpoint, err), "failed to access dirk")
+	}
+	if resp.GetState() != pb.ResponseState_SUCCEEDED {
+		return nil, stateError("list accounts", endpoint, resp.GetState(), "")
+	}
+
+	pubKeys := make([]*bls.PublicKey, len(requests))
+	for _, respAccount := range resp.GetDistributedAccounts() {
+		for i, request := range requests {
+			if !bytes.Equal(request.account.compositePubKey.Marshal(), respAccount.GetCompositePublicKey()) {
+				continue
+			}
+			var pubKey bls.PublicKey
+			if err := pubKey.Deserialize(respAccount.GetPublicKey()); err != nil {
+				return nil, errors.Wrap(err, fmt.Sprintf("public key share %#x invalid", respAccount.GetPublicKey()))
 			}
+			pubKeys[i] = &pubKey
 		}
 	}
-	span.AddEvent("Received responses", trace.WithAttributes(
-		attribute.Int("signed", signed),
-		attribute.Int("denied", denied),
-		attribute.Int("failed", failed),
-		attribute.Int("errored", errored),
-	))
-	if signed != int(a.signingThreshold) {
-		return nil, fmt.Errorf("not enough signatures: %d signed, %d denied, %d failed, %d errored", signed, denied, failed, errored)
+
+	return pubKeys, nil
+}
+
+// checkShareKeys checks that any threshold of the public key shares recover
+// the composite public key.  It recovers the composite public key from the
+// first threshold shares, and then from each other share in place of the last
+// of those, which between them show that all shares lie on the same polynomial.
+func checkShareKeys(compositePubKey e2types.PublicKey,
+	threshold uint32,
+	pubKeys map[uint64]*bls.PublicKey,
+) error {
+	if len(pubKeys) < int(threshold) {
+		return fmt.Errorf("%d public key shares obtained, %d required", len(pubKeys), threshold)
+	}
+
+	ids := make([]uint64, 0, len(pubKeys))
+	for id := range pubKeys {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	recovers := func(ids []uint64) bool {
+		shares := make([]bls.PublicKey, len(ids))
+		shareIDs := make([]bls.ID, len(ids))
+		for i, id := range ids {
+			shares[i] = *pubKeys[id]
+			shareIDs[i] = *blsID(id)
+		}
+		var recovered bls.PublicKey
+		if err := recovered.Recover(shares, shareIDs); err != nil {
+			return false
+		}
+
+		return bytes.Equal(recovered.Serialize(), compositePubKey.Marshal())
 	}
 
-	var signature bls.Sign
-	if err := signature.Recover(signatures, ids); err != nil {
-		return nil, errors.Wrap(err, "failed to recover composite signature")
+	base := ids[:threshold]
+	if !recovers(base) {
+		return errors.New("public key shares do not recover composite public key")
+	}
+	for _, id := range ids[threshold:] {
+		subset := make([]uint64, 0, threshold)
+		subset = append(subset, base[:threshold-1]...)
+		subset = append(subset, id)
+		if !recovers(subset) {
+			return fmt.Errorf("public key share of participant %d does not match composite public key", id)
+		}
 	}
-	span.AddEvent("Recovered signature")
 
-	//nolint:wrapcheck
-	return e2types.BLSSignatureFromSig(signature)
+	return nil
 }
 
 // blsID turns a uint64 in to a BLS identifier.
@@ -1573,12 +2033,18 @@ func (w *wallet) obtainDistributedAccount(respAccount *pb.DistributedAccount) (
 	} else {
 		name = respAccount.GetName()
 	}
+	if err := checkParticipants(respAccount.GetParticipants(), respAccount.GetSigningThreshold()); err != nil {
+		return nil, err
+	}
 	participants := make(map[uint64]*Endpoint, len(respAccount.GetParticipants()))
 	for _, participant := range respAccount.GetParticipants() {
 		participants[participant.GetId()] = &Endpoint{
 			host: participant.GetName(),
 			port: participant.GetPort(),
 		}
+		if w.healthChecker != nil {
+			w.healthChecker.register(participants[participant.GetId()])
+		}
 	}
 
 	account = newDistributedAccount(w, uuid, name, pubKey, compositePubKey, respAccount.GetSigningThreshold(), participants, 1)
@@ -1589,3 +2055,29 @@ func (w *wallet) obtainDistributedAccount(respAccount *pb.DistributedAccount) (
 
 	return account, nil
 }
+
+// checkParticipants checks that the participants of a distributed account
+// can provide signatures at its signing threshold.
+func checkParticipants(participants []*pb.Endpoint, signingThreshold uint32) error {
+	if len(participants) == 0 {
+		return errors.New("no participants")
+	}
+	if signingThreshold == 0 || int(signingThreshold) > len(participants) {
+		return fmt.Errorf("signing threshold %d invalid for %d participants", signingThreshold, len(participants))
+	}
+	ids := make(map[uint64]bool, len(participants))
+	for _, participant := range participants {
+		if participant.GetId() == 0 {
+			return errors.New("participant ID 0 invalid")
+		}
+		if ids[participant.GetId()] {
+			return fmt.Errorf("participant %d duplicated", participant.GetId())
+		}
+		ids[participant.GetId()] = true
+		if participant.GetName() == "" || participant.GetPort() == 0 {
+			return fmt.Errorf("participant %d endpoint invalid", participant.GetId())
+		}
+	}
+
+	return nil
+}
@@ -21,6 +21,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/herumi/bls-eth-go-binary/bls"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
@@ -69,7 +70,11 @@ func NewBufConnectionProvider(_ context.Context,
 }
 
 func (c *BufConnectionProvider) bufDialer(_ context.Context, in string) (net.Conn, error) {
-	return c.listeners[in].Dial()
+	c.mutex.Lock()
+	listener := c.listeners[in]
+	c.mutex.Unlock()
+
+	return listener.Dial()
 }
 
 // Connection returns a connection and release function.
@@ -84,9 +89,10 @@ func (c *BufConnectionProvider) Connection(ctx context.Context, endpoint *Endpoi
 			pb.RegisterListerServer(server, c.listerServers[int(endpoint.port)%len(c.listerServers)])
 		}
 		c.servers[serverAddress] = server
-		c.listeners[serverAddress] = bufconn.Listen(bufSize)
+		listener := bufconn.Listen(bufSize)
+		c.listeners[serverAddress] = listener
 		go func() {
-			if err := server.Serve(c.listeners[serverAddress]); err != nil {
+			if err := server.Serve(listener); err != nil {
 				log.Fatalf("Buffer server error: %v", err)
 			}
 		}()
@@ -134,6 +140,142 @@ func TestListGRPCErroring(t *testing.T) {
 	require.Equal(t, 8, accounts)
 }
 
+func TestVerifyShare(t *testing.T) {
+	require.NoError(t, e2types.InitBLS())
+
+	var secretKey bls.SecretKey
+	secretKey.SetByCSPRNG()
+	var otherSecretKey bls.SecretKey
+	otherSecretKey.SetByCSPRNG()
+	root := _byte("31664419a53cd839c3ebee6009dbaf309e2b15d8faf48d6f6fd64be6e94f36a8")
+	sig := secretKey.SignByte(root).Serialize()
+
+	tests := []struct {
+		name    string
+		data    []byte
+		pubKey  *bls.PublicKey
+		root    []byte
+		invalid bool
+	}{
+		{
+			name:    "PubKeyMissing",
+			data:    sig,
+			root:    root,
+			invalid: true,
+		},
+		{
+			name:    "SignatureCorrupt",
+			data:    sig[1:],
+			pubKey:  secretKey.GetPublicKey(),
+			root:    root,
+			invalid: true,
+		},
+		{
+			name:    "PubKeyIncorrect",
+			data:    sig,
+			pubKey:  otherSecretKey.GetPublicKey(),
+			root:    root,
+			invalid: true,
+		},
+		{
+			name:    "RootIncorrect",
+			data:    sig,
+			pubKey:  secretKey.GetPublicKey(),
+			root:    _byte("0000000000000000000000000000000000000000000000000000000000000000"),
+			invalid: true,
+		},
+		{
+			name:   "Good",
+			data:   sig,
+			pubKey: secretKey.GetPublicKey(),
+			root:   root,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			signature, invalid := verifyShare(test.data, test.pubKey, test.root)
+			require.Equal(t, test.invalid, invalid)
+			if !test.invalid {
+				require.Equal(t, test.data, signature.Serialize())
+			}
+		})
+	}
+}
+
+func TestCheckShareKeys(t *testing.T) {
+	require.NoError(t, e2types.InitBLS())
+
+	var secretKey bls.SecretKey
+	secretKey.SetByCSPRNG()
+	compositePubKey, err := e2types.BLSPublicKeyFromBytes(secretKey.GetPublicKey().Serialize())
+	require.NoError(t, err)
+	masterKey := secretKey.GetMasterSecretKey(2)
+	shareKeys := make(map[uint64]*bls.PublicKey)
+	for id := uint64(1); id <= 3; id++ {
+		var share bls.SecretKey
+		require.NoError(t, share.Set(masterKey, blsID(id)))
+		shareKeys[id] = share.GetPublicKey()
+	}
+	var otherSecretKey bls.SecretKey
+	otherSecretKey.SetByCSPRNG()
+
+	shares := func(ids ...uint64) map[uint64]*bls.PublicKey {
+		res := make(map[uint64]*bls.PublicKey, len(ids))
+		for _, id := range ids {
+			res[id] = shareKeys[id]
+		}
+
+		return res
+	}
+	withOther := func(pubKeys map[uint64]*bls.PublicKey, id uint64) map[uint64]*bls.PublicKey {
+		pubKeys[id] = otherSecretKey.GetPublicKey()
+
+		return pubKeys
+	}
+
+	tests := []struct {
+		name    string
+		pubKeys map[uint64]*bls.PublicKey
+		err     string
+	}{
+		{
+			name:    "Missing",
+			pubKeys: shares(1),
+			err:     "1 public key shares obtained, 2 required",
+		},
+		{
+			name:    "Incorrect",
+			pubKeys: withOther(shares(1, 3), 2),
+			err:     "public key shares do not recover composite public key",
+		},
+		{
+			name:    "ExtraIncorrect",
+			pubKeys: withOther(shares(1, 2), 3),
+			err:     "public key share of participant 3 does not match composite public key",
+		},
+		{
+			name:    "Threshold",
+			pubKeys: shares(2, 3),
+		},
+		{
+			name:    "All",
+			pubKeys: shares(1, 2, 3),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkShareKeys(compositePubKey, 2, test.pubKeys)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
 // Disabled because it results in a link back to Dirk repository for
 //	"github.com/attestantio/dirk/testing/daemon"
 //	"github.com/attestantio/dirk/testing/resources"
@@ -0,0 +1,349 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+)
+
+const (
+	// healthCheckDegradedLatency is the probe latency above which an endpoint is considered degraded.
+	healthCheckDegradedLatency = time.Second
+	// healthCheckDownThreshold is the number of consecutive failures after which an endpoint is considered down.
+	healthCheckDownThreshold = 3
+)
+
+// EndpointState is the health state of an endpoint.
+type EndpointState int
+
+const (
+	// EndpointStateUnknown is the state of an endpoint that has not been checked.
+	EndpointStateUnknown EndpointState = iota
+	// EndpointStateUp is the state of an endpoint that is responding promptly.
+	EndpointStateUp
+	// EndpointStateDegraded is the state of an endpoint that is responding slowly, or has recently failed.
+	EndpointStateDegraded
+	// EndpointStateDown is the state of an endpoint that has repeatedly failed.
+	EndpointStateDown
+)
+
+// String implements the stringer interface.
+func (s EndpointState) String() string {
+	switch s {
+	case EndpointStateUnknown:
+		return "unknown"
+	case EndpointStateUp:
+		return "up"
+	case EndpointStateDegraded:
+		return "degraded"
+	case EndpointStateDown:
+		return "down"
+	default:
+		return "invalid"
+	}
+}
+
+// EndpointStatus is the health status of an endpoint.
+type EndpointStatus struct {
+	// Endpoint is the address of the endpoint.
+	Endpoint string
+	// State is the health state of the endpoint.
+	State EndpointState
+	// Latency is the latency of the most recent successful probe.
+	Latency time.Duration
+	// LastChecked is the time of the most recent probe or request failure.
+	LastChecked time.Time
+	// ConsecutiveFailures is the number of failures since the last success.
+	ConsecutiveFailures int
+	// LastErr is the most recent error, if any.
+	LastErr error
+}
+
+// EndpointStatusProvider is the interface for wallets that track the health of their endpoints.
+type EndpointStatusProvider interface {
+	// EndpointStatus provides the health status of the endpoints known to the wallet.
+	EndpointStatus() []*EndpointStatus
+}
+
+// endpointHealth is the tracked health of an endpoint.
+type endpointHealth struct {
+	endpoint *Endpoint
+	status   EndpointStatus
+}
+
+// healthChecker tracks the health of endpoints, probing them periodically.
+type healthChecker struct {
+	log      zerolog.Logger
+	interval time.Duration
+	timeout  time.Duration
+	probe    func(ctx context.Context, endpoint *Endpoint) error
+
+	mu        sync.RWMutex
+	endpoints map[string]*endpointHealth
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newHealthChecker creates a new health checker.
+func newHealthChecker(log zerolog.Logger,
+	interval time.Duration,
+	timeout time.Duration,
+	probe func(ctx context.Context, endpoint *Endpoint) error,
+) *healthChecker {
+	return &healthChecker{
+		log:       log,
+		interval:  interval,
+		timeout:   timeout,
+		probe:     probe,
+		endpoints: make(map[string]*endpointHealth),
+	}
+}
+
+// register adds an endpoint to those tracked by the health checker.
+func (h *healthChecker) register(endpoint *Endpoint) {
+	key := endpoint.String()
+	h.mu.Lock()
+	if _, exists := h.endpoints[key]; !exists {
+		h.endpoints[key] = &endpointHealth{
+			endpoint: endpoint,
+			status: EndpointStatus{
+				Endpoint: key,
+			},
+		}
+	}
+	h.mu.Unlock()
+}
+
+// start starts probing the tracked endpoints in the background.
+func (h *healthChecker) start(ctx context.Context) {
+	ctx, h.cancel = context.WithCancel(ctx)
+	h.done = make(chan struct{})
+	go func() {
+		defer close(h.done)
+		h.run(ctx)
+	}()
+}
+
+// stop stops probing the tracked endpoints, waiting for any probes in progress.
+func (h *healthChecker) stop() {
+	if h.cancel == nil {
+		return
+	}
+	h.cancel()
+	<-h.done
+}
+
+// run probes the tracked endpoints until the context is done.
+func (h *healthChecker) run(ctx context.Context) {
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+	h.checkAll(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.checkAll(ctx)
+		}
+	}
+}
+
+// checkAll probes all tracked endpoints concurrently.
+func (h *healthChecker) checkAll(ctx context.Context) {
+	h.mu.RLock()
+	endpoints := make([]*Endpoint, 0, len(h.endpoints))
+	for _, health := range h.endpoints {
+		endpoints = append(endpoints, health.endpoint)
+	}
+	h.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, endpoint := range endpoints {
+		wg.Add(1)
+		go func(endpoint *Endpoint) {
+			defer wg.Done()
+			h.check(ctx, endpoint)
+		}(endpoint)
+	}
+	wg.Wait()
+}
+
+// check probes a single endpoint and records the result.
+func (h *healthChecker) check(ctx context.Context, endpoint *Endpoint) {
+	probeCtx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	started := time.Now()
+	err := h.probe(probeCtx, endpoint)
+	if ctx.Err() != nil {
+		// Shutting down, so the result tells us nothing about the endpoint.
+		return
+	}
+	h.record(endpoint, time.Since(started), err)
+}
+
+// record records the outcome of a probe or request to an endpoint.
+func (h *healthChecker) record(endpoint *Endpoint, latency time.Duration, err error) {
+	key := endpoint.String()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	health, exists := h.endpoints[key]
+	if !exists {
+		health = &endpointHealth{
+			endpoint: endpoint,
+			status: EndpointStatus{
+				Endpoint: key,
+			},
+		}
+		h.endpoints[key] = health
+	}
+
+	previous := health.status.State
+	health.status.LastChecked = time.Now()
+	health.status.LastErr = err
+	switch {
+	case err != nil:
+		health.status.ConsecutiveFailures++
+		if health.status.ConsecutiveFailures >= healthCheckDownThreshold {
+			health.status.State = EndpointStateDown
+		} else {
+			health.status.State = EndpointStateDegraded
+		}
+	case latency > healthCheckDegradedLatency:
+		health.status.ConsecutiveFailures = 0
+		health.status.Latency = latency
+		health.status.State = EndpointStateDegraded
+	default:
+		health.status.ConsecutiveFailures = 0
+		health.status.Latency = latency
+		health.status.State = EndpointStateUp
+	}
+
+	if health.status.State != previous {
+		h.log.Debug().Str("endpoint", key).Stringer("previous", previous).Stringer("state", health.status.State).Err(err).Msg("Endpoint state changed")
+	}
+}
+
+// isDown returns true if the endpoint is known to be down.
+func (h *healthChecker) isDown(endpoint *Endpoint) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	health, exists := h.endpoints[endpoint.String()]
+
+	return exists && health.status.State == EndpointStateDown
+}
+
+// state returns the state of the endpoint.
+func (h *healthChecker) state(endpoint *Endpoint) EndpointState {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	health, exists := h.endpoints[endpoint.String()]
+	if !exists {
+		return EndpointStateUnknown
+	}
+
+	return health.status.State
+}
+
+// statuses returns the status of all tracked endpoints, ordered by address.
+func (h *healthChecker) statuses() []*EndpointStatus {
+	h.mu.RLock()
+	res := make([]*EndpointStatus, 0, len(h.endpoints))
+	for _, health := range h.endpoints {
+		status := health.status
+		res = append(res, &status)
+	}
+	h.mu.RUnlock()
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Endpoint < res[j].Endpoint
+	})
+
+	return res
+}
+
+// EndpointStatus provides the health status of the endpoints known to the wallet,
+// including the participants of distributed accounts that have been listed.
+// If health checking is disabled the state of all endpoints is unknown.
+func (w *wallet) EndpointStatus() []*EndpointStatus {
+	if w.healthChecker == nil {
+		res := make([]*EndpointStatus, len(w.endpoints))
+		for i := range w.endpoints {
+			res[i] = &EndpointStatus{
+				Endpoint: w.endpoints[i].String(),
+			}
+		}
+
+		return res
+	}
+
+	return w.healthChecker.statuses()
+}
+
+// probeEndpoint checks that an endpoint is able to service requests.  Any
+// response to a request for an account that cannot exist shows that the
+// endpoint is up, regardless of its state.
+func (w *wallet) probeEndpoint(ctx context.Context, endpoint *Endpoint) error {
+	conn, release, err := w.connectionProvider.Connection(ctx, endpoint)
+	if err != nil {
+		return transportError(endpoint, err)
+	}
+	defer release()
+
+	_, err = pb.NewListerClient(conn).ListAccounts(ctx, &pb.ListAccountsRequest{
+		Paths: []string{
+			fmt.Sprintf("%s/^$", w.name),
+		},
+	})
+	if err != nil {
+		return transportError(endpoint, err)
+	}
+
+	return nil
+}
+
+// endpointDown returns true if health checking is enabled and the endpoint is down.
+func (w *wallet) endpointDown(endpoint *Endpoint) bool {
+	if w.healthChecker == nil {
+		return false
+	}
+
+	return w.healthChecker.isDown(endpoint)
+}
+
+// endpointFailed records a failed request to an endpoint.  Requests that
+// fail because their context is done, for example because the caller's
+// deadline has passed, say nothing about the endpoint so are ignored.
+func (w *wallet) endpointFailed(ctx context.Context, endpoint *Endpoint, err error) {
+	if w.healthChecker == nil {
+		return
+	}
+	if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	w.healthChecker.record(endpoint, 0, err)
+}
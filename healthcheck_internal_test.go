@@ -0,0 +1,136 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	mock "github.com/wealdtech/go-eth2-wallet-dirk/mock"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestHealthCheckerRecord(t *testing.T) {
+	endpoint := &Endpoint{host: "localhost", port: 12345}
+	h := newHealthChecker(zerolog.Nop(), time.Second, time.Second, nil)
+	h.register(endpoint)
+	require.Equal(t, EndpointStateUnknown, h.statuses()[0].State)
+
+	h.record(endpoint, time.Millisecond, nil)
+	require.Equal(t, EndpointStateUp, h.statuses()[0].State)
+	require.Equal(t, time.Millisecond, h.statuses()[0].Latency)
+
+	h.record(endpoint, 2*healthCheckDegradedLatency, nil)
+	require.Equal(t, EndpointStateDegraded, h.statuses()[0].State)
+
+	for i := 1; i < healthCheckDownThreshold; i++ {
+		h.record(endpoint, 0, errors.New("mock error"))
+		require.Equal(t, EndpointStateDegraded, h.statuses()[0].State)
+		require.False(t, h.isDown(endpoint))
+	}
+	h.record(endpoint, 0, errors.New("mock error"))
+	require.Equal(t, EndpointStateDown, h.statuses()[0].State)
+	require.Equal(t, healthCheckDownThreshold, h.statuses()[0].ConsecutiveFailures)
+	require.EqualError(t, h.statuses()[0].LastErr, "mock error")
+	require.True(t, h.isDown(endpoint))
+
+	h.record(endpoint, time.Millisecond, nil)
+	require.Equal(t, EndpointStateUp, h.statuses()[0].State)
+	require.Zero(t, h.statuses()[0].ConsecutiveFailures)
+	require.False(t, h.isDown(endpoint))
+}
+
+func TestEndpointFailedContextDone(t *testing.T) {
+	ctx := context.Background()
+	endpoint := &Endpoint{host: "localhost", port: 12345}
+	w := &wallet{
+		healthChecker: newHealthChecker(zerolog.Nop(), time.Second, time.Second, nil),
+	}
+	w.healthChecker.register(endpoint)
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	for range healthCheckDownThreshold {
+		w.endpointFailed(canceledCtx, endpoint, errors.New("mock error"))
+		w.endpointFailed(ctx, endpoint, fmt.Errorf("mock error: %w", context.DeadlineExceeded))
+		w.endpointFailed(ctx, endpoint, context.Canceled)
+	}
+	require.Equal(t, EndpointStateUnknown, w.healthChecker.state(endpoint))
+
+	for range healthCheckDownThreshold {
+		w.endpointFailed(ctx, endpoint, errors.New("mock error"))
+	}
+	require.True(t, w.healthChecker.isDown(endpoint))
+}
+
+func TestEndpointStatusDisabled(t *testing.T) {
+	ctx := context.Background()
+	w, err := OpenWallet(ctx, "Test wallet", credentials.NewTLS(nil), []*Endpoint{{host: "localhost", port: 12345}})
+	require.NoError(t, err)
+	statuses := w.(EndpointStatusProvider).EndpointStatus()
+	require.Len(t, statuses, 1)
+	require.Equal(t, "localhost:12345", statuses[0].Endpoint)
+	require.Equal(t, EndpointStateUnknown, statuses[0].State)
+}
+
+func TestHealthCheckIntervalParameter(t *testing.T) {
+	ctx := context.Background()
+	_, err := Open(ctx,
+		WithName("Test wallet"),
+		WithCredentials(credentials.NewTLS(nil)),
+		WithEndpoints([]*Endpoint{{host: "localhost", port: 12345}}),
+		WithHealthCheckInterval(-time.Second),
+	)
+	require.EqualError(t, err, "problem with parameters: invalid health check interval specified")
+}
+
+func TestHealthCheckSkipsDownEndpoints(t *testing.T) {
+	require.NoError(t, e2types.InitBLS())
+	ctx := context.Background()
+	// Endpoints are served by the server at index (port % 2).
+	connectionProvider, err := NewBufConnectionProvider(ctx, []pb.ListerServer{&mock.ErroringListerServer{}, &mock.MockListerServer{}})
+	require.NoError(t, err)
+	w, err := OpenWallet(ctx, "Test wallet", credentials.NewTLS(nil), []*Endpoint{
+		{host: "localhost", port: 12344},
+		{host: "localhost", port: 12345},
+	})
+	require.NoError(t, err)
+	w.(*wallet).SetConnectionProvider(connectionProvider)
+	w.(*wallet).healthChecker = newHealthChecker(zerolog.Nop(), time.Minute, time.Second, w.(*wallet).probeEndpoint)
+	for _, endpoint := range w.(*wallet).endpoints {
+		w.(*wallet).healthChecker.register(endpoint)
+	}
+
+	for range healthCheckDownThreshold {
+		w.(*wallet).healthChecker.checkAll(ctx)
+	}
+	statuses := w.(EndpointStatusProvider).EndpointStatus()
+	require.Len(t, statuses, 2)
+	require.Equal(t, EndpointStateDown, statuses[0].State)
+	require.Equal(t, EndpointStateUp, statuses[1].State)
+	require.Equal(t, []*Endpoint{w.(*wallet).endpoints[1]}, w.(*wallet).availableEndpoints())
+
+	// Listing accounts registers the participants of distributed accounts.
+	accounts, err := w.(*wallet).List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, accounts, 8)
+	require.Greater(t, len(w.(EndpointStatusProvider).EndpointStatus()), 2)
+}
@@ -0,0 +1,148 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"sort"
+	"time"
+)
+
+// participantLauncher starts requests to the participants of a distributed
+// account in order of preference, tracking how many have been started.
+type participantLauncher struct {
+	order    []uint64
+	next     int
+	received map[uint64]bool
+	start    func(id uint64)
+}
+
+// newParticipantLauncher creates a launcher for requests to the given
+// participants.  If hedging is enabled the participants are ordered by
+// health and recent latency, so that the best participants are started first.
+func (w *wallet) newParticipantLauncher(participants map[uint64]*Endpoint,
+	ids []uint64,
+	start func(id uint64),
+) *participantLauncher {
+	order := make([]uint64, len(ids))
+	copy(order, ids)
+	if w.hedgeDelay > 0 {
+		w.orderParticipants(participants, order)
+	}
+
+	return &participantLauncher{
+		order:    order,
+		received: make(map[uint64]bool, len(order)),
+		start:    start,
+	}
+}
+
+// launch starts requests to up to the given number of further participants.
+func (l *participantLauncher) launch(count int) {
+	for ; count > 0 && l.next < len(l.order); count-- {
+		l.start(l.order[l.next])
+		l.next++
+	}
+}
+
+// receive records that a response has been received from a participant.
+func (l *participantLauncher) receive(id uint64) {
+	l.received[id] = true
+}
+
+// outstanding returns the participants whose requests have been started but
+// that have not yet responded.
+func (l *participantLauncher) outstanding() []uint64 {
+	res := make([]uint64, 0)
+	for _, id := range l.order[:l.next] {
+		if !l.received[id] {
+			res = append(res, id)
+		}
+	}
+
+	return res
+}
+
+// remaining returns the number of participants whose requests have not been started.
+func (l *participantLauncher) remaining() int {
+	return len(l.order) - l.next
+}
+
+// topUp starts requests to further participants so that the requests
+// outstanding are enough to provide the number of signature shares still
+// needed, assuming that they all succeed.
+func (l *participantLauncher) topUp(needed int) {
+	l.launch(needed - len(l.outstanding()))
+}
+
+// hedge starts requests to all remaining participants once the hedge delay
+// has passed.  Participants that have not yet responded are recorded as
+// having at least the hedge delay as their latency, so that they are not
+// preferred for future requests to participants.
+func (w *wallet) hedge(participants map[uint64]*Endpoint, launcher *participantLauncher) {
+	for _, id := range launcher.outstanding() {
+		w.endpointSelector.observeHedged(participants[id], w.hedgeDelay)
+	}
+
+	launcher.launch(launcher.remaining())
+}
+
+// initialParticipants returns the number of participants to which requests
+// should be sent at the start of threshold signing.
+func (w *wallet) initialParticipants(threshold int, participants int) int {
+	if w.hedgeDelay > 0 {
+		return threshold
+	}
+
+	return participants
+}
+
+// hedgeTimer returns a channel that fires once the hedge delay has passed,
+// or nil if hedging is disabled, along with a function to release the timer.
+func (w *wallet) hedgeTimer() (<-chan time.Time, func()) {
+	if w.hedgeDelay <= 0 {
+		return nil, func() {}
+	}
+	timer := time.NewTimer(w.hedgeDelay)
+
+	return timer.C, func() { timer.Stop() }
+}
+
+// orderParticipants orders participant IDs by preference: endpoints that are
+// up or unknown before those that are degraded, then by recent latency.
+func (w *wallet) orderParticipants(participants map[uint64]*Endpoint, ids []uint64) {
+	states := make(map[uint64]EndpointState, len(ids))
+	for _, id := range ids {
+		states[id] = EndpointStateUnknown
+		if w.healthChecker != nil {
+			states[id] = w.healthChecker.state(participants[id])
+		}
+	}
+	latencies := make(map[uint64]time.Duration, len(ids))
+	for _, id := range ids {
+		latencies[id] = w.endpointSelector.participantLatency(participants[id])
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		iDegraded := states[ids[i]] == EndpointStateDegraded
+		jDegraded := states[ids[j]] == EndpointStateDegraded
+		if iDegraded != jDegraded {
+			return jDegraded
+		}
+		if latencies[ids[i]] != latencies[ids[j]] {
+			return latencies[ids[i]] < latencies[ids[j]]
+		}
+
+		return ids[i] < ids[j]
+	})
+}
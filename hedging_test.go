@@ -0,0 +1,192 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	mock "github.com/wealdtech/go-eth2-wallet-dirk/mock"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// requests returns the number of requests for the given RPC received by all servers in a cluster.
+func requests(cluster *mock.Cluster, method string) uint64 {
+	res := uint64(0)
+	for _, server := range cluster.Servers() {
+		res += server.Requests(method)
+	}
+
+	return res
+}
+
+func TestHedgeDelayParameter(t *testing.T) {
+	ctx := context.Background()
+	_, err := dirk.Open(ctx,
+		dirk.WithName("Test wallet"),
+		dirk.WithCredentials(credentials.NewTLS(nil)),
+		dirk.WithEndpoints([]*dirk.Endpoint{dirk.NewEndpoint("localhost", 12345)}),
+		dirk.WithHedgeDelay(-time.Second),
+	)
+	require.EqualError(t, err, "problem with parameters: invalid hedge delay specified")
+}
+
+func TestHedgedSign(t *testing.T) {
+	ctx := context.Background()
+	denied := pb.ResponseState_DENIED
+
+	// The first server is used to list accounts, so it has a recorded latency
+	// and participants 2, 3 and 4 are contacted first.
+	tests := []struct {
+		name     string
+		faults   []*mock.Fault
+		requests uint64
+	}{
+		{
+			name:     "Healthy",
+			requests: 3,
+		},
+		{
+			name: "Denied",
+			faults: []*mock.Fault{
+				{Endpoint: "signer-test02:12002", Method: "Sign", State: &denied},
+			},
+			requests: 4,
+		},
+		{
+			name: "Slow",
+			faults: []*mock.Fault{
+				{Endpoint: "signer-test02:12002", Method: "Sign", Latency: time.Minute},
+				{Endpoint: "signer-test01:12001", Method: "Sign", Err: status.Error(codes.Unavailable, "connection dropped")},
+			},
+			// Neither the slow request nor the dropped request reach their servers.
+			requests: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := newCluster(t, 5)
+			_, err := cluster.AddDistributedAccount("Wallet", "Account", 5, 3, []byte("pass"))
+			require.NoError(t, err)
+			wallet := openClusterWallet(ctx, t, cluster, "Wallet",
+				dirk.WithTimeout(5*time.Second),
+				dirk.WithHedgeDelay(100*time.Millisecond),
+			)
+			account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+			require.NoError(t, err)
+
+			faultInjector := mock.NewFaultInjector(1)
+			for _, fault := range test.faults {
+				faultInjector.Add(fault)
+			}
+			cluster.SetFaultInjector(faultInjector)
+
+			data := bytes.Repeat([]byte{0x01}, 32)
+			domain := bytes.Repeat([]byte{0x03}, 32)
+			started := time.Now()
+			sig, err := account.(e2wtypes.AccountProtectingSigner).SignGeneric(ctx, data, domain)
+			require.NoError(t, err)
+			require.Less(t, time.Since(started), 5*time.Second)
+			require.True(t, sig.Verify(genericSigningRoot(data, domain), account.(e2wtypes.AccountCompositePublicKeyProvider).CompositePublicKey()))
+			require.Equal(t, test.requests, requests(cluster, "Sign"))
+		})
+	}
+}
+
+func TestHedgedSignPrefersFastParticipants(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddDistributedAccount("Wallet", "Account", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet",
+		dirk.WithTimeout(5*time.Second),
+		dirk.WithHedgeDelay(100*time.Millisecond),
+	)
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+	domain := bytes.Repeat([]byte{0x03}, 32)
+
+	// The first server has been used to list accounts, so participants 2
+	// and 3 are contacted first; make participant 2 slow, so that the hedge
+	// is used.
+	faultInjector := mock.NewFaultInjector(1)
+	faultInjector.Add(&mock.Fault{Endpoint: "signer-test02:12002", Method: "Sign", Latency: time.Minute})
+	cluster.SetFaultInjector(faultInjector)
+	_, err = account.(e2wtypes.AccountProtectingSigner).SignGeneric(ctx, bytes.Repeat([]byte{0x01}, 32), domain)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), cluster.Server(2).Requests("Sign"))
+
+	// Subsequent requests go to the faster participants only.
+	for i := range 3 {
+		_, err = account.(e2wtypes.AccountProtectingSigner).SignGeneric(ctx, bytes.Repeat([]byte{byte(i + 2)}, 32), domain)
+		require.NoError(t, err)
+	}
+	require.Equal(t, uint64(4), cluster.Server(1).Requests("Sign"))
+	require.Equal(t, uint64(0), cluster.Server(2).Requests("Sign"))
+	require.Equal(t, uint64(4), cluster.Server(3).Requests("Sign"))
+}
+
+func TestHedgedMultiSign(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddDistributedAccount("Wallet", "Account 1", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet",
+		dirk.WithTimeout(5*time.Second),
+		dirk.WithHedgeDelay(100*time.Millisecond),
+	)
+	account1, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+	account2, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 2")
+	require.NoError(t, err)
+
+	// Participants 2 and 3 are contacted first; a participant that fails to sign is replaced.
+	faultInjector := mock.NewFaultInjector(1)
+	faultInjector.Add(&mock.Fault{Endpoint: "signer-test02:12002", Method: "Multisign", CorruptSignatures: true})
+	cluster.SetFaultInjector(faultInjector)
+	data := [][]byte{
+		bytes.Repeat([]byte{0x01}, 32),
+		bytes.Repeat([]byte{0x02}, 32),
+	}
+	domain := bytes.Repeat([]byte{0x03}, 32)
+	sigs, err := account1.(e2wtypes.AccountProtectingMultiSigner).SignGenericMulti(ctx, []e2wtypes.Account{account1, account2}, data, domain)
+	require.NoError(t, err)
+	require.Len(t, sigs, 2)
+	require.True(t, sigs[0].Verify(genericSigningRoot(data[0], domain), account1.(e2wtypes.AccountCompositePublicKeyProvider).CompositePublicKey()))
+	require.True(t, sigs[1].Verify(genericSigningRoot(data[1], domain), account2.(e2wtypes.AccountCompositePublicKeyProvider).CompositePublicKey()))
+	require.Equal(t, uint64(3), requests(cluster, "Multisign"))
+
+	// Without faults only enough participants to reach the threshold are contacted.
+	faultInjector.Clear()
+	data = [][]byte{
+		bytes.Repeat([]byte{0x04}, 32),
+		bytes.Repeat([]byte{0x05}, 32),
+	}
+	sigs, err = account1.(e2wtypes.AccountProtectingMultiSigner).SignGenericMulti(ctx, []e2wtypes.Account{account1, account2}, data, domain)
+	require.NoError(t, err)
+	require.NotNil(t, sigs[0])
+	require.NotNil(t, sigs[1])
+	require.Equal(t, uint64(5), requests(cluster, "Multisign"))
+}
@@ -0,0 +1,185 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// interchangeFormatVersion is the supported version of the EIP-3076
+// slashing protection interchange format.
+const interchangeFormatVersion = "5"
+
+// interchange is the EIP-3076 slashing protection interchange format.
+type interchange struct {
+	Metadata *interchangeMetadata `json:"metadata"`
+	Data     []*interchangeData   `json:"data"`
+}
+
+type interchangeMetadata struct {
+	InterchangeFormatVersion string `json:"interchange_format_version"`
+	GenesisValidatorsRoot    string `json:"genesis_validators_root"`
+}
+
+type interchangeData struct {
+	Pubkey             string                    `json:"pubkey"`
+	SignedBlocks       []*interchangeBlock       `json:"signed_blocks"`
+	SignedAttestations []*interchangeAttestation `json:"signed_attestations"`
+}
+
+type interchangeBlock struct {
+	Slot        string `json:"slot"`
+	SigningRoot string `json:"signing_root,omitempty"`
+}
+
+type interchangeAttestation struct {
+	SourceEpoch string `json:"source_epoch"`
+	TargetEpoch string `json:"target_epoch"`
+	SigningRoot string `json:"signing_root,omitempty"`
+}
+
+// Import imports slashing protection data in the EIP-3076 interchange
+// format.  Imported data is merged with existing records, keeping the
+// highest slots and epochs for each validator.
+func (p *SlashingProtection) Import(ctx context.Context, r io.Reader) error {
+	data := &interchange{}
+	if err := json.NewDecoder(r).Decode(data); err != nil {
+		return errors.Wrap(err, "invalid interchange data")
+	}
+	if data.Metadata == nil {
+		return errors.New("interchange metadata missing")
+	}
+	if data.Metadata.InterchangeFormatVersion != interchangeFormatVersion {
+		return fmt.Errorf("unsupported interchange format version %s", data.Metadata.InterchangeFormatVersion)
+	}
+	genesisValidatorsRoot, err := decodeInterchangeHex(data.Metadata.GenesisValidatorsRoot)
+	if err != nil {
+		return errors.Wrap(err, "invalid genesis validators root")
+	}
+	if !bytes.Equal(genesisValidatorsRoot, p.genesisValidatorsRoot) {
+		return errors.New("interchange data is for a different chain")
+	}
+
+	// Parse all data before recording any, so that invalid data is not partially imported.
+	records := make(map[[48]byte]*SlashingProtectionRecord)
+	for _, validator := range data.Data {
+		pubKey, err := decodeInterchangeHex(validator.Pubkey)
+		if err != nil || len(pubKey) != 48 {
+			return fmt.Errorf("invalid public key %s", validator.Pubkey)
+		}
+		record, exists := records[[48]byte(pubKey)]
+		if !exists {
+			record = &SlashingProtectionRecord{}
+			records[[48]byte(pubKey)] = record
+		}
+		for _, block := range validator.SignedBlocks {
+			slot, err := strconv.ParseUint(block.Slot, 10, 64)
+			if err != nil {
+				return errors.Wrapf(err, "invalid slot for %s", validator.Pubkey)
+			}
+			record.merge(&SlashingProtectionRecord{Proposed: true, ProposalSlot: slot})
+		}
+		for _, attestation := range validator.SignedAttestations {
+			sourceEpoch, err := strconv.ParseUint(attestation.SourceEpoch, 10, 64)
+			if err != nil {
+				return errors.Wrapf(err, "invalid source epoch for %s", validator.Pubkey)
+			}
+			targetEpoch, err := strconv.ParseUint(attestation.TargetEpoch, 10, 64)
+			if err != nil {
+				return errors.Wrapf(err, "invalid target epoch for %s", validator.Pubkey)
+			}
+			record.merge(&SlashingProtectionRecord{Attested: true, SourceEpoch: sourceEpoch, TargetEpoch: targetEpoch})
+		}
+	}
+
+	for pubKey, record := range records {
+		if err := p.record(ctx, pubKey, record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Export exports slashing protection data in the EIP-3076 interchange
+// format.  As only the highest slots and epochs are held, each validator has
+// at most a single block and attestation, which is sufficient to protect
+// against slashing when imported.
+func (p *SlashingProtection) Export(ctx context.Context, w io.Writer) error {
+	p.mu.Lock()
+	records, err := p.store.Records(ctx)
+	p.mu.Unlock()
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain slashing protection records")
+	}
+
+	pubKeys := make([][48]byte, 0, len(records))
+	for pubKey := range records {
+		pubKeys = append(pubKeys, pubKey)
+	}
+	sort.Slice(pubKeys, func(i, j int) bool { return bytes.Compare(pubKeys[i][:], pubKeys[j][:]) < 0 })
+
+	data := &interchange{
+		Metadata: &interchangeMetadata{
+			InterchangeFormatVersion: interchangeFormatVersion,
+			GenesisValidatorsRoot:    fmt.Sprintf("%#x", p.genesisValidatorsRoot),
+		},
+		Data: make([]*interchangeData, 0, len(pubKeys)),
+	}
+	for _, pubKey := range pubKeys {
+		record := records[pubKey]
+		validator := &interchangeData{
+			Pubkey:             fmt.Sprintf("%#x", pubKey),
+			SignedBlocks:       make([]*interchangeBlock, 0, 1),
+			SignedAttestations: make([]*interchangeAttestation, 0, 1),
+		}
+		if record.Proposed {
+			validator.SignedBlocks = append(validator.SignedBlocks, &interchangeBlock{
+				Slot: strconv.FormatUint(record.ProposalSlot, 10),
+			})
+		}
+		if record.Attested {
+			validator.SignedAttestations = append(validator.SignedAttestations, &interchangeAttestation{
+				SourceEpoch: strconv.FormatUint(record.SourceEpoch, 10),
+				TargetEpoch: strconv.FormatUint(record.TargetEpoch, 10),
+			})
+		}
+		data.Data = append(data.Data, validator)
+	}
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		return errors.Wrap(err, "failed to write interchange data")
+	}
+
+	return nil
+}
+
+// decodeInterchangeHex decodes a 0x-prefixed hex string.
+func decodeInterchangeHex(input string) ([]byte, error) {
+	if !strings.HasPrefix(input, "0x") {
+		return nil, errors.New("missing 0x prefix")
+	}
+
+	return hex.DecodeString(input[2:])
+}
@@ -16,14 +16,21 @@ package dirk
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
 )
 
 var (
-	connections   *prometheus.GaugeVec
-	connectionsMu sync.Mutex
+	connections          *prometheus.GaugeVec
+	credentialsReloads   *prometheus.CounterVec
+	certificateExpiry    *prometheus.GaugeVec
+	requestDuration      *prometheus.HistogramVec
+	requests             *prometheus.CounterVec
+	listingDisagreements *prometheus.CounterVec
+	connectionsMu        sync.Mutex
 )
 
 func registerMetrics(ctx context.Context, monitor Metrics) error {
@@ -56,6 +63,57 @@ func registerPrometheusMetrics(_ context.Context) error {
 			return errors.Wrap(err, "failed to register dirk_server_connections")
 		}
 	}
+	if credentialsReloads == nil {
+		credentialsReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dirk",
+			Name:      "credentials_reloads_total",
+			Help:      "Reloads of client credentials",
+		}, []string{"result"})
+		if err := prometheus.Register(credentialsReloads); err != nil {
+			return errors.Wrap(err, "failed to register dirk_credentials_reloads_total")
+		}
+	}
+	if certificateExpiry == nil {
+		certificateExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "dirk",
+			Name:      "certificate_expiry_seconds",
+			Help:      "Seconds until expiry of certificates",
+		}, []string{"certificate", "subject"})
+		if err := prometheus.Register(certificateExpiry); err != nil {
+			return errors.Wrap(err, "failed to register dirk_certificate_expiry_seconds")
+		}
+	}
+	if requestDuration == nil {
+		requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "dirk",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of requests to remote Dirk servers",
+			Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+		}, []string{"operation", "server"})
+		if err := prometheus.Register(requestDuration); err != nil {
+			return errors.Wrap(err, "failed to register dirk_request_duration_seconds")
+		}
+	}
+	if requests == nil {
+		requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dirk",
+			Name:      "requests_total",
+			Help:      "Requests to remote Dirk servers",
+		}, []string{"operation", "server", "result"})
+		if err := prometheus.Register(requests); err != nil {
+			return errors.Wrap(err, "failed to register dirk_requests_total")
+		}
+	}
+	if listingDisagreements == nil {
+		listingDisagreements = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "dirk",
+			Name:      "listing_disagreements_total",
+			Help:      "Accounts on which remote Dirk servers disagree when listing",
+		}, []string{"attribute"})
+		if err := prometheus.Register(listingDisagreements); err != nil {
+			return errors.Wrap(err, "failed to register dirk_listing_disagreements_total")
+		}
+	}
 
 	return nil
 }
@@ -72,6 +130,62 @@ func decConnections(address string) {
 	}
 }
 
+func credentialsReloaded(succeeded bool) {
+	if credentialsReloads != nil {
+		if succeeded {
+			credentialsReloads.WithLabelValues("succeeded").Inc()
+		} else {
+			credentialsReloads.WithLabelValues("failed").Inc()
+		}
+	}
+}
+
+func setCertificateExpiry(role string, subject string, remaining time.Duration) {
+	if certificateExpiry != nil {
+		certificateExpiry.WithLabelValues(role, subject).Set(remaining.Seconds())
+	}
+}
+
+// clearCertificateExpiry removes the expiry of a certificate that is no longer in use.
+func clearCertificateExpiry(role string, subject string) {
+	if certificateExpiry != nil {
+		certificateExpiry.DeleteLabelValues(role, subject)
+	}
+}
+
+// requestOutcome returns the outcome of a request for metrics.
+func requestOutcome(state pb.ResponseState, err error) string {
+	if err != nil {
+		return "errored"
+	}
+
+	switch state {
+	case pb.ResponseState_SUCCEEDED:
+		return "succeeded"
+	case pb.ResponseState_DENIED:
+		return "denied"
+	case pb.ResponseState_FAILED:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+func observeRequest(operation string, endpoint *Endpoint, duration time.Duration, outcome string) {
+	if requestDuration != nil {
+		requestDuration.WithLabelValues(operation, endpoint.String()).Observe(duration.Seconds())
+	}
+	if requests != nil {
+		requests.WithLabelValues(operation, endpoint.String(), outcome).Inc()
+	}
+}
+
+func listingDisagreed(attribute string) {
+	if listingDisagreements != nil {
+		listingDisagreements.WithLabelValues(attribute).Inc()
+	}
+}
+
 // Metrics is an interface to a metrics provider.
 type Metrics interface {
 	// Presenter returns the presenter for the metrics.
@@ -0,0 +1,89 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	mock "github.com/wealdtech/go-eth2-wallet-dirk/mock"
+	"google.golang.org/grpc/credentials"
+)
+
+type prometheusMetrics struct{}
+
+func (m *prometheusMetrics) Presenter() string {
+	return "prometheus"
+}
+
+func TestRequestOutcome(t *testing.T) {
+	require.Equal(t, "succeeded", requestOutcome(pb.ResponseState_SUCCEEDED, nil))
+	require.Equal(t, "denied", requestOutcome(pb.ResponseState_DENIED, nil))
+	require.Equal(t, "failed", requestOutcome(pb.ResponseState_FAILED, nil))
+	require.Equal(t, "unknown", requestOutcome(pb.ResponseState_UNKNOWN, nil))
+	require.Equal(t, "errored", requestOutcome(pb.ResponseState_SUCCEEDED, errors.New("mock error")))
+}
+
+func TestMultisignState(t *testing.T) {
+	require.Equal(t, pb.ResponseState_UNKNOWN, multisignState(nil))
+	require.Equal(t, pb.ResponseState_SUCCEEDED, multisignState(&pb.MultisignResponse{
+		Responses: []*pb.SignResponse{
+			{State: pb.ResponseState_SUCCEEDED},
+			{State: pb.ResponseState_SUCCEEDED},
+		},
+	}))
+	require.Equal(t, pb.ResponseState_DENIED, multisignState(&pb.MultisignResponse{
+		Responses: []*pb.SignResponse{
+			{State: pb.ResponseState_SUCCEEDED},
+			{State: pb.ResponseState_DENIED},
+			{State: pb.ResponseState_FAILED},
+		},
+	}))
+}
+
+func TestRequestMetrics(t *testing.T) {
+	require.NoError(t, e2types.InitBLS())
+	ctx := context.Background()
+	require.NoError(t, registerMetrics(ctx, &prometheusMetrics{}))
+
+	// Endpoints are served by the server at index (port % 3).
+	connectionProvider, err := NewBufConnectionProvider(ctx, []pb.ListerServer{
+		&mock.MockListerServer{},
+		&mock.DenyingListerServer{},
+		&mock.ErroringListerServer{},
+	})
+	require.NoError(t, err)
+	w, err := OpenWallet(ctx, "Test wallet", credentials.NewTLS(nil), []*Endpoint{
+		{host: "metrics", port: 12345},
+		{host: "metrics", port: 12346},
+		{host: "metrics", port: 12347},
+	})
+	require.NoError(t, err)
+	w.(*wallet).SetConnectionProvider(connectionProvider)
+
+	for _, endpoint := range w.(*wallet).endpoints {
+		w.(*wallet).endpointSelector = newEndpointSelector(EndpointSelectionOrdered, []*Endpoint{endpoint})
+		_, _ = w.(*wallet).List(ctx, "")
+	}
+
+	require.InDelta(t, 1, testutil.ToFloat64(requests.WithLabelValues("list", "metrics:12345", "succeeded")), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(requests.WithLabelValues("list", "metrics:12346", "denied")), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(requests.WithLabelValues("list", "metrics:12347", "errored")), 0)
+	require.GreaterOrEqual(t, testutil.CollectAndCount(requestDuration), 3)
+}
@@ -0,0 +1,408 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/herumi/bls-eth-go-binary/bls"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const clusterBufSize = 1024 * 1024
+
+var (
+	blsInit    sync.Once
+	blsInitErr error
+)
+
+// Cluster is an in-process cluster of mock Dirk servers that hold real keys,
+// including threshold shares of distributed accounts, and serve the lister,
+// signer and account manager services over in-memory connections.
+//
+// Server n, numbered from 1, is named signer-test0n and listens on port
+// 12000+n.  Accounts start unlocked.  Each server keeps its own simple
+// slashing protection: it denies generic signing requests for the beacon
+// proposer and attester domains, proposals that are not for a later slot
+// than the previous proposal, and attestations that are not for a later
+// target epoch or that have an earlier source epoch than the previous
+// attestation.
+type Cluster struct {
+	servers []*Server
+
+	accountsMu sync.RWMutex
+	accounts   map[string]*clusterAccount
+
+	connsMu sync.Mutex
+	conns   map[string]*grpc.ClientConn
+
+	faultInjectorMu sync.RWMutex
+	faultInjector   *FaultInjector
+}
+
+// clusterAccount is an account held by a cluster.
+type clusterAccount struct {
+	name       string
+	uuid       []byte
+	passphrase []byte
+	locked     bool
+	pubKey     *bls.PublicKey
+	// threshold is 0 for accounts that are not distributed.
+	threshold uint32
+	// keys are the keys held by each server, by server ID.
+	keys map[uint64]*bls.SecretKey
+}
+
+// Server is a mock Dirk server in a cluster.
+type Server struct {
+	cluster  *Cluster
+	id       uint64
+	host     string
+	port     uint32
+	listener *bufconn.Listener
+	server   *grpc.Server
+
+	mu   sync.Mutex
+	down bool
+	// requests are the number of requests received, by RPC name.
+	requests map[string]uint64
+	// proposalSlots are the slots of the latest signed proposals, by account.
+	proposalSlots map[string]uint64
+	// attestationEpochs are the source and target epochs of the latest signed attestations, by account.
+	attestationEpochs map[string][2]uint64
+}
+
+// NewCluster creates a new cluster with the given number of servers.
+func NewCluster(_ context.Context, servers int) (*Cluster, error) {
+	if servers < 1 {
+		return nil, errors.New("cluster requires at least one server")
+	}
+	// BLS is initialised once only, as initialising it again can break
+	// signing and verification in progress elsewhere.
+	blsInit.Do(func() { blsInitErr = e2types.InitBLS() })
+	if blsInitErr != nil {
+		return nil, blsInitErr
+	}
+
+	c := &Cluster{
+		servers:  make([]*Server, servers),
+		accounts: make(map[string]*clusterAccount),
+		conns:    make(map[string]*grpc.ClientConn),
+	}
+	for i := range c.servers {
+		id := uint64(i + 1)
+		s := &Server{
+			cluster:           c,
+			id:                id,
+			host:              fmt.Sprintf("signer-test%02d", id),
+			port:              uint32(12000 + id),
+			listener:          bufconn.Listen(clusterBufSize),
+			requests:          make(map[string]uint64),
+			proposalSlots:     make(map[string]uint64),
+			attestationEpochs: make(map[string][2]uint64),
+		}
+		s.server = grpc.NewServer(grpc.UnaryInterceptor(s.interceptor))
+		pb.RegisterListerServer(s.server, &listerService{server: s})
+		pb.RegisterSignerServer(s.server, &signerService{server: s})
+		pb.RegisterAccountManagerServer(s.server, &accountManagerService{server: s})
+		go func() {
+			// Serve returns when the server is stopped.
+			_ = s.server.Serve(s.listener)
+		}()
+		c.servers[i] = s
+	}
+
+	return c, nil
+}
+
+// Servers returns the servers in the cluster.
+func (c *Cluster) Servers() []*Server {
+	return c.servers
+}
+
+// Server returns the server with the given ID, or nil if there is no such server.
+func (c *Cluster) Server(id uint64) *Server {
+	if id < 1 || id > uint64(len(c.servers)) {
+		return nil
+	}
+
+	return c.servers[id-1]
+}
+
+// Connection returns a connection to the server at the given address, in the
+// form host:port, and a release function.  It can be used to implement a
+// connection provider for a wallet.
+func (c *Cluster) Connection(ctx context.Context, address string) (*grpc.ClientConn, func(), error) {
+	var server *Server
+	for _, s := range c.servers {
+		if s.Address() == address {
+			server = s
+
+			break
+		}
+	}
+	if server == nil {
+		return nil, nil, fmt.Errorf("unknown server %s", address)
+	}
+
+	c.connsMu.Lock()
+	defer c.connsMu.Unlock()
+	conn, exists := c.conns[address]
+	if !exists {
+		var err error
+		conn, err = grpc.DialContext(ctx,
+			address,
+			grpc.WithContextDialer(func(_ context.Context, _ string) (net.Conn, error) {
+				return server.listener.Dial()
+			}),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithUnaryInterceptor(c.intercept),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.conns[address] = conn
+	}
+
+	return conn, func() {}, nil
+}
+
+// SetFaultInjector sets a fault injector for requests made over the
+// cluster's connections.  Nil removes the fault injector.
+func (c *Cluster) SetFaultInjector(faultInjector *FaultInjector) {
+	c.faultInjectorMu.Lock()
+	c.faultInjector = faultInjector
+	c.faultInjectorMu.Unlock()
+}
+
+// intercept passes requests to the fault injector, if present.
+func (c *Cluster) intercept(ctx context.Context,
+	method string,
+	req any,
+	reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	c.faultInjectorMu.RLock()
+	faultInjector := c.faultInjector
+	c.faultInjectorMu.RUnlock()
+	if faultInjector == nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	return faultInjector.intercept(ctx, method, req, reply, cc, invoker, opts...)
+}
+
+// Close stops all servers in the cluster and closes their connections.
+func (c *Cluster) Close() {
+	c.connsMu.Lock()
+	for _, conn := range c.conns {
+		_ = conn.Close()
+	}
+	c.conns = make(map[string]*grpc.ClientConn)
+	c.connsMu.Unlock()
+
+	for _, s := range c.servers {
+		s.server.Stop()
+	}
+}
+
+// AddAccount adds an account that is held in full by every server in the
+// cluster, returning its public key.
+func (c *Cluster) AddAccount(walletName string, accountName string, passphrase []byte) ([]byte, error) {
+	var key bls.SecretKey
+	key.SetByCSPRNG()
+
+	keys := make(map[uint64]*bls.SecretKey, len(c.servers))
+	for _, s := range c.servers {
+		keys[s.id] = &key
+	}
+
+	return c.addAccount(walletName, accountName, passphrase, key.GetPublicKey(), 0, keys)
+}
+
+// AddDistributedAccount adds a distributed account whose key is split in to
+// threshold shares held by the first participants servers in the cluster,
+// returning its composite public key.
+func (c *Cluster) AddDistributedAccount(walletName string,
+	accountName string,
+	participants uint32,
+	threshold uint32,
+	passphrase []byte,
+) (
+	[]byte,
+	error,
+) {
+	if participants < 2 || participants > uint32(len(c.servers)) {
+		return nil, fmt.Errorf("invalid number of participants %d", participants)
+	}
+	if threshold < 1 || threshold > participants {
+		return nil, fmt.Errorf("invalid signing threshold %d", threshold)
+	}
+
+	var key bls.SecretKey
+	key.SetByCSPRNG()
+	masterKey := key.GetMasterSecretKey(int(threshold))
+
+	keys := make(map[uint64]*bls.SecretKey, participants)
+	for _, s := range c.servers[:participants] {
+		var share bls.SecretKey
+		if err := share.Set(masterKey, blsID(s.id)); err != nil {
+			return nil, err
+		}
+		keys[s.id] = &share
+	}
+
+	return c.addAccount(walletName, accountName, passphrase, key.GetPublicKey(), threshold, keys)
+}
+
+// addAccount adds an account to the cluster.
+func (c *Cluster) addAccount(walletName string,
+	accountName string,
+	passphrase []byte,
+	pubKey *bls.PublicKey,
+	threshold uint32,
+	keys map[uint64]*bls.SecretKey,
+) (
+	[]byte,
+	error,
+) {
+	id, err := uuid.New().MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("%s/%s", walletName, accountName)
+	c.accountsMu.Lock()
+	defer c.accountsMu.Unlock()
+	if _, exists := c.accounts[name]; exists {
+		return nil, fmt.Errorf("account %s already exists", name)
+	}
+	c.accounts[name] = &clusterAccount{
+		name:       name,
+		uuid:       id,
+		passphrase: passphrase,
+		pubKey:     pubKey,
+		threshold:  threshold,
+		keys:       keys,
+	}
+
+	return pubKey.Serialize(), nil
+}
+
+// RemoveAccount removes an account from the cluster.
+func (c *Cluster) RemoveAccount(walletName string, accountName string) error {
+	name := fmt.Sprintf("%s/%s", walletName, accountName)
+	c.accountsMu.Lock()
+	defer c.accountsMu.Unlock()
+	if _, exists := c.accounts[name]; !exists {
+		return fmt.Errorf("account %s does not exist", name)
+	}
+	delete(c.accounts, name)
+
+	return nil
+}
+
+// account returns the account with the given name, if present.
+func (c *Cluster) account(name string) (*clusterAccount, bool) {
+	c.accountsMu.RLock()
+	defer c.accountsMu.RUnlock()
+	account, exists := c.accounts[name]
+
+	return account, exists
+}
+
+// ID returns the ID of the server.
+func (s *Server) ID() uint64 {
+	return s.id
+}
+
+// Host returns the host name of the server.
+func (s *Server) Host() string {
+	return s.host
+}
+
+// Port returns the port of the server.
+func (s *Server) Port() uint32 {
+	return s.port
+}
+
+// Address returns the address of the server, in the form host:port.
+func (s *Server) Address() string {
+	return fmt.Sprintf("%s:%d", s.host, s.port)
+}
+
+// SetDown sets whether the server is down.  A server that is down rejects
+// all requests as unavailable.
+func (s *Server) SetDown(down bool) {
+	s.mu.Lock()
+	s.down = down
+	s.mu.Unlock()
+}
+
+// Requests returns the number of requests the server has received for the
+// RPC with the given name, for example "Sign", including those rejected
+// because the server was down.
+func (s *Server) Requests(method string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[method]
+}
+
+// interceptor counts requests, and rejects them when the server is down.
+func (s *Server) interceptor(ctx context.Context,
+	req any,
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (
+	any,
+	error,
+) {
+	s.mu.Lock()
+	s.requests[info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]]++
+	down := s.down
+	s.mu.Unlock()
+	if down {
+		return nil, status.Error(codes.Unavailable, "server down")
+	}
+
+	return handler(ctx, req)
+}
+
+// blsID turns a uint64 in to a BLS identifier.
+func blsID(id uint64) *bls.ID {
+	var res bls.ID
+	buf := [8]byte{}
+	binary.LittleEndian.PutUint64(buf[:], id)
+	if err := res.SetLittleEndian(buf[:]); err != nil {
+		panic(err)
+	}
+
+	return &res
+}
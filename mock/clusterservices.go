@@ -0,0 +1,336 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+)
+
+var (
+	// domainBeaconProposer is the domain type for beacon proposals.
+	domainBeaconProposer = []byte{0x00, 0x00, 0x00, 0x00}
+	// domainBeaconAttester is the domain type for beacon attestations.
+	domainBeaconAttester = []byte{0x01, 0x00, 0x00, 0x00}
+)
+
+// listerService is the lister service of a cluster server.
+type listerService struct {
+	pb.UnimplementedListerServer
+	server *Server
+}
+
+// ListAccounts lists the accounts held by the server that match the requested paths.
+func (l *listerService) ListAccounts(_ context.Context, in *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	s := l.server
+	c := s.cluster
+
+	resp := &pb.ListAccountsResponse{
+		State:               pb.ResponseState_SUCCEEDED,
+		Accounts:            make([]*pb.Account, 0),
+		DistributedAccounts: make([]*pb.DistributedAccount, 0),
+	}
+
+	c.accountsMu.RLock()
+	defer c.accountsMu.RUnlock()
+	for _, account := range c.accounts {
+		key, exists := account.keys[s.id]
+		if !exists || !matchesPaths(account.name, in.GetPaths()) {
+			continue
+		}
+		if account.threshold == 0 {
+			resp.Accounts = append(resp.Accounts, &pb.Account{
+				Name:      account.name,
+				PublicKey: account.pubKey.Serialize(),
+				Uuid:      account.uuid,
+			})
+
+			continue
+		}
+		resp.DistributedAccounts = append(resp.DistributedAccounts, &pb.DistributedAccount{
+			Name:               account.name,
+			PublicKey:          key.GetPublicKey().Serialize(),
+			CompositePublicKey: account.pubKey.Serialize(),
+			SigningThreshold:   account.threshold,
+			Participants:       c.participants(account),
+			Uuid:               account.uuid,
+		})
+	}
+	sort.Slice(resp.Accounts, func(i, j int) bool {
+		return resp.Accounts[i].GetName() < resp.Accounts[j].GetName()
+	})
+	sort.Slice(resp.DistributedAccounts, func(i, j int) bool {
+		return resp.DistributedAccounts[i].GetName() < resp.DistributedAccounts[j].GetName()
+	})
+
+	return resp, nil
+}
+
+// matchesPaths returns true if the account name matches any of the paths.
+// A path is either a wallet name, matching all accounts in the wallet, or
+// a wallet name and account name, where an account name starting with '^'
+// is a regular expression.
+func matchesPaths(name string, paths []string) bool {
+	walletName, accountName, _ := strings.Cut(name, "/")
+	for _, path := range paths {
+		pathWallet, pathAccount, hasAccount := strings.Cut(path, "/")
+		if pathWallet != walletName {
+			continue
+		}
+		if !hasAccount {
+			return true
+		}
+		if strings.HasPrefix(pathAccount, "^") {
+			re, err := regexp.Compile(pathAccount)
+			if err == nil && re.MatchString(accountName) {
+				return true
+			}
+
+			continue
+		}
+		if pathAccount == accountName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// participants returns the endpoints of the servers holding shares of a distributed account.
+func (c *Cluster) participants(account *clusterAccount) []*pb.Endpoint {
+	res := make([]*pb.Endpoint, 0, len(account.keys))
+	for _, s := range c.servers {
+		if _, exists := account.keys[s.id]; exists {
+			res = append(res, &pb.Endpoint{
+				Id:   s.id,
+				Name: s.host,
+				Port: s.port,
+			})
+		}
+	}
+
+	return res
+}
+
+// signerService is the signer service of a cluster server.
+type signerService struct {
+	pb.UnimplementedSignerServer
+	server *Server
+}
+
+// Sign signs generic data.
+func (s *signerService) Sign(_ context.Context, in *pb.SignRequest) (*pb.SignResponse, error) {
+	return s.server.signGeneric(in), nil
+}
+
+// Multisign signs multiple generic data.
+func (s *signerService) Multisign(_ context.Context, in *pb.MultisignRequest) (*pb.MultisignResponse, error) {
+	resp := &pb.MultisignResponse{
+		Responses: make([]*pb.SignResponse, len(in.GetRequests())),
+	}
+	for i, req := range in.GetRequests() {
+		resp.Responses[i] = s.server.signGeneric(req)
+	}
+
+	return resp, nil
+}
+
+// SignBeaconAttestation signs a beacon attestation.
+func (s *signerService) SignBeaconAttestation(_ context.Context, in *pb.SignBeaconAttestationRequest) (*pb.SignResponse, error) {
+	return s.server.signBeaconAttestation(in), nil
+}
+
+// SignBeaconAttestations signs multiple beacon attestations.
+func (s *signerService) SignBeaconAttestations(_ context.Context, in *pb.SignBeaconAttestationsRequest) (*pb.MultisignResponse, error) {
+	resp := &pb.MultisignResponse{
+		Responses: make([]*pb.SignResponse, len(in.GetRequests())),
+	}
+	for i, req := range in.GetRequests() {
+		resp.Responses[i] = s.server.signBeaconAttestation(req)
+	}
+
+	return resp, nil
+}
+
+// SignBeaconProposal signs a beacon proposal.
+func (s *signerService) SignBeaconProposal(_ context.Context, in *pb.SignBeaconProposalRequest) (*pb.SignResponse, error) {
+	return s.server.signBeaconProposal(in), nil
+}
+
+// signGeneric signs generic data, refusing domains that require slashing protection.
+func (s *Server) signGeneric(req *pb.SignRequest) *pb.SignResponse {
+	domain := req.GetDomain()
+	if len(domain) < 4 ||
+		bytes.Equal(domain[:4], domainBeaconProposer) ||
+		bytes.Equal(domain[:4], domainBeaconAttester) {
+		return &pb.SignResponse{State: pb.ResponseState_DENIED}
+	}
+
+	return s.sign(req.GetAccount(), signingRoot(req.GetData(), domain), nil)
+}
+
+// signBeaconAttestation signs a beacon attestation if it is not slashable.
+func (s *Server) signBeaconAttestation(req *pb.SignBeaconAttestationRequest) *pb.SignResponse {
+	name := req.GetAccount()
+	sourceEpoch := req.GetData().GetSource().GetEpoch()
+	targetEpoch := req.GetData().GetTarget().GetEpoch()
+	if sourceEpoch > targetEpoch {
+		return &pb.SignResponse{State: pb.ResponseState_DENIED}
+	}
+
+	return s.sign(name,
+		signingRoot(attestationDataRoot(req.GetData()), req.GetDomain()),
+		func() bool {
+			if epochs, exists := s.attestationEpochs[name]; exists {
+				if targetEpoch <= epochs[1] || sourceEpoch < epochs[0] {
+					return false
+				}
+			}
+			s.attestationEpochs[name] = [2]uint64{sourceEpoch, targetEpoch}
+
+			return true
+		},
+	)
+}
+
+// signBeaconProposal signs a beacon proposal if it is not slashable.
+func (s *Server) signBeaconProposal(req *pb.SignBeaconProposalRequest) *pb.SignResponse {
+	name := req.GetAccount()
+	slot := req.GetData().GetSlot()
+
+	return s.sign(name,
+		signingRoot(beaconBlockHeaderRoot(req.GetData()), req.GetDomain()),
+		func() bool {
+			if lastSlot, exists := s.proposalSlots[name]; exists && slot <= lastSlot {
+				return false
+			}
+			s.proposalSlots[name] = slot
+
+			return true
+		},
+	)
+}
+
+// sign signs a root with the server's key for the named account.  If
+// supplied, protect is called with the server locked, and should record
+// the request and return true if it is safe to sign.
+func (s *Server) sign(name string, root []byte, protect func() bool) *pb.SignResponse {
+	account, exists := s.cluster.account(name)
+	if !exists {
+		return &pb.SignResponse{State: pb.ResponseState_DENIED}
+	}
+	key, exists := account.keys[s.id]
+	if !exists {
+		return &pb.SignResponse{State: pb.ResponseState_DENIED}
+	}
+	s.cluster.accountsMu.RLock()
+	locked := account.locked
+	s.cluster.accountsMu.RUnlock()
+	if locked {
+		return &pb.SignResponse{State: pb.ResponseState_DENIED}
+	}
+
+	if protect != nil {
+		s.mu.Lock()
+		safe := protect()
+		s.mu.Unlock()
+		if !safe {
+			return &pb.SignResponse{State: pb.ResponseState_DENIED}
+		}
+	}
+
+	return &pb.SignResponse{
+		State:     pb.ResponseState_SUCCEEDED,
+		Signature: key.SignByte(root).Serialize(),
+	}
+}
+
+// accountManagerService is the account manager service of a cluster server.
+type accountManagerService struct {
+	pb.UnimplementedAccountManagerServer
+	server *Server
+}
+
+// Unlock unlocks an account given its passphrase.
+func (a *accountManagerService) Unlock(_ context.Context, in *pb.UnlockAccountRequest) (*pb.UnlockAccountResponse, error) {
+	c := a.server.cluster
+	account, exists := c.account(in.GetAccount())
+	if !exists || !bytes.Equal(account.passphrase, in.GetPassphrase()) {
+		return &pb.UnlockAccountResponse{State: pb.ResponseState_DENIED}, nil
+	}
+	c.accountsMu.Lock()
+	account.locked = false
+	c.accountsMu.Unlock()
+
+	return &pb.UnlockAccountResponse{State: pb.ResponseState_SUCCEEDED}, nil
+}
+
+// Lock locks an account.
+func (a *accountManagerService) Lock(_ context.Context, in *pb.LockAccountRequest) (*pb.LockAccountResponse, error) {
+	c := a.server.cluster
+	account, exists := c.account(in.GetAccount())
+	if !exists {
+		return &pb.LockAccountResponse{State: pb.ResponseState_DENIED}, nil
+	}
+	c.accountsMu.Lock()
+	account.locked = true
+	c.accountsMu.Unlock()
+
+	return &pb.LockAccountResponse{State: pb.ResponseState_SUCCEEDED}, nil
+}
+
+// Generate generates an account, distributed across the first servers in
+// the cluster if there is more than one participant.
+func (a *accountManagerService) Generate(_ context.Context, in *pb.GenerateRequest) (*pb.GenerateResponse, error) {
+	c := a.server.cluster
+	walletName, accountName, found := strings.Cut(in.GetAccount(), "/")
+	if !found || walletName == "" || accountName == "" {
+		return &pb.GenerateResponse{
+			State:   pb.ResponseState_FAILED,
+			Message: "invalid account name",
+		}, nil
+	}
+
+	var pubKey []byte
+	var err error
+	if in.GetParticipants() <= 1 {
+		pubKey, err = c.AddAccount(walletName, accountName, in.GetPassphrase())
+	} else {
+		pubKey, err = c.AddDistributedAccount(walletName, accountName, in.GetParticipants(), in.GetSigningThreshold(), in.GetPassphrase())
+	}
+	if err != nil {
+		return &pb.GenerateResponse{
+			State:   pb.ResponseState_FAILED,
+			Message: err.Error(),
+		}, nil
+	}
+
+	resp := &pb.GenerateResponse{
+		State:     pb.ResponseState_SUCCEEDED,
+		PublicKey: pubKey,
+	}
+	if in.GetParticipants() > 1 {
+		account, _ := c.account(in.GetAccount())
+		c.accountsMu.RLock()
+		resp.Participants = c.participants(account)
+		c.accountsMu.RUnlock()
+	}
+
+	return resp, nil
+}
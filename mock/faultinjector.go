@@ -0,0 +1,219 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/herumi/bls-eth-go-binary/bls"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	"google.golang.org/grpc"
+)
+
+// Fault is a fault to inject in to requests.
+type Fault struct {
+	// Endpoint is the address of the endpoint, in the form host:port, to
+	// which the fault applies.  Empty applies the fault to all endpoints.
+	Endpoint string
+	// Method is the name of the RPC, for example "Sign" or "ListAccounts",
+	// to which the fault applies.  Empty applies the fault to all RPCs.
+	Method string
+	// Probability is the probability that the fault applies to a matching
+	// request.  Zero applies the fault to all matching requests.
+	Probability float64
+	// Latency is the delay added before the request is sent.
+	Latency time.Duration
+	// Err is returned in place of sending the request, if set.
+	Err error
+	// State overrides the state of the response, and of each response in a
+	// multiple signing response, if set.  Signatures are removed from
+	// responses that do not succeed.
+	State *pb.ResponseState
+	// CorruptSignatures replaces signatures in the response with valid
+	// signatures over the wrong data.
+	CorruptSignatures bool
+}
+
+// FaultInjector injects faults in to requests made over gRPC connections.
+// Whether a fault applies to a request is decided by a pseudo-random value
+// derived from the seed, the endpoint, the RPC and the number of previous
+// requests to that RPC at that endpoint, so a run with the same seed and the
+// same requests to each endpoint injects the same faults regardless of the
+// order in which concurrent requests are made.
+type FaultInjector struct {
+	seed         [8]byte
+	corruptorKey *bls.SecretKey
+
+	mu     sync.Mutex
+	faults []*Fault
+	calls  map[string]uint64
+}
+
+// NewFaultInjector creates a new fault injector with the given seed.
+func NewFaultInjector(seed int64) *FaultInjector {
+	f := &FaultInjector{
+		corruptorKey: &bls.SecretKey{},
+		calls:        make(map[string]uint64),
+	}
+	binary.LittleEndian.PutUint64(f.seed[:], uint64(seed))
+	keySeed := sha256.Sum256(append([]byte("corruptor"), f.seed[:]...))
+	if err := f.corruptorKey.SetLittleEndianMod(keySeed[:]); err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+// Add adds a fault.  Faults apply in the order in which they are added.
+func (f *FaultInjector) Add(fault *Fault) {
+	f.mu.Lock()
+	f.faults = append(f.faults, fault)
+	f.mu.Unlock()
+}
+
+// Clear removes all faults.
+func (f *FaultInjector) Clear() {
+	f.mu.Lock()
+	f.faults = nil
+	f.mu.Unlock()
+}
+
+// UnaryClientInterceptor returns a gRPC client interceptor that injects faults.
+func (f *FaultInjector) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return f.intercept
+}
+
+// intercept injects faults in to a request.
+func (f *FaultInjector) intercept(ctx context.Context,
+	fullMethod string,
+	req any,
+	reply any,
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	faults := f.matchingFaults(cc.Target(), fullMethod)
+
+	for _, fault := range faults {
+		if fault.Latency > 0 {
+			timer := time.NewTimer(fault.Latency)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if fault.Err != nil {
+			return fault.Err
+		}
+	}
+
+	if err := invoker(ctx, fullMethod, req, reply, cc, opts...); err != nil {
+		return err
+	}
+
+	for _, fault := range faults {
+		f.alterReply(reply, fault)
+	}
+
+	return nil
+}
+
+// matchingFaults returns the faults that apply to a request.
+func (f *FaultInjector) matchingFaults(endpoint string, fullMethod string) []*Fault {
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := endpoint + "|" + method
+	call := f.calls[key]
+	f.calls[key]++
+
+	res := make([]*Fault, 0)
+	for i, fault := range f.faults {
+		if fault.Endpoint != "" && fault.Endpoint != endpoint {
+			continue
+		}
+		if fault.Method != "" && fault.Method != method {
+			continue
+		}
+		if fault.Probability > 0 && f.draw(key, call, i) >= fault.Probability {
+			continue
+		}
+		res = append(res, fault)
+	}
+
+	return res
+}
+
+// draw returns a pseudo-random value in [0,1) for a fault on a request.
+func (f *FaultInjector) draw(key string, call uint64, fault int) float64 {
+	buf := make([]byte, 0, len(f.seed)+len(key)+16)
+	buf = append(buf, f.seed[:]...)
+	buf = append(buf, key...)
+	buf = binary.LittleEndian.AppendUint64(buf, call)
+	buf = binary.LittleEndian.AppendUint64(buf, uint64(fault))
+	hash := sha256.Sum256(buf)
+
+	return float64(binary.LittleEndian.Uint64(hash[:8])>>11) / (1 << 53)
+}
+
+// alterReply applies the state and signature faults to a reply.
+func (f *FaultInjector) alterReply(reply any, fault *Fault) {
+	switch resp := reply.(type) {
+	case *pb.SignResponse:
+		f.alterSignResponse(resp, fault)
+	case *pb.MultisignResponse:
+		for _, response := range resp.GetResponses() {
+			f.alterSignResponse(response, fault)
+		}
+	case *pb.ListAccountsResponse:
+		if fault.State != nil {
+			resp.State = *fault.State
+		}
+	case *pb.UnlockAccountResponse:
+		if fault.State != nil {
+			resp.State = *fault.State
+		}
+	case *pb.LockAccountResponse:
+		if fault.State != nil {
+			resp.State = *fault.State
+		}
+	case *pb.GenerateResponse:
+		if fault.State != nil {
+			resp.State = *fault.State
+		}
+	}
+}
+
+// alterSignResponse applies the state and signature faults to a signing response.
+func (f *FaultInjector) alterSignResponse(resp *pb.SignResponse, fault *Fault) {
+	if fault.State != nil {
+		resp.State = *fault.State
+		if resp.GetState() != pb.ResponseState_SUCCEEDED {
+			resp.Signature = nil
+		}
+	}
+	if fault.CorruptSignatures && len(resp.GetSignature()) > 0 {
+		resp.Signature = f.corruptorKey.SignByte(resp.GetSignature()).Serialize()
+	}
+}
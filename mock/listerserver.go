@@ -15,12 +15,16 @@ package dirk
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/herumi/bls-eth-go-binary/bls"
 	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	"google.golang.org/grpc/metadata"
 )
 
 func _byte(input string) []byte {
@@ -53,12 +57,15 @@ func (s *DenyingListerServer) ListAccounts(_ context.Context, _ *pb.ListAccounts
 }
 
 // MockListerServer is a mock lister server that returns static accounts.
+// When addressed as one of the participants of the distributed accounts,
+// signer-test0n:1200n, it returns the public key share of that participant
+// for each distributed account.
 type MockListerServer struct {
 	pb.UnimplementedListerServer
 }
 
 // ListAccounts returns static accounts.
-func (s *MockListerServer) ListAccounts(_ context.Context, in *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+func (s *MockListerServer) ListAccounts(ctx context.Context, in *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
 	interopAccounts := map[string]*pb.Account{
 		"Interop 0": {
 			Name:      "Interop 0",
@@ -191,6 +198,16 @@ func (s *MockListerServer) ListAccounts(_ context.Context, in *pb.ListAccountsRe
 		},
 	}
 
+	if id := participantID(ctx); id != 0 {
+		for _, account := range allDistributedAccounts {
+			pubKey, err := participantShareKey(account, id)
+			if err != nil {
+				return nil, err
+			}
+			account.PublicKey = pubKey
+		}
+	}
+
 	accounts := make([]*pb.Account, 0)
 	for _, account := range interopAccounts {
 		if len(in.GetPaths()) == 0 {
@@ -235,3 +252,109 @@ func (s *MockListerServer) ListAccounts(_ context.Context, in *pb.ListAccountsRe
 		DistributedAccounts: distributedAccounts,
 	}, nil
 }
+
+// participantID returns the ID of the participant to which a request is
+// addressed, or 0 if it is not addressed to a participant.
+func participantID(ctx context.Context) uint64 {
+	md, exists := metadata.FromIncomingContext(ctx)
+	if !exists || len(md.Get(":authority")) == 0 {
+		return 0
+	}
+	authority := md.Get(":authority")[0]
+	if !strings.HasPrefix(authority, "signer-test") || !strings.Contains(authority, ":") {
+		return 0
+	}
+	port, err := strconv.ParseUint(authority[strings.LastIndex(authority, ":")+1:], 10, 32)
+	if err != nil || port <= 12000 || port > 12005 {
+		return 0
+	}
+
+	return port - 12000
+}
+
+// participantShareKey returns the public key share of a participant in a
+// distributed account.  The shares are those of a polynomial whose constant
+// term is the composite public key, with other terms derived from the name
+// of the account.
+func participantShareKey(account *pb.DistributedAccount, id uint64) ([]byte, error) {
+	terms := make([]bls.PublicKey, account.GetSigningThreshold())
+	if err := terms[0].Deserialize(account.GetCompositePublicKey()); err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(terms); i++ {
+		seed := sha256.Sum256([]byte(fmt.Sprintf("%s/%d", account.GetName(), i)))
+		var key bls.SecretKey
+		if err := key.SetLittleEndianMod(seed[:]); err != nil {
+			return nil, err
+		}
+		terms[i] = *key.GetPublicKey()
+	}
+
+	var share bls.PublicKey
+	if err := share.Set(terms, blsID(id)); err != nil {
+		return nil, err
+	}
+
+	return share.Serialize(), nil
+}
+
+// MalformedListerServer is a mock lister server that returns a valid
+// account alongside malformed accounts.
+type MalformedListerServer struct {
+	pb.UnimplementedListerServer
+}
+
+// ListAccounts returns a valid account and malformed accounts.
+func (s *MalformedListerServer) ListAccounts(_ context.Context, _ *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	participants := []*pb.Endpoint{
+		{
+			Id:   1,
+			Name: "signer-test01",
+			Port: 12001,
+		},
+		{
+			Id:   2,
+			Name: "signer-test02",
+			Port: 12002,
+		},
+	}
+
+	return &pb.ListAccountsResponse{
+		State: pb.ResponseState_SUCCEEDED,
+		Accounts: []*pb.Account{
+			{
+				Name:      "Valid",
+				PublicKey: _byte("0xa99a76ed7796f7be22d5b7e85deeb7c5677e88e511e0b337618f8c4eb61349b4bf2d153f649f7b53359fe8b94a38e44c"),
+				Uuid:      _byte("0x00000000000000000000000000000000"),
+			},
+			{
+				Name:      "Bad public key",
+				PublicKey: _byte("0x0102"),
+				Uuid:      _byte("0x00000000000000000000000000000001"),
+			},
+			{
+				Name:      "Bad UUID",
+				PublicKey: _byte("0xb89bebc699769726a318c8e9971bd3171297c61aea4a6578a7a4f94b547dcba5bac16a89108b6b6a1fe3695d1a874a0b"),
+				Uuid:      _byte("0x0102"),
+			},
+		},
+		DistributedAccounts: []*pb.DistributedAccount{
+			{
+				Name:               "Bad threshold",
+				PublicKey:          _byte("0xaaf4abea98732aa9da46a4ddd8c56c03ec173a4daae90424e986be61d2b07999db746e103d6f505dc98716e91d4f946a"),
+				CompositePublicKey: _byte("0xa155a5fb0a6d732fa0f4d3714a8550ee5b90690475e010fbf89277e98e060203d69eba05fa71b2d0fa6aa6d091172f1e"),
+				SigningThreshold:   3,
+				Participants:       participants,
+				Uuid:               _byte("0x01000000000000000000000000000000"),
+			},
+			{
+				Name:               "Bad participants",
+				PublicKey:          _byte("0x98bc7c7596d70a27a243e6b6acc4a96bf1666428783671cb8545ced08a10c641fae1afbc83b525fce9357f6be667129e"),
+				CompositePublicKey: _byte("0x93c98077de26a2d382910c64664bb34ca3e29a5a6e3222c590b28efe9bc554b607677947cb6a44b168b2da5c74237fba"),
+				SigningThreshold:   2,
+				Participants:       append(participants, &pb.Endpoint{Id: 2, Name: "signer-test03", Port: 12003}),
+				Uuid:               _byte("0x01000000000000000000000000000001"),
+			},
+		},
+	}, nil
+}
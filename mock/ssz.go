@@ -0,0 +1,95 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+)
+
+// signingRoot returns the root of the signing data container for the given object root and domain.
+func signingRoot(objectRoot []byte, domain []byte) []byte {
+	return merkleize([][32]byte{
+		chunk(objectRoot),
+		chunk(domain),
+	})
+}
+
+// attestationDataRoot returns the hash tree root of attestation data.
+func attestationDataRoot(data *pb.AttestationData) []byte {
+	return merkleize([][32]byte{
+		uint64Chunk(data.GetSlot()),
+		uint64Chunk(data.GetCommitteeIndex()),
+		chunk(data.GetBeaconBlockRoot()),
+		chunk(checkpointRoot(data.GetSource())),
+		chunk(checkpointRoot(data.GetTarget())),
+	})
+}
+
+// checkpointRoot returns the hash tree root of a checkpoint.
+func checkpointRoot(checkpoint *pb.Checkpoint) []byte {
+	return merkleize([][32]byte{
+		uint64Chunk(checkpoint.GetEpoch()),
+		chunk(checkpoint.GetRoot()),
+	})
+}
+
+// beaconBlockHeaderRoot returns the hash tree root of a beacon block header.
+func beaconBlockHeaderRoot(header *pb.BeaconBlockHeader) []byte {
+	return merkleize([][32]byte{
+		uint64Chunk(header.GetSlot()),
+		uint64Chunk(header.GetProposerIndex()),
+		chunk(header.GetParentRoot()),
+		chunk(header.GetStateRoot()),
+		chunk(header.GetBodyRoot()),
+	})
+}
+
+// chunk turns a byte slice of up to 32 bytes in to a right-padded chunk.
+func chunk(data []byte) [32]byte {
+	var res [32]byte
+	copy(res[:], data)
+
+	return res
+}
+
+// uint64Chunk turns a uint64 in to a chunk.
+func uint64Chunk(val uint64) [32]byte {
+	var res [32]byte
+	binary.LittleEndian.PutUint64(res[:8], val)
+
+	return res
+}
+
+// merkleize returns the merkle root of the supplied chunks, padding with
+// zero chunks to the next power of two.
+func merkleize(chunks [][32]byte) []byte {
+	width := 1
+	for width < len(chunks) {
+		width *= 2
+	}
+	layer := make([][32]byte, width)
+	copy(layer, chunks)
+	for len(layer) > 1 {
+		next := make([][32]byte, len(layer)/2)
+		for i := range next {
+			next[i] = sha256.Sum256(append(layer[2*i][:], layer[2*i+1][:]...))
+		}
+		layer = next
+	}
+
+	return layer[0][:]
+}
@@ -0,0 +1,125 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// MultiSignStatus is the overall status of a multiple signing request.
+type MultiSignStatus int
+
+const (
+	// MultiSignStatusFailed is the status of a request for which no signatures were obtained.
+	MultiSignStatusFailed MultiSignStatus = iota
+	// MultiSignStatusPartial is the status of a request for which some, but not all, signatures were obtained.
+	MultiSignStatusPartial
+	// MultiSignStatusSucceeded is the status of a request for which all signatures were obtained.
+	MultiSignStatusSucceeded
+)
+
+// String implements the stringer interface.
+func (s MultiSignStatus) String() string {
+	switch s {
+	case MultiSignStatusFailed:
+		return "failed"
+	case MultiSignStatusPartial:
+		return "partial"
+	case MultiSignStatusSucceeded:
+		return "succeeded"
+	default:
+		return "unknown"
+	}
+}
+
+// MultiSignItemResult is the result of a single item in a multiple signing request.
+type MultiSignItemResult struct {
+	// Signature is the composite signature, if it was obtained.
+	Signature e2types.Signature
+	// Err is the reason that the signature was not obtained, if it was not.
+	// This is a *ThresholdError if not enough signature shares were obtained,
+	// or a *CompositeSignatureError if the composite signature failed
+	// verification.
+	Err error
+}
+
+// MultiSignResult is the result of a multiple signing request.
+type MultiSignResult struct {
+	// Items are the results for each item, in the order in which they were requested.
+	Items []*MultiSignItemResult
+}
+
+// Status returns the overall status of the request.
+func (r *MultiSignResult) Status() MultiSignStatus {
+	signed := 0
+	for _, item := range r.Items {
+		if item.Signature != nil {
+			signed++
+		}
+	}
+
+	switch signed {
+	case len(r.Items):
+		return MultiSignStatusSucceeded
+	case 0:
+		return MultiSignStatusFailed
+	default:
+		return MultiSignStatusPartial
+	}
+}
+
+// Signatures returns the signatures for each item, with nil for items
+// whose signatures were not obtained.
+func (r *MultiSignResult) Signatures() []e2types.Signature {
+	res := make([]e2types.Signature, len(r.Items))
+	for i, item := range r.Items {
+		res[i] = item.Signature
+	}
+
+	return res
+}
+
+// AccountMultiSignResultsProvider is the interface for accounts that provide
+// the result of each item in multiple signing requests, rather than leaving
+// a nil signature for items that could not be signed.
+type AccountMultiSignResultsProvider interface {
+	// SignGenericMultiResults signs multiple generic data.
+	SignGenericMultiResults(ctx context.Context,
+		accounts []e2wtypes.Account,
+		data [][]byte,
+		domain []byte,
+	) (
+		*MultiSignResult,
+		error,
+	)
+
+	// SignBeaconAttestationsResults signs multiple beacon attestations.
+	SignBeaconAttestationsResults(ctx context.Context,
+		slot uint64,
+		accounts []e2wtypes.Account,
+		committeeIndices []uint64,
+		blockRoot []byte,
+		sourceEpoch uint64,
+		sourceRoot []byte,
+		targetEpoch uint64,
+		targetRoot []byte,
+		domain []byte,
+	) (
+		*MultiSignResult,
+		error,
+	)
+}
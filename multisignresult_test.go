@@ -0,0 +1,156 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	mock "github.com/wealdtech/go-eth2-wallet-dirk/mock"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSignGenericMultiResults(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddDistributedAccount("Wallet", "Account 1", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 2", 3, 3, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet")
+	account1, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+	account2, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 2")
+	require.NoError(t, err)
+	accounts := []e2wtypes.Account{account1, account2}
+	domain := bytes.Repeat([]byte{0x03}, 32)
+	unavailable := status.Error(codes.Unavailable, "connection dropped")
+
+	tests := []struct {
+		name        string
+		unavailable []string
+		status      dirk.MultiSignStatus
+		errs        []string
+	}{
+		{
+			name:   "Succeeded",
+			status: dirk.MultiSignStatusSucceeded,
+			errs:   []string{"", ""},
+		},
+		{
+			name:        "Partial",
+			unavailable: []string{"signer-test03:12003"},
+			status:      dirk.MultiSignStatusPartial,
+			errs: []string{
+				"",
+				"not enough signatures: 2 signed, 0 denied, 0 failed, 1 errored, 0 invalid",
+			},
+		},
+		{
+			name:        "Failed",
+			unavailable: []string{"signer-test02:12002", "signer-test03:12003"},
+			status:      dirk.MultiSignStatusFailed,
+			errs: []string{
+				"not enough signatures: 1 signed, 0 denied, 0 failed, 2 errored, 0 invalid",
+				"not enough signatures: 1 signed, 0 denied, 0 failed, 2 errored, 0 invalid",
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			faultInjector := mock.NewFaultInjector(1)
+			for _, endpoint := range test.unavailable {
+				faultInjector.Add(&mock.Fault{Endpoint: endpoint, Method: "Multisign", Err: unavailable})
+			}
+			cluster.SetFaultInjector(faultInjector)
+			defer cluster.SetFaultInjector(nil)
+
+			data := [][]byte{
+				bytes.Repeat([]byte{byte(2 * i)}, 32),
+				bytes.Repeat([]byte{byte(2*i + 1)}, 32),
+			}
+			res, err := account1.(dirk.AccountMultiSignResultsProvider).SignGenericMultiResults(ctx, accounts, data, domain)
+			require.NoError(t, err)
+			require.Equal(t, test.status, res.Status())
+			require.Len(t, res.Items, len(accounts))
+			sigs := res.Signatures()
+			for j, item := range res.Items {
+				if test.errs[j] == "" {
+					require.NoError(t, item.Err)
+					require.True(t, item.Signature.Verify(genericSigningRoot(data[j], domain), accounts[j].(e2wtypes.AccountCompositePublicKeyProvider).CompositePublicKey()))
+					require.Equal(t, item.Signature, sigs[j])
+				} else {
+					require.Nil(t, item.Signature)
+					require.Nil(t, sigs[j])
+					require.EqualError(t, item.Err, test.errs[j])
+					var thresholdErr *dirk.ThresholdError
+					require.True(t, errors.As(item.Err, &thresholdErr))
+					require.Equal(t, accounts[j].Name(), thresholdErr.Report.Account)
+				}
+			}
+		})
+	}
+}
+
+func TestSignBeaconAttestationsResults(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddDistributedAccount("Wallet", "Account 1", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet")
+	account1, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+	account2, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 2")
+	require.NoError(t, err)
+
+	signAttestations := func(accounts []e2wtypes.Account, targetEpoch uint64) *dirk.MultiSignResult {
+		committeeIndices := make([]uint64, len(accounts))
+		res, err := account1.(dirk.AccountMultiSignResultsProvider).SignBeaconAttestationsResults(ctx,
+			targetEpoch*32,
+			accounts,
+			committeeIndices,
+			bytes.Repeat([]byte{0x01}, 32),
+			0,
+			bytes.Repeat([]byte{0x02}, 32),
+			targetEpoch,
+			bytes.Repeat([]byte{0x03}, 32),
+			bytes.Repeat([]byte{0x04}, 32),
+		)
+		require.NoError(t, err)
+
+		return res
+	}
+
+	// Sign for the first account alone, so that signing again for the same
+	// target epoch is denied.
+	res := signAttestations([]e2wtypes.Account{account1}, 1)
+	require.Equal(t, dirk.MultiSignStatusSucceeded, res.Status())
+
+	res = signAttestations([]e2wtypes.Account{account1, account2}, 1)
+	require.Equal(t, dirk.MultiSignStatusPartial, res.Status())
+	require.Nil(t, res.Items[0].Signature)
+	require.ErrorIs(t, res.Items[0].Err, dirk.ErrDenied)
+	require.EqualError(t, res.Items[0].Err, "not enough signatures: 0 signed, 3 denied, 0 failed, 0 errored, 0 invalid")
+	require.NotNil(t, res.Items[1].Signature)
+	require.NoError(t, res.Items[1].Err)
+}
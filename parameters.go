@@ -29,6 +29,30 @@ type parameters struct {
 	credentials     credentials.TransportCredentials
 	endpoints       []*Endpoint
 	poolConnections int32
+	// endpointSelection is the policy for selecting endpoints.
+	endpointSelection EndpointSelection
+	// healthCheckInterval is the interval between endpoint health checks; 0 disables health checking.
+	healthCheckInterval time.Duration
+	// certificateExpiryWarnings are the times before certificate expiry at which to warn.
+	certificateExpiryWarnings []time.Duration
+	// verifyCompositeSignatures is true if recovered composite signatures should be verified.
+	verifyCompositeSignatures bool
+	// hedgeDelay is the time to wait for signature shares before contacting further participants; 0 disables hedging.
+	hedgeDelay time.Duration
+	// attestationCoalescingWindow is the time to gather attestations to sign together; 0 disables coalescing.
+	attestationCoalescingWindow time.Duration
+	// genesisTime is the genesis time of the chain, used with slotDuration to provide duty deadlines.
+	genesisTime time.Time
+	// slotDuration is the duration of a slot of the chain; 0 disables duty deadlines.
+	slotDuration time.Duration
+	// slashingProtection is local slashing protection; nil disables it.
+	slashingProtection *SlashingProtection
+	// accountCacheTTL is the time for which cached accounts are used before they are refreshed; 0 disables caching.
+	accountCacheTTL time.Duration
+	// accountCacheRefreshInterval is the interval between background refreshes of cached accounts; 0 disables them.
+	accountCacheRefreshInterval time.Duration
+	// listingQuorum is the number of endpoints that must respond when listing accounts from all endpoints; 0 disables quorum listing.
+	listingQuorum int
 }
 
 // Parameter is the interface for service parameters.
@@ -91,6 +115,132 @@ func WithPoolConnections(connections int32) Parameter {
 	})
 }
 
+// WithEndpointSelection sets the policy for selecting endpoints for requests
+// that can be serviced by any endpoint of the wallet.
+func WithEndpointSelection(selection EndpointSelection) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.endpointSelection = selection
+	})
+}
+
+// WithHealthCheckInterval sets the interval between health checks of the
+// wallet's endpoints.  Endpoints that are down are skipped until they recover.
+// An interval of 0, the default, disables health checking.
+func WithHealthCheckInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.healthCheckInterval = interval
+	})
+}
+
+// WithCertificateExpiryWarnings sets the times before expiry of the client
+// and CA certificates at which warnings are logged.
+func WithCertificateExpiryWarnings(thresholds []time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.certificateExpiryWarnings = thresholds
+	})
+}
+
+// WithCompositeSignatureVerification enables verification of composite signatures recovered from
+// distributed accounts against the composite public key of the account.
+func WithCompositeSignatureVerification(verify bool) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.verifyCompositeSignatures = verify
+	})
+}
+
+// WithHedgeDelay enables hedged signing with distributed accounts.  Signing
+// requests are initially sent only to as many participants as are required
+// to reach the signing threshold, picked by health and recent latency, and
+// sent to the remaining participants if signature shares have not been
+// obtained within the delay.  A participant that does not provide a
+// signature share is replaced immediately.  A delay of 0, the default,
+// disables hedging, and requests are sent to all participants at once.
+func WithHedgeDelay(delay time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.hedgeDelay = delay
+	})
+}
+
+// WithAttestationCoalescing enables coalescing of beacon attestation
+// signing.  Requests to sign single attestations with the same data,
+// received within the window, are signed with a single request for multiple
+// attestations and each caller is given its own signature or error.  This
+// reduces the number of requests made when signing for many accounts, at the
+// cost of delaying each request by up to the window.  A caller whose context
+// is done before its batch is signed is returned an error, but its
+// attestation remains in the batch so is still signed, and recorded for
+// slashing protection, by Dirk.  Pending attestations are signed immediately
+// when the wallet is closed.  A window of 0, the default, disables
+// coalescing.
+func WithAttestationCoalescing(window time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.attestationCoalescingWindow = window
+	})
+}
+
+// WithGenesisTime sets the genesis time of the chain.  Along with the slot
+// duration this enables deadlines for signing duties; see WithSlotDuration.
+func WithGenesisTime(genesisTime time.Time) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.genesisTime = genesisTime
+	})
+}
+
+// WithSlotDuration sets the duration of a slot of the chain.  Along with the
+// genesis time this enables deadlines for signing duties: requests to sign
+// attestations and proposals must complete by the end of their slot, and are
+// rejected without contacting Dirk if their slot has already ended.
+func WithSlotDuration(slotDuration time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slotDuration = slotDuration
+	})
+}
+
+// WithSlashingProtection enables local slashing protection.  Requests to
+// sign proposals and attestations are checked before they are sent to Dirk,
+// and those that are slashable given previously signed requests are refused
+// locally.  Dirk continues to apply its own slashing protection.
+func WithSlashingProtection(protection *SlashingProtection) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.slashingProtection = protection
+	})
+}
+
+// WithAccountCacheTTL enables caching of the wallet's accounts.  Accounts
+// are obtained from Dirk when first required, and again in the background
+// when required after the TTL has passed; the cached accounts continue to be
+// used until they are replaced, so lookups do not wait on Dirk.  Accounts
+// that are not in the cache are looked up in Dirk.
+// A TTL of 0, the default, disables caching.
+func WithAccountCacheTTL(ttl time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.accountCacheTTL = ttl
+	})
+}
+
+// WithAccountCacheRefreshInterval sets the interval between refreshes of the
+// wallet's cached accounts in the background, which requires the account
+// cache to be enabled with WithAccountCacheTTL.  An interval of 0, the
+// default, disables background refreshes.
+func WithAccountCacheRefreshInterval(interval time.Duration) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.accountCacheRefreshInterval = interval
+	})
+}
+
+// WithListingQuorum enables quorum listing of accounts.  Accounts are listed
+// from all endpoints at once rather than from the first endpoint to respond,
+// and listing fails unless at least the quorum of endpoints respond.  The
+// listings are merged, and accounts that are listed by fewer than the quorum
+// of endpoints, or whose public key, composite public key, signing threshold
+// or participants differ between endpoints, are reported as invalid rather
+// than returned.  A quorum of 0, the default, disables quorum listing.
+func WithListingQuorum(quorum int) Parameter {
+	return parameterFunc(func(p *parameters) {
+		p.listingQuorum = quorum
+	})
+}
+
 // parseAndCheckParameters parses and checks parameters to ensure that mandatory parameters are present and correct.
 func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	parameters := parameters{
@@ -98,6 +248,11 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 		timeout:         30 * time.Second,
 		poolConnections: 128,
 		monitor:         &nullMetrics{},
+		certificateExpiryWarnings: []time.Duration{
+			30 * 24 * time.Hour,
+			7 * 24 * time.Hour,
+			24 * time.Hour,
+		},
 	}
 	for _, p := range params {
 		if params != nil {
@@ -123,6 +278,49 @@ func parseAndCheckParameters(params ...Parameter) (*parameters, error) {
 	if parameters.poolConnections < 1 {
 		return nil, errors.New("no pool connections specified")
 	}
+	switch parameters.endpointSelection {
+	case EndpointSelectionOrdered, EndpointSelectionRoundRobin, EndpointSelectionLeastLatency:
+	default:
+		return nil, errors.New("unknown endpoint selection specified")
+	}
+	if parameters.healthCheckInterval < 0 {
+		return nil, errors.New("invalid health check interval specified")
+	}
+	if parameters.hedgeDelay < 0 {
+		return nil, errors.New("invalid hedge delay specified")
+	}
+	if parameters.attestationCoalescingWindow < 0 {
+		return nil, errors.New("invalid attestation coalescing window specified")
+	}
+	if parameters.slotDuration < 0 {
+		return nil, errors.New("invalid slot duration specified")
+	}
+	if parameters.slotDuration > 0 && parameters.genesisTime.IsZero() {
+		return nil, errors.New("no genesis time specified")
+	}
+	if parameters.slotDuration == 0 && !parameters.genesisTime.IsZero() {
+		return nil, errors.New("no slot duration specified")
+	}
+	if parameters.accountCacheTTL < 0 {
+		return nil, errors.New("invalid account cache TTL specified")
+	}
+	if parameters.accountCacheRefreshInterval < 0 {
+		return nil, errors.New("invalid account cache refresh interval specified")
+	}
+	if parameters.accountCacheRefreshInterval > 0 && parameters.accountCacheTTL == 0 {
+		return nil, errors.New("no account cache TTL specified")
+	}
+	if parameters.listingQuorum < 0 {
+		return nil, errors.New("invalid listing quorum specified")
+	}
+	if parameters.listingQuorum > len(parameters.endpoints) {
+		return nil, errors.New("listing quorum exceeds number of endpoints")
+	}
+	for _, threshold := range parameters.certificateExpiryWarnings {
+		if threshold <= 0 {
+			return nil, errors.New("invalid certificate expiry warning specified")
+		}
+	}
 
 	return &parameters, nil
 }
@@ -0,0 +1,224 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+)
+
+// endpointListing is an account as listed by a single endpoint; exactly one
+// of account and distributedAccount is set.
+type endpointListing struct {
+	endpoint           *Endpoint
+	account            *pb.Account
+	distributedAccount *pb.DistributedAccount
+}
+
+// listQuorum lists accounts from all endpoints at once, requiring at least
+// the listing quorum of endpoints to respond.  Accounts are included if at
+// least the listing quorum of endpoints list them and all endpoints that list
+// them agree on their details; other accounts are returned as invalid.
+func (w *wallet) listQuorum(ctx context.Context,
+	req *pb.ListAccountsRequest,
+) (
+	*pb.ListAccountsResponse,
+	[]*InvalidAccountError,
+	error,
+) {
+	resps := make([]*pb.ListAccountsResponse, len(w.endpoints))
+	errs := make([]error, len(w.endpoints))
+	var wg sync.WaitGroup
+	for i := range w.endpoints {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			resps[i], errs[i] = w.listEndpoint(ctx, w.endpoints[i], req)
+		}(i)
+	}
+	wg.Wait()
+
+	responded := 0
+	var err error
+	for i := range w.endpoints {
+		if errs[i] != nil {
+			err = errs[i]
+
+			continue
+		}
+		responded++
+	}
+	if responded < w.listingQuorum {
+		return nil, nil, errors.Wrapf(err, "listing quorum not reached: %d of %d endpoints responded, %d required", responded, len(w.endpoints), w.listingQuorum)
+	}
+
+	// Gather the listings of each account, in the order in which the accounts are first listed.
+	names := make([]string, 0)
+	listings := make(map[string][]*endpointListing)
+	add := func(name string, listing *endpointListing) {
+		if _, exists := listings[name]; !exists {
+			names = append(names, name)
+		}
+		listings[name] = append(listings[name], listing)
+	}
+	for i, resp := range resps {
+		if resp == nil {
+			continue
+		}
+		for _, account := range resp.GetAccounts() {
+			add(account.GetName(), &endpointListing{endpoint: w.endpoints[i], account: account})
+		}
+		for _, distributedAccount := range resp.GetDistributedAccounts() {
+			add(distributedAccount.GetName(), &endpointListing{endpoint: w.endpoints[i], distributedAccount: distributedAccount})
+		}
+	}
+
+	merged := &pb.ListAccountsResponse{
+		State:               pb.ResponseState_SUCCEEDED,
+		Accounts:            make([]*pb.Account, 0),
+		DistributedAccounts: make([]*pb.DistributedAccount, 0),
+	}
+	invalid := make([]*InvalidAccountError, 0)
+	for _, name := range names {
+		attribute := listingDisagreement(listings[name])
+		if attribute == "" && len(listings[name]) < w.listingQuorum {
+			// Too few endpoints list the account, for example because the
+			// others have a stale or partial keystore.
+			attribute = "presence"
+		}
+		if attribute != "" {
+			endpoints := make([]string, len(listings[name]))
+			for i := range listings[name] {
+				endpoints[i] = listings[name][i].endpoint.String()
+			}
+			w.log.Warn().Str("account", name).Str("attribute", attribute).Strs("endpoints", endpoints).Msg("Endpoints disagree on account")
+			listingDisagreed(attribute)
+			invalid = append(invalid, &InvalidAccountError{
+				Name: name,
+				Err: &ListingDisagreementError{
+					Attribute: attribute,
+					Endpoints: endpoints,
+				},
+			})
+
+			continue
+		}
+		if listings[name][0].account != nil {
+			merged.Accounts = append(merged.Accounts, listings[name][0].account)
+		} else {
+			merged.DistributedAccounts = append(merged.DistributedAccounts, listings[name][0].distributedAccount)
+		}
+	}
+
+	return merged, invalid, nil
+}
+
+// listEndpoint lists accounts from a single endpoint.
+func (w *wallet) listEndpoint(ctx context.Context,
+	endpoint *Endpoint,
+	req *pb.ListAccountsRequest,
+) (
+	*pb.ListAccountsResponse,
+	error,
+) {
+	started := time.Now()
+	conn, release, err := w.connectionProvider.Connection(ctx, endpoint)
+	if err != nil {
+		w.endpointSelector.observe(endpoint, time.Since(started), err)
+		observeRequest("list", endpoint, time.Since(started), requestOutcome(pb.ResponseState_UNKNOWN, err))
+		err = transportError(endpoint, err)
+		w.endpointFailed(ctx, endpoint, err)
+
+		return nil, err
+	}
+	resp, err := pb.NewListerClient(conn).ListAccounts(ctx, req)
+	release()
+	w.endpointSelector.observe(endpoint, time.Since(started), err)
+	observeRequest("list", endpoint, time.Since(started), requestOutcome(resp.GetState(), err))
+	if err != nil {
+		w.log.Debug().Stringer("endpoint", endpoint).Err(err).Msg("Request to endpoint failed")
+		err = transportError(endpoint, err)
+		w.endpointFailed(ctx, endpoint, err)
+
+		return nil, err
+	}
+	if resp.GetState() != pb.ResponseState_SUCCEEDED {
+		return nil, stateError("list wallet accounts", endpoint, resp.GetState(), "")
+	}
+
+	return resp, nil
+}
+
+// listingDisagreement returns the attribute on which the listings of an
+// account disagree, or an empty string if they agree.  Distributed accounts
+// have a different public key for each participant, so only their
+// composite public keys are compared.
+func listingDisagreement(listings []*endpointListing) string {
+	first := listings[0]
+	for _, listing := range listings[1:] {
+		if (listing.account == nil) != (first.account == nil) {
+			return "type"
+		}
+		if first.account != nil {
+			if !bytes.Equal(listing.account.GetPublicKey(), first.account.GetPublicKey()) {
+				return "public key"
+			}
+
+			continue
+		}
+		if !bytes.Equal(listing.distributedAccount.GetCompositePublicKey(), first.distributedAccount.GetCompositePublicKey()) {
+			return "composite public key"
+		}
+		if listing.distributedAccount.GetSigningThreshold() != first.distributedAccount.GetSigningThreshold() {
+			return "signing threshold"
+		}
+		if !sameParticipants(listing.distributedAccount.GetParticipants(), first.distributedAccount.GetParticipants()) {
+			return "participants"
+		}
+	}
+
+	return ""
+}
+
+// sameParticipants returns true if two sets of participants are the same,
+// regardless of order.
+func sameParticipants(participants1 []*pb.Endpoint, participants2 []*pb.Endpoint) bool {
+	if len(participants1) != len(participants2) {
+		return false
+	}
+	sorted := func(participants []*pb.Endpoint) []*pb.Endpoint {
+		res := make([]*pb.Endpoint, len(participants))
+		copy(res, participants)
+		sort.Slice(res, func(i, j int) bool { return res[i].GetId() < res[j].GetId() })
+
+		return res
+	}
+	sorted1 := sorted(participants1)
+	sorted2 := sorted(participants2)
+	for i := range sorted1 {
+		if sorted1[i].GetId() != sorted2[i].GetId() ||
+			sorted1[i].GetName() != sorted2[i].GetName() ||
+			sorted1[i].GetPort() != sorted2[i].GetPort() {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,168 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	mock "github.com/wealdtech/go-eth2-wallet-dirk/mock"
+	"google.golang.org/grpc/credentials"
+)
+
+// staticListerServer is a lister server that returns a fixed response.
+type staticListerServer struct {
+	pb.UnimplementedListerServer
+	resp *pb.ListAccountsResponse
+}
+
+// ListAccounts returns the fixed response.
+func (s *staticListerServer) ListAccounts(_ context.Context, _ *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	return s.resp, nil
+}
+
+func TestListQuorum(t *testing.T) {
+	require.NoError(t, e2types.InitBLS())
+	ctx := context.Background()
+	require.NoError(t, registerMetrics(ctx, &prometheusMetrics{}))
+
+	participants := func(ids ...uint64) []*pb.Endpoint {
+		res := make([]*pb.Endpoint, len(ids))
+		for i, id := range ids {
+			res[i] = &pb.Endpoint{Id: id, Name: "signer", Port: uint32(12000 + id)}
+		}
+
+		return res
+	}
+	account := func(name string, pubKey string) *pb.Account {
+		return &pb.Account{
+			Name:      "Test wallet/" + name,
+			PublicKey: _byte(pubKey),
+			Uuid:      _byte("00000000000000000000000000000000"),
+		}
+	}
+	// With a signing threshold of 1 the public key share of each participant is the composite public key.
+	distributedAccount := func(name string, threshold uint32, participants []*pb.Endpoint) *pb.DistributedAccount {
+		return &pb.DistributedAccount{
+			Name:               "Test wallet/" + name,
+			PublicKey:          _byte("a155a5fb0a6d732fa0f4d3714a8550ee5b90690475e010fbf89277e98e060203d69eba05fa71b2d0fa6aa6d091172f1e"),
+			CompositePublicKey: _byte("a155a5fb0a6d732fa0f4d3714a8550ee5b90690475e010fbf89277e98e060203d69eba05fa71b2d0fa6aa6d091172f1e"),
+			SigningThreshold:   threshold,
+			Participants:       participants,
+			Uuid:               _byte("01000000000000000000000000000000"),
+		}
+	}
+	key1 := "a99a76ed7796f7be22d5b7e85deeb7c5677e88e511e0b337618f8c4eb61349b4bf2d153f649f7b53359fe8b94a38e44c"
+	key2 := "b89bebc699769726a318c8e9971bd3171297c61aea4a6578a7a4f94b547dcba5bac16a89108b6b6a1fe3695d1a874a0b"
+	key3 := "a3a32b0f8b4ddb83f1a0a853d81dd725dfe577d4f4c3db8ece52ce2b026eca84815c1a7e8e92a4de3d755733bf7e4a9b"
+
+	// Endpoints are served by the server at index (port % 3).
+	connectionProvider, err := NewBufConnectionProvider(ctx, []pb.ListerServer{
+		&staticListerServer{resp: &pb.ListAccountsResponse{
+			State:    pb.ResponseState_SUCCEEDED,
+			Accounts: []*pb.Account{account("Agreed", key1), account("Key", key2), account("Partial", key2)},
+			DistributedAccounts: []*pb.DistributedAccount{
+				distributedAccount("Agreed distributed", 1, participants(1, 2)),
+				distributedAccount("Threshold", 2, participants(1, 2)),
+				distributedAccount("Participants", 2, participants(1, 2)),
+			},
+		}},
+		&staticListerServer{resp: &pb.ListAccountsResponse{
+			State:    pb.ResponseState_SUCCEEDED,
+			Accounts: []*pb.Account{account("Agreed", key1), account("Key", key3)},
+			DistributedAccounts: []*pb.DistributedAccount{
+				distributedAccount("Agreed distributed", 1, participants(2, 1)),
+				distributedAccount("Threshold", 1, participants(1, 2)),
+				distributedAccount("Participants", 2, participants(1, 3)),
+			},
+		}},
+		&staticListerServer{resp: &pb.ListAccountsResponse{
+			State:    pb.ResponseState_SUCCEEDED,
+			Accounts: []*pb.Account{account("Agreed", key1)},
+			DistributedAccounts: []*pb.DistributedAccount{
+				distributedAccount("Agreed distributed", 1, participants(1, 2)),
+			},
+		}},
+	})
+	require.NoError(t, err)
+	w, err := OpenWallet(ctx, "Test wallet", credentials.NewTLS(nil), []*Endpoint{
+		{host: "quorum", port: 12345},
+		{host: "quorum", port: 12346},
+		{host: "quorum", port: 12347},
+	})
+	require.NoError(t, err)
+	w.(*wallet).SetConnectionProvider(connectionProvider)
+	w.(*wallet).listingQuorum = 3
+
+	accounts, err := w.(*wallet).List(ctx, "")
+	require.ErrorIs(t, err, ErrInvalidAccount)
+	names := make([]string, len(accounts))
+	for i := range accounts {
+		names[i] = accounts[i].Name()
+	}
+	require.ElementsMatch(t, []string{"Agreed", "Agreed distributed"}, names)
+
+	var listErr *AccountListError
+	require.ErrorAs(t, err, &listErr)
+	require.Len(t, listErr.Invalid, 4)
+	require.EqualError(t, listErr.Invalid[0], "account Test wallet/Key invalid: endpoints quorum:12345, quorum:12346 disagree on public key")
+	require.EqualError(t, listErr.Invalid[1], "account Test wallet/Partial invalid: endpoints quorum:12345 disagree on presence")
+	require.EqualError(t, listErr.Invalid[2], "account Test wallet/Participants invalid: endpoints quorum:12345, quorum:12346 disagree on participants")
+	require.EqualError(t, listErr.Invalid[3], "account Test wallet/Threshold invalid: endpoints quorum:12345, quorum:12346 disagree on signing threshold")
+	var disagreementErr *ListingDisagreementError
+	require.ErrorAs(t, listErr.Invalid[0], &disagreementErr)
+	require.Equal(t, "public key", disagreementErr.Attribute)
+
+	require.InDelta(t, 1, testutil.ToFloat64(listingDisagreements.WithLabelValues("public key")), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(listingDisagreements.WithLabelValues("signing threshold")), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(listingDisagreements.WithLabelValues("participants")), 0)
+	require.InDelta(t, 1, testutil.ToFloat64(listingDisagreements.WithLabelValues("presence")), 0)
+	require.InDelta(t, 3, testutil.ToFloat64(requests.WithLabelValues("list", "quorum:12345", "succeeded"))+
+		testutil.ToFloat64(requests.WithLabelValues("list", "quorum:12346", "succeeded"))+
+		testutil.ToFloat64(requests.WithLabelValues("list", "quorum:12347", "succeeded")), 0)
+}
+
+func TestListQuorumNotReached(t *testing.T) {
+	require.NoError(t, e2types.InitBLS())
+	ctx := context.Background()
+
+	// Endpoints are served by the server at index (port % 3).
+	connectionProvider, err := NewBufConnectionProvider(ctx, []pb.ListerServer{
+		&mock.MockListerServer{},
+		&mock.DenyingListerServer{},
+		&mock.ErroringListerServer{},
+	})
+	require.NoError(t, err)
+	w, err := OpenWallet(ctx, "Test wallet", credentials.NewTLS(nil), []*Endpoint{
+		{host: "localhost", port: 12345},
+		{host: "localhost", port: 12346},
+		{host: "localhost", port: 12347},
+	})
+	require.NoError(t, err)
+	w.(*wallet).SetConnectionProvider(connectionProvider)
+
+	// The participants of the distributed accounts do not provide enough public key shares, so only the other accounts are listed.
+	w.(*wallet).listingQuorum = 1
+	accounts, err := w.(*wallet).List(ctx, "")
+	require.ErrorIs(t, err, ErrInvalidAccount)
+	require.Len(t, accounts, 5)
+
+	w.(*wallet).listingQuorum = 2
+	_, err = w.(*wallet).List(ctx, "")
+	require.ErrorContains(t, err, "failed to access dirk: listing quorum not reached: 1 of 3 endpoints responded, 2 required")
+}
@@ -0,0 +1,111 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc/credentials"
+)
+
+func TestListingQuorumParameter(t *testing.T) {
+	ctx := context.Background()
+	endpoints := []*dirk.Endpoint{
+		dirk.NewEndpoint("localhost", 12345),
+		dirk.NewEndpoint("localhost", 12346),
+	}
+
+	tests := []struct {
+		name   string
+		quorum int
+		err    string
+	}{
+		{
+			name:   "Invalid",
+			quorum: -1,
+			err:    "problem with parameters: invalid listing quorum specified",
+		},
+		{
+			name:   "TooHigh",
+			quorum: 3,
+			err:    "problem with parameters: listing quorum exceeds number of endpoints",
+		},
+		{
+			name:   "Good",
+			quorum: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := dirk.Open(ctx,
+				dirk.WithName("Test wallet"),
+				dirk.WithEndpoints(endpoints),
+				dirk.WithCredentials(credentials.NewTLS(nil)),
+				dirk.WithListingQuorum(test.quorum),
+			)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestListingQuorum(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddAccount("Wallet", "Account 1", []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	// Account 3 is only held, and so only listed, by servers 1 and 2.
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 3", 2, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithListingQuorum(2))
+
+	// All endpoints are asked, and the participants are also asked for their public key shares.
+	accounts, err := wallet.(dirk.WalletAccountsWithErrorProvider).AccountsWithError(ctx)
+	require.NoError(t, err)
+	require.Len(t, accounts, 3)
+	for _, server := range cluster.Servers() {
+		require.Equal(t, uint64(2), server.Requests("ListAccounts"))
+	}
+
+	// Accounts are listed while the quorum of endpoints respond, but accounts
+	// listed by fewer than the quorum of endpoints are invalid.
+	cluster.Server(1).SetDown(true)
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 2")
+	require.NoError(t, err)
+	require.Equal(t, "Account 2", account.Name())
+	accounts, err = wallet.(dirk.WalletAccountsWithErrorProvider).AccountsWithError(ctx)
+	require.ErrorIs(t, err, dirk.ErrInvalidAccount)
+	require.Len(t, accounts, 2)
+	var listErr *dirk.AccountListError
+	require.ErrorAs(t, err, &listErr)
+	require.Len(t, listErr.Invalid, 1)
+	var disagreementErr *dirk.ListingDisagreementError
+	require.ErrorAs(t, listErr.Invalid[0], &disagreementErr)
+	require.Equal(t, "presence", disagreementErr.Attribute)
+	require.Equal(t, []string{"signer-test02:12002"}, disagreementErr.Endpoints)
+
+	cluster.Server(2).SetDown(true)
+	_, err = wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 2")
+	require.ErrorContains(t, err, "listing quorum not reached: 1 of 3 endpoints responded, 2 required")
+}
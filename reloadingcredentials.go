@@ -0,0 +1,258 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	zerologger "github.com/rs/zerolog/log"
+	"google.golang.org/grpc/credentials"
+)
+
+// CredentialsSource provides the client certificate, client key and optional
+// CA certificate from which transport credentials are built.
+type CredentialsSource func(ctx context.Context) (clientCert []byte, clientKey []byte, caCert []byte, err error)
+
+// ReloadingCredentials are transport credentials that are rebuilt whenever
+// their source changes.  New connections use the latest credentials, and
+// existing connections continue to run with the credentials with which they
+// were established.  If the credentials cannot be rebuilt the last good
+// credentials remain in use.
+type ReloadingCredentials struct {
+	state *reloadingCredentialsState
+}
+
+// reloadingCredentialsState is the state shared between clones of reloading credentials.
+type reloadingCredentialsState struct {
+	log    zerolog.Logger
+	source CredentialsSource
+
+	mu         sync.RWMutex
+	current    credentials.TransportCredentials
+	digest     [32]byte
+	serverName string
+}
+
+// ComposeReloadingCredentials composes a set of transport credentials given
+// individual certificate and key paths, checking the files for changes at the
+// given interval.  The CA certificate path can be empty.  Checking stops when
+// the context is done.
+func ComposeReloadingCredentials(ctx context.Context,
+	certPath string,
+	keyPath string,
+	caCertPath string,
+	interval time.Duration,
+) (
+	*ReloadingCredentials,
+	error,
+) {
+	return NewReloadingCredentials(ctx, FileCredentialsSource(certPath, keyPath, caCertPath), interval)
+}
+
+// FileCredentialsSource provides credentials from the given certificate and
+// key paths.  The CA certificate path can be empty.
+func FileCredentialsSource(certPath string, keyPath string, caCertPath string) CredentialsSource {
+	return func(_ context.Context) ([]byte, []byte, []byte, error) {
+		clientCert, err := os.ReadFile(certPath)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "failed to obtain client certificate")
+		}
+		clientKey, err := os.ReadFile(keyPath)
+		if err != nil {
+			return nil, nil, nil, errors.Wrap(err, "failed to obtain client key")
+		}
+		var caCert []byte
+		if caCertPath != "" {
+			caCert, err = os.ReadFile(caCertPath)
+			if err != nil {
+				return nil, nil, nil, errors.Wrap(err, "failed to obtain CA certificate")
+			}
+		}
+
+		return clientCert, clientKey, caCert, nil
+	}
+}
+
+// NewReloadingCredentials creates transport credentials from the given
+// source, checking the source for changes at the given interval.  An interval
+// of 0 disables checking, in which case credentials are only reloaded by
+// calling Reload().  Checking stops when the context is done.
+func NewReloadingCredentials(ctx context.Context,
+	source CredentialsSource,
+	interval time.Duration,
+) (
+	*ReloadingCredentials,
+	error,
+) {
+	if source == nil {
+		return nil, errors.New("no credentials source specified")
+	}
+	if interval < 0 {
+		return nil, errors.New("invalid reload interval specified")
+	}
+
+	c := &ReloadingCredentials{
+		state: &reloadingCredentialsState{
+			log:    zerologger.With().Str("service", "credentials").Str("impl", "dirk").Logger(),
+			source: source,
+		},
+	}
+	if err := c.Reload(ctx); err != nil {
+		return nil, err
+	}
+
+	if interval > 0 {
+		go c.watch(ctx, interval)
+	}
+
+	return c, nil
+}
+
+// watch reloads the credentials at the given interval until the context is done.
+func (c *ReloadingCredentials) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			// Failures are logged and counted by Reload(), and the last good credentials remain in use.
+			_ = c.Reload(ctx)
+		}
+	}
+}
+
+// Reload rebuilds the credentials from their source, if it has changed.
+func (c *ReloadingCredentials) Reload(ctx context.Context) error {
+	clientCert, clientKey, caCert, err := c.state.source(ctx)
+	if err != nil {
+		return c.state.reloadFailed(errors.Wrap(err, "failed to obtain credentials"))
+	}
+
+	digest := sha256.Sum256(bytes.Join([][]byte{clientCert, clientKey, caCert}, []byte{0}))
+	c.state.mu.RLock()
+	unchanged := c.state.current != nil && digest == c.state.digest
+	c.state.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	creds, err := Credentials(ctx, clientCert, clientKey, caCert)
+	if err != nil {
+		return c.state.reloadFailed(err)
+	}
+
+	c.state.mu.Lock()
+	if c.state.serverName != "" {
+		//nolint:staticcheck
+		if err := creds.OverrideServerName(c.state.serverName); err != nil {
+			c.state.mu.Unlock()
+
+			return c.state.reloadFailed(errors.Wrap(err, "failed to override server name"))
+		}
+	}
+	initial := c.state.current == nil
+	c.state.current = creds
+	c.state.digest = digest
+	c.state.mu.Unlock()
+
+	if !initial {
+		c.state.log.Info().Msg("Reloaded credentials")
+		credentialsReloaded(true)
+	}
+
+	return nil
+}
+
+// reloadFailed records a failure to reload the credentials.
+func (s *reloadingCredentialsState) reloadFailed(err error) error {
+	s.mu.RLock()
+	initial := s.current == nil
+	s.mu.RUnlock()
+
+	if !initial {
+		s.log.Warn().Err(err).Msg("Failed to reload credentials; continuing to use existing credentials")
+		credentialsReloaded(false)
+	}
+
+	return err
+}
+
+// credentials returns the current credentials.
+func (c *ReloadingCredentials) credentials() credentials.TransportCredentials {
+	c.state.mu.RLock()
+	defer c.state.mu.RUnlock()
+
+	return c.state.current
+}
+
+// ClientHandshake does the authentication handshake for a client connection
+// using the current credentials.
+func (c *ReloadingCredentials) ClientHandshake(ctx context.Context,
+	authority string,
+	rawConn net.Conn,
+) (
+	net.Conn,
+	credentials.AuthInfo,
+	error,
+) {
+	//nolint:wrapcheck
+	return c.credentials().ClientHandshake(ctx, authority, rawConn)
+}
+
+// ServerHandshake does the authentication handshake for a server connection
+// using the current credentials.
+func (c *ReloadingCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	//nolint:wrapcheck
+	return c.credentials().ServerHandshake(rawConn)
+}
+
+// Info provides the protocol information of the current credentials.
+func (c *ReloadingCredentials) Info() credentials.ProtocolInfo {
+	return c.credentials().Info()
+}
+
+// Clone makes a copy of the credentials.  The copy shares its source with the
+// original, so both pick up reloaded credentials.
+func (c *ReloadingCredentials) Clone() credentials.TransportCredentials {
+	return &ReloadingCredentials{
+		state: c.state,
+	}
+}
+
+// OverrideServerName overrides the server name used to verify the hostname
+// on the returned certificates from the server.
+//
+// Deprecated: use grpc.WithAuthority instead.
+func (c *ReloadingCredentials) OverrideServerName(serverName string) error {
+	c.state.mu.Lock()
+	defer c.state.mu.Unlock()
+
+	//nolint:staticcheck
+	if err := c.state.current.OverrideServerName(serverName); err != nil {
+		return errors.Wrap(err, "failed to override server name")
+	}
+	c.state.serverName = serverName
+
+	return nil
+}
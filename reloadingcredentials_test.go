@@ -0,0 +1,129 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	"google.golang.org/grpc/credentials"
+)
+
+// handshakeClientName carries out a TLS handshake with the given credentials
+// against a test server, returning the common name of the client certificate.
+func handshakeClientName(ctx context.Context, t *testing.T, creds credentials.TransportCredentials) string {
+	t.Helper()
+
+	serverPair, err := tls.X509KeyPair([]byte(signerTest01Crt), []byte(signerTest01Key))
+	require.NoError(t, err)
+	clientName := make(chan string, 1)
+	serverCfg := &tls.Config{
+		Certificates: []tls.Certificate{serverPair},
+		ClientAuth:   tls.RequireAnyClientCert,
+		MinVersion:   tls.VersionTLS13,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			cert, err := x509.ParseCertificate(rawCerts[0])
+			if err != nil {
+				return err
+			}
+			clientName <- cert.Subject.CommonName
+
+			return nil
+		},
+	}
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	go func() {
+		_ = tls.Server(serverConn, serverCfg).HandshakeContext(ctx)
+	}()
+	_, _, err = creds.ClientHandshake(ctx, "signer-test01", clientConn)
+	require.NoError(t, err)
+
+	return <-clientName
+}
+
+func TestComposeReloadingCredentials(t *testing.T) {
+	ctx := context.Background()
+	base := t.TempDir()
+	require.NoError(t, SetupCerts(base))
+	certPath := filepath.Join(base, "client.crt")
+	keyPath := filepath.Join(base, "client.key")
+	caCertPath := filepath.Join(base, "ca.crt")
+
+	_, err := dirk.ComposeReloadingCredentials(ctx, certPath, keyPath, caCertPath, 0)
+	require.EqualError(t, err, "failed to obtain credentials: failed to obtain client certificate: open "+certPath+": no such file or directory")
+
+	require.NoError(t, os.WriteFile(certPath, []byte(clientTest01Crt), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, []byte(clientTest01Key), 0o600))
+	creds, err := dirk.ComposeReloadingCredentials(ctx, certPath, keyPath, caCertPath, 0)
+	require.NoError(t, err)
+	require.Equal(t, "tls", creds.Info().SecurityProtocol)
+	clone := creds.Clone()
+	require.Equal(t, "client-test01", handshakeClientName(ctx, t, creds))
+
+	// A partial rotation fails, and the existing credentials remain in use.
+	require.NoError(t, os.WriteFile(certPath, []byte(clientTest02Crt), 0o600))
+	require.ErrorContains(t, creds.Reload(ctx), "failed to load client keypair")
+	require.Equal(t, "client-test01", handshakeClientName(ctx, t, creds))
+
+	// A complete rotation succeeds, and is picked up by clones.
+	require.NoError(t, os.WriteFile(keyPath, []byte(clientTest02Key), 0o600))
+	require.NoError(t, creds.Reload(ctx))
+	require.Equal(t, "client-test02", handshakeClientName(ctx, t, creds))
+	require.Equal(t, "client-test02", handshakeClientName(ctx, t, clone))
+}
+
+func TestReloadingCredentialsWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rotated := make(chan struct{})
+	source := func(_ context.Context) ([]byte, []byte, []byte, error) {
+		select {
+		case <-rotated:
+			return []byte(clientTest03Crt), []byte(clientTest03Key), []byte(caCrt), nil
+		default:
+			return []byte(clientTest01Crt), []byte(clientTest01Key), []byte(caCrt), nil
+		}
+	}
+
+	_, err := dirk.NewReloadingCredentials(ctx, nil, time.Second)
+	require.EqualError(t, err, "no credentials source specified")
+	_, err = dirk.NewReloadingCredentials(ctx, source, -time.Second)
+	require.EqualError(t, err, "invalid reload interval specified")
+	_, err = dirk.NewReloadingCredentials(ctx, func(_ context.Context) ([]byte, []byte, []byte, error) {
+		return nil, nil, nil, errors.New("mock error")
+	}, time.Second)
+	require.EqualError(t, err, "failed to obtain credentials: mock error")
+
+	creds, err := dirk.NewReloadingCredentials(ctx, source, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, "client-test01", handshakeClientName(ctx, t, creds))
+
+	close(rotated)
+	require.Eventually(t, func() bool {
+		return handshakeClientName(ctx, t, creds) == "client-test03"
+	}, time.Second, 20*time.Millisecond)
+}
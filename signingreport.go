@@ -0,0 +1,200 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// errInvalidShare is recorded for participants that return signature shares that fail verification.
+var errInvalidShare = errors.New("signature share failed verification")
+
+// ParticipantOutcome is the outcome of a threshold signing request for a
+// single participant.
+type ParticipantOutcome int
+
+const (
+	// ParticipantOutcomeNotContacted is the outcome for a participant that was
+	// not sent the request, because enough signature shares were obtained
+	// from other participants.
+	ParticipantOutcomeNotContacted ParticipantOutcome = iota
+	// ParticipantOutcomeSkipped is the outcome for a participant that was not
+	// sent the request because its endpoint was down.
+	ParticipantOutcomeSkipped
+	// ParticipantOutcomePending is the outcome for a participant that had not
+	// responded by the time the request completed.
+	ParticipantOutcomePending
+	// ParticipantOutcomeSigned is the outcome for a participant that returned a valid signature share.
+	ParticipantOutcomeSigned
+	// ParticipantOutcomeDenied is the outcome for a participant that denied the request.
+	ParticipantOutcomeDenied
+	// ParticipantOutcomeFailed is the outcome for a participant that failed, or returned an unknown state for, the request.
+	ParticipantOutcomeFailed
+	// ParticipantOutcomeErrored is the outcome for a participant that could not be contacted.
+	ParticipantOutcomeErrored
+	// ParticipantOutcomeInvalid is the outcome for a participant that returned an invalid signature share.
+	ParticipantOutcomeInvalid
+)
+
+// String implements the stringer interface.
+func (o ParticipantOutcome) String() string {
+	switch o {
+	case ParticipantOutcomeNotContacted:
+		return "not contacted"
+	case ParticipantOutcomeSkipped:
+		return "skipped"
+	case ParticipantOutcomePending:
+		return "pending"
+	case ParticipantOutcomeSigned:
+		return "signed"
+	case ParticipantOutcomeDenied:
+		return "denied"
+	case ParticipantOutcomeFailed:
+		return "failed"
+	case ParticipantOutcomeErrored:
+		return "errored"
+	case ParticipantOutcomeInvalid:
+		return "invalid"
+	default:
+		return "unknown"
+	}
+}
+
+// ParticipantReport is the outcome of a threshold signing request for a
+// single participant.
+type ParticipantReport struct {
+	// ID is the ID of the participant.
+	ID uint64
+	// Endpoint is the endpoint of the participant.
+	Endpoint string
+	// Outcome is the outcome of the request.
+	Outcome ParticipantOutcome
+	// Latency is the time taken for the participant to respond, if it responded.
+	Latency time.Duration
+	// Err is the error encountered with the participant, if any.
+	Err error
+}
+
+// SigningReport is a report of the outcome of a threshold signing request
+// for each participant of a distributed account.
+type SigningReport struct {
+	// Account is the name of the account.
+	Account string
+	// Operation is the signing operation, for example "sign" or "proposal".
+	Operation string
+	// Required is the number of signature shares required.
+	Required int
+	// Participants are the reports for each participant, ordered by ID.
+	Participants []*ParticipantReport
+}
+
+// String implements the stringer interface.
+func (r *SigningReport) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s for %s (%d required):", r.Operation, r.Account, r.Required)
+	for _, participant := range r.Participants {
+		fmt.Fprintf(&builder, " %d@%s %s", participant.ID, participant.Endpoint, participant.Outcome)
+		if participant.Outcome != ParticipantOutcomeNotContacted &&
+			participant.Outcome != ParticipantOutcomeSkipped &&
+			participant.Outcome != ParticipantOutcomePending {
+			fmt.Fprintf(&builder, " in %v", participant.Latency)
+		}
+		if participant.Err != nil {
+			fmt.Fprintf(&builder, " (%v)", participant.Err)
+		}
+		builder.WriteString(";")
+	}
+
+	return strings.TrimSuffix(builder.String(), ";")
+}
+
+// newSigningReport creates a report in which no participant has been contacted.
+func newSigningReport(account string, operation string, required int, participants map[uint64]*Endpoint) *SigningReport {
+	report := &SigningReport{
+		Account:      account,
+		Operation:    operation,
+		Required:     required,
+		Participants: make([]*ParticipantReport, 0, len(participants)),
+	}
+	for id, endpoint := range participants {
+		report.Participants = append(report.Participants, &ParticipantReport{
+			ID:       id,
+			Endpoint: endpoint.String(),
+		})
+	}
+	sort.Slice(report.Participants, func(i, j int) bool {
+		return report.Participants[i].ID < report.Participants[j].ID
+	})
+
+	return report
+}
+
+// participant returns the report for the participant with the given ID.
+func (r *SigningReport) participant(id uint64) *ParticipantReport {
+	for _, participant := range r.Participants {
+		if participant.ID == id {
+			return participant
+		}
+	}
+
+	return nil
+}
+
+// launched records that the request has been sent to a participant.
+func (r *SigningReport) launched(id uint64) {
+	if participant := r.participant(id); participant != nil {
+		participant.Outcome = ParticipantOutcomePending
+	}
+}
+
+// record records the outcome of the request for a participant.
+func (r *SigningReport) record(id uint64, outcome ParticipantOutcome, latency time.Duration, err error) {
+	if participant := r.participant(id); participant != nil {
+		participant.Outcome = outcome
+		participant.Latency = latency
+		participant.Err = err
+	}
+}
+
+// SigningReportHook is called with the report of each threshold signing
+// request, whether or not it succeeded.  Multiple signing requests generate
+// a report for each account.
+type SigningReportHook func(report *SigningReport)
+
+type signingReportHookKey struct{}
+
+// ContextWithSigningReportHook returns a context that calls the hook with the
+// reports of threshold signing requests made with it.  Reports are also
+// available from the ThresholdError returned when a request does not obtain
+// enough signature shares.
+func ContextWithSigningReportHook(ctx context.Context, hook SigningReportHook) context.Context {
+	return context.WithValue(ctx, signingReportHookKey{}, hook)
+}
+
+// reportSigning passes signing reports to the hook in the context, if present.
+func reportSigning(ctx context.Context, reports ...*SigningReport) {
+	hook, ok := ctx.Value(signingReportHookKey{}).(SigningReportHook)
+	if !ok || hook == nil {
+		return
+	}
+	for _, report := range reports {
+		hook(report)
+	}
+}
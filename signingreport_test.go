@@ -0,0 +1,168 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	mock "github.com/wealdtech/go-eth2-wallet-dirk/mock"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSigningReport(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 5)
+	_, err := cluster.AddDistributedAccount("Wallet", "Account", 5, 3, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithTimeout(2*time.Second))
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+
+	denied := pb.ResponseState_DENIED
+	faultInjector := mock.NewFaultInjector(1)
+	faultInjector.Add(&mock.Fault{Endpoint: "signer-test02:12002", Method: "Sign", State: &denied})
+	faultInjector.Add(&mock.Fault{Endpoint: "signer-test03:12003", Method: "Sign", Err: status.Error(codes.Unavailable, "connection dropped")})
+	faultInjector.Add(&mock.Fault{Endpoint: "signer-test04:12004", Method: "Sign", CorruptSignatures: true})
+	cluster.SetFaultInjector(faultInjector)
+
+	reports := make([]*dirk.SigningReport, 0)
+	hookCtx := dirk.ContextWithSigningReportHook(ctx, func(report *dirk.SigningReport) {
+		reports = append(reports, report)
+	})
+	_, err = account.(e2wtypes.AccountProtectingSigner).SignGeneric(hookCtx, bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x03}, 32))
+	require.EqualError(t, err, "failed to obtain signature: not enough signatures: 2 signed, 1 denied, 0 failed, 1 errored, 1 invalid")
+
+	var thresholdErr *dirk.ThresholdError
+	require.True(t, errors.As(err, &thresholdErr))
+	report := thresholdErr.Report
+	require.NotNil(t, report)
+	require.Equal(t, []*dirk.SigningReport{report}, reports)
+	require.Equal(t, "sign", report.Operation)
+	require.Equal(t, 3, report.Required)
+	require.Len(t, report.Participants, 5)
+
+	expected := []struct {
+		endpoint string
+		outcome  dirk.ParticipantOutcome
+		err      error
+	}{
+		{endpoint: "signer-test01:12001", outcome: dirk.ParticipantOutcomeSigned},
+		{endpoint: "signer-test02:12002", outcome: dirk.ParticipantOutcomeDenied, err: dirk.ErrDenied},
+		{endpoint: "signer-test03:12003", outcome: dirk.ParticipantOutcomeErrored},
+		{endpoint: "signer-test04:12004", outcome: dirk.ParticipantOutcomeInvalid},
+		{endpoint: "signer-test05:12005", outcome: dirk.ParticipantOutcomeSigned},
+	}
+	for i, participant := range report.Participants {
+		require.Equal(t, uint64(i+1), participant.ID)
+		require.Equal(t, expected[i].endpoint, participant.Endpoint)
+		require.Equal(t, expected[i].outcome, participant.Outcome)
+		require.Positive(t, participant.Latency)
+		switch {
+		case expected[i].err != nil:
+			require.ErrorIs(t, participant.Err, expected[i].err)
+		case expected[i].outcome == dirk.ParticipantOutcomeSigned:
+			require.NoError(t, participant.Err)
+		default:
+			require.Error(t, participant.Err)
+		}
+	}
+	require.Contains(t, report.String(), "2@signer-test02:12002 denied in ")
+
+	// Successful requests also provide a report.
+	faultInjector.Clear()
+	reports = reports[:0]
+	_, err = account.(e2wtypes.AccountProtectingSigner).SignGeneric(hookCtx, bytes.Repeat([]byte{0x02}, 32), bytes.Repeat([]byte{0x03}, 32))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	signed := 0
+	for _, participant := range reports[0].Participants {
+		if participant.Outcome == dirk.ParticipantOutcomeSigned {
+			signed++
+		}
+	}
+	require.Equal(t, 3, signed)
+}
+
+func TestSigningReportNotContacted(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddDistributedAccount("Wallet", "Account", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithHedgeDelay(time.Second))
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+
+	// With hedging only participants 2 and 3 are contacted, as participant 1
+	// has been used to list accounts.
+	var report *dirk.SigningReport
+	hookCtx := dirk.ContextWithSigningReportHook(ctx, func(r *dirk.SigningReport) {
+		report = r
+	})
+	_, err = account.(e2wtypes.AccountProtectingSigner).SignGeneric(hookCtx, bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x03}, 32))
+	require.NoError(t, err)
+	require.NotNil(t, report)
+	require.Equal(t, dirk.ParticipantOutcomeNotContacted, report.Participants[0].Outcome)
+	require.Equal(t, dirk.ParticipantOutcomeSigned, report.Participants[1].Outcome)
+	require.Equal(t, dirk.ParticipantOutcomeSigned, report.Participants[2].Outcome)
+}
+
+func TestMultiSigningReport(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddDistributedAccount("Wallet", "Account 1", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet")
+	account1, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 1")
+	require.NoError(t, err)
+	account2, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account 2")
+	require.NoError(t, err)
+
+	faultInjector := mock.NewFaultInjector(1)
+	faultInjector.Add(&mock.Fault{Endpoint: "signer-test01:12001", Method: "Multisign", CorruptSignatures: true})
+	faultInjector.Add(&mock.Fault{Endpoint: "signer-test02:12002", Method: "Multisign", Err: status.Error(codes.Unavailable, "connection dropped")})
+	cluster.SetFaultInjector(faultInjector)
+
+	reports := make([]*dirk.SigningReport, 0)
+	hookCtx := dirk.ContextWithSigningReportHook(ctx, func(report *dirk.SigningReport) {
+		reports = append(reports, report)
+	})
+	data := [][]byte{
+		bytes.Repeat([]byte{0x01}, 32),
+		bytes.Repeat([]byte{0x02}, 32),
+	}
+	sigs, err := account1.(e2wtypes.AccountProtectingMultiSigner).SignGenericMulti(hookCtx, []e2wtypes.Account{account1, account2}, data, bytes.Repeat([]byte{0x03}, 32))
+	require.NoError(t, err)
+	require.Nil(t, sigs[0])
+	require.Nil(t, sigs[1])
+
+	require.Len(t, reports, 2)
+	for _, report := range reports {
+		require.Equal(t, "multisign", report.Operation)
+		require.Equal(t, dirk.ParticipantOutcomeInvalid, report.Participants[0].Outcome)
+		require.Equal(t, dirk.ParticipantOutcomeErrored, report.Participants[1].Outcome)
+		require.Equal(t, dirk.ParticipantOutcomeSigned, report.Participants[2].Outcome)
+	}
+	require.NotEqual(t, reports[0].Account, reports[1].Account)
+}
@@ -0,0 +1,243 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math/bits"
+
+	"github.com/pkg/errors"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+)
+
+// maxValidatorsPerCommittee is the maximum number of validators in a beacon committee.
+const maxValidatorsPerCommittee = 2048
+
+// maxCommitteesPerSlot is the maximum number of beacon committees in a slot.
+const maxCommitteesPerSlot = 64
+
+// signingRoot returns the root of the signing data container for the given object root and domain,
+// which is the message that is signed by remote signers.
+func signingRoot(objectRoot []byte, domain []byte) []byte {
+	return merkleize([][32]byte{
+		chunk(objectRoot),
+		chunk(domain),
+	})
+}
+
+// attestationDataRoot returns the hash tree root of attestation data.
+func attestationDataRoot(data *pb.AttestationData) []byte {
+	return merkleize([][32]byte{
+		uint64Chunk(data.GetSlot()),
+		uint64Chunk(data.GetCommitteeIndex()),
+		chunk(data.GetBeaconBlockRoot()),
+		chunk(checkpointRoot(data.GetSource())),
+		chunk(checkpointRoot(data.GetTarget())),
+	})
+}
+
+// checkpointRoot returns the hash tree root of a checkpoint.
+func checkpointRoot(checkpoint *pb.Checkpoint) []byte {
+	return merkleize([][32]byte{
+		uint64Chunk(checkpoint.GetEpoch()),
+		chunk(checkpoint.GetRoot()),
+	})
+}
+
+// beaconBlockHeaderRoot returns the hash tree root of a beacon block header.
+func beaconBlockHeaderRoot(header *pb.BeaconBlockHeader) []byte {
+	return merkleize([][32]byte{
+		uint64Chunk(header.GetSlot()),
+		uint64Chunk(header.GetProposerIndex()),
+		chunk(header.GetParentRoot()),
+		chunk(header.GetStateRoot()),
+		chunk(header.GetBodyRoot()),
+	})
+}
+
+// syncAggregatorSelectionDataRoot returns the hash tree root of sync aggregator selection data.
+func syncAggregatorSelectionDataRoot(slot uint64, subcommitteeIndex uint64) []byte {
+	return merkleize([][32]byte{
+		uint64Chunk(slot),
+		uint64Chunk(subcommitteeIndex),
+	})
+}
+
+// syncCommitteeContributionRoot returns the hash tree root of a sync committee contribution.
+func syncCommitteeContributionRoot(contribution *SyncCommitteeContribution) []byte {
+	return merkleize([][32]byte{
+		uint64Chunk(contribution.Slot),
+		chunk(contribution.BeaconBlockRoot),
+		uint64Chunk(contribution.SubcommitteeIndex),
+		chunk(contribution.AggregationBits),
+		chunk(bytesRoot(contribution.Signature)),
+	})
+}
+
+// contributionAndProofRoot returns the hash tree root of a contribution and proof.
+func contributionAndProofRoot(contributionAndProof *ContributionAndProof) []byte {
+	return merkleize([][32]byte{
+		uint64Chunk(contributionAndProof.AggregatorIndex),
+		chunk(syncCommitteeContributionRoot(contributionAndProof.Contribution)),
+		chunk(bytesRoot(contributionAndProof.SelectionProof)),
+	})
+}
+
+// attestationRoot returns the hash tree root of an attestation.  Attestations
+// with committee bits are hashed with the Electra layout, in which the
+// aggregation bits cover every committee in the slot and are followed by the
+// committee bits.
+func attestationRoot(attestation *Attestation) ([]byte, error) {
+	if attestation.CommitteeBits == nil {
+		aggregationBitsRoot, err := bitlistRoot(attestation.AggregationBits, maxValidatorsPerCommittee)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid aggregation bits")
+		}
+
+		return merkleize([][32]byte{
+			chunk(aggregationBitsRoot),
+			chunk(attestationDataRoot(attestation.Data.pb())),
+			chunk(bytesRoot(attestation.Signature)),
+		}), nil
+	}
+
+	aggregationBitsRoot, err := bitlistRoot(attestation.AggregationBits, maxValidatorsPerCommittee*maxCommitteesPerSlot)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid aggregation bits")
+	}
+
+	return merkleize([][32]byte{
+		chunk(aggregationBitsRoot),
+		chunk(attestationDataRoot(attestation.Data.pb())),
+		chunk(bytesRoot(attestation.Signature)),
+		chunk(attestation.CommitteeBits),
+	}), nil
+}
+
+// aggregateAndProofRoot returns the hash tree root of an aggregate and proof.
+func aggregateAndProofRoot(aggregateAndProof *AggregateAndProof) ([]byte, error) {
+	aggregateRoot, err := attestationRoot(aggregateAndProof.Aggregate)
+	if err != nil {
+		return nil, err
+	}
+
+	return merkleize([][32]byte{
+		uint64Chunk(aggregateAndProof.AggregatorIndex),
+		chunk(aggregateRoot),
+		chunk(bytesRoot(aggregateAndProof.SelectionProof)),
+	}), nil
+}
+
+// voluntaryExitRoot returns the hash tree root of a voluntary exit.
+func voluntaryExitRoot(epoch uint64, validatorIndex uint64) []byte {
+	return merkleize([][32]byte{
+		uint64Chunk(epoch),
+		uint64Chunk(validatorIndex),
+	})
+}
+
+// validatorRegistrationRoot returns the hash tree root of a validator registration.
+func validatorRegistrationRoot(registration *ValidatorRegistration) []byte {
+	return merkleize([][32]byte{
+		chunk(registration.FeeRecipient),
+		uint64Chunk(registration.GasLimit),
+		uint64Chunk(registration.Timestamp),
+		chunk(bytesRoot(registration.Pubkey)),
+	})
+}
+
+// bytesRoot returns the hash tree root of a fixed-length byte vector.
+func bytesRoot(data []byte) []byte {
+	chunks := make([][32]byte, (len(data)+31)/32)
+	for i := range chunks {
+		chunks[i] = chunk(data[i*32 : min(len(data), (i+1)*32)])
+	}
+
+	return merkleize(chunks)
+}
+
+// bitlistRoot returns the hash tree root of a bitlist with the given
+// maximum number of bits.  The bitlist is in its serialized form, with a
+// delimiting bit after the last bit.
+func bitlistRoot(data []byte, limit uint64) ([]byte, error) {
+	if len(data) == 0 || data[len(data)-1] == 0 {
+		return nil, errors.New("missing delimiting bit")
+	}
+	delimiter := bits.Len8(data[len(data)-1]) - 1
+	length := uint64(len(data)-1)*8 + uint64(delimiter)
+	if length > limit {
+		return nil, errors.New("too many bits")
+	}
+
+	// Remove the delimiting bit, and the byte that held it if it is now surplus.
+	packed := make([]byte, (length+7)/8)
+	copy(packed, data)
+	if delimiter != 0 {
+		packed[len(packed)-1] &^= 1 << delimiter
+	}
+
+	chunks := make([][32]byte, (len(packed)+31)/32)
+	for i := range chunks {
+		chunks[i] = chunk(packed[i*32 : min(len(packed), (i+1)*32)])
+	}
+	root := merkleizeWithLimit(chunks, int((limit+255)/256))
+
+	return merkleize([][32]byte{
+		chunk(root),
+		uint64Chunk(length),
+	}), nil
+}
+
+// chunk turns a byte slice of up to 32 bytes in to a right-padded chunk.
+func chunk(data []byte) [32]byte {
+	var res [32]byte
+	copy(res[:], data)
+
+	return res
+}
+
+// uint64Chunk turns a uint64 in to a chunk.
+func uint64Chunk(val uint64) [32]byte {
+	var res [32]byte
+	binary.LittleEndian.PutUint64(res[:8], val)
+
+	return res
+}
+
+// merkleize returns the merkle root of the supplied chunks, padding with
+// zero chunks to the next power of two.
+func merkleize(chunks [][32]byte) []byte {
+	return merkleizeWithLimit(chunks, len(chunks))
+}
+
+// merkleizeWithLimit returns the merkle root of the supplied chunks, padding
+// with zero chunks to the next power of two of the limit.
+func merkleizeWithLimit(chunks [][32]byte, limit int) []byte {
+	width := 1
+	for width < max(len(chunks), limit) {
+		width *= 2
+	}
+	layer := make([][32]byte, width)
+	copy(layer, chunks)
+	for len(layer) > 1 {
+		next := make([][32]byte, len(layer)/2)
+		for i := range next {
+			next[i] = sha256.Sum256(append(layer[2*i][:], layer[2*i+1][:]...))
+		}
+		layer = next
+	}
+
+	return layer[0][:]
+}
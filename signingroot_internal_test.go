@@ -0,0 +1,187 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+)
+
+// The expected roots in these tests are computed independently with
+// github.com/ferranbt/fastssz, using its generated spec test types where
+// available.
+
+func _byte(input string) []byte {
+	res, _ := hex.DecodeString(input)
+	return res
+}
+
+func TestAttestationDataRoot(t *testing.T) {
+	data := &pb.AttestationData{
+		Slot:            12345,
+		CommitteeIndex:  7,
+		BeaconBlockRoot: bytes.Repeat([]byte{0x01}, 32),
+		Source: &pb.Checkpoint{
+			Epoch: 3,
+			Root:  bytes.Repeat([]byte{0x02}, 32),
+		},
+		Target: &pb.Checkpoint{
+			Epoch: 4,
+			Root:  bytes.Repeat([]byte{0x03}, 32),
+		},
+	}
+	require.Equal(t, _byte("9c898c684b452a36d0f38ede8bdfdd41c94b2a37af2f2e008a03b846fd996664"), attestationDataRoot(data))
+}
+
+func TestBeaconBlockHeaderRoot(t *testing.T) {
+	header := &pb.BeaconBlockHeader{
+		Slot:          1,
+		ProposerIndex: 2,
+		ParentRoot:    bytes.Repeat([]byte{0x04}, 32),
+		StateRoot:     bytes.Repeat([]byte{0x05}, 32),
+		BodyRoot:      bytes.Repeat([]byte{0x06}, 32),
+	}
+	require.Equal(t, _byte("26a2dfcfd362c43170be10ca94cb10a988ab97b10ae3a62f481723434deba926"), beaconBlockHeaderRoot(header))
+}
+
+func TestSigningRoot(t *testing.T) {
+	objectRoot := _byte("9c898c684b452a36d0f38ede8bdfdd41c94b2a37af2f2e008a03b846fd996664")
+	domain := bytes.Repeat([]byte{0x09}, 32)
+	require.Equal(t, _byte("31664419a53cd839c3ebee6009dbaf309e2b15d8faf48d6f6fd64be6e94f36a8"), signingRoot(objectRoot, domain))
+}
+
+func TestBitlistRoot(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		root []byte
+		err  string
+	}{
+		{
+			name: "Empty",
+			data: []byte{0x01},
+			root: _byte("e8e527e84f666163a90ef900e013f56b0a4d020148b2224057b719f351b003a6"),
+		},
+		{
+			name: "DelimiterInOwnByte",
+			data: []byte{0xff, 0x01},
+			root: _byte("eebfa0d92b6c11efb3105805eae7ab6f4120ca797b958f5c7e7e5c46e2fb23be"),
+		},
+		{
+			name: "DelimiterInDataByte",
+			data: []byte{0x80},
+			root: _byte("b588c8760194fd5360c6b300b1888a8beb8a0284ca817c15913dc6512b6a0b92"),
+		},
+		{
+			name: "MultipleChunks",
+			data: bytes.Repeat([]byte{0xff}, 40),
+			root: _byte("72bf3224751c8449c38b5898fa83376dd33a2d6c79b5df580791ea430f9bfa97"),
+		},
+		{
+			name: "Missing",
+			data: []byte{},
+			err:  "missing delimiting bit",
+		},
+		{
+			name: "NoDelimiter",
+			data: []byte{0x01, 0x00},
+			err:  "missing delimiting bit",
+		},
+		{
+			name: "TooLong",
+			data: append(bytes.Repeat([]byte{0xff}, 256), 0x03),
+			err:  "too many bits",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := bitlistRoot(test.data, maxValidatorsPerCommittee)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.root, root)
+			}
+		})
+	}
+}
+
+func TestAggregateAndProofRoot(t *testing.T) {
+	aggregateAndProof := &AggregateAndProof{
+		AggregatorIndex: 9,
+		Aggregate: &Attestation{
+			AggregationBits: []byte{0x05, 0x80, 0x03},
+			Data: &AttestationData{
+				Slot:            12345,
+				Index:           7,
+				BeaconBlockRoot: bytes.Repeat([]byte{0x01}, 32),
+				Source: &Checkpoint{
+					Epoch: 3,
+					Root:  bytes.Repeat([]byte{0x02}, 32),
+				},
+				Target: &Checkpoint{
+					Epoch: 4,
+					Root:  bytes.Repeat([]byte{0x03}, 32),
+				},
+			},
+			Signature: bytes.Repeat([]byte{0x04}, 96),
+		},
+		SelectionProof: bytes.Repeat([]byte{0x05}, 96),
+	}
+	root, err := attestationRoot(aggregateAndProof.Aggregate)
+	require.NoError(t, err)
+	require.Equal(t, _byte("94de7cfcb7ca505fcdf2b09e8f32502438e84164b4d45cf4d577b6719410c0dc"), root)
+	root, err = aggregateAndProofRoot(aggregateAndProof)
+	require.NoError(t, err)
+	require.Equal(t, _byte("7b06cb14b3c1411119e80de61961771534bc8d1da4ebef01a39ae2298f0da5c1"), root)
+
+	// Electra attestations have a larger aggregation bits limit, and committee bits.
+	aggregateAndProof.Aggregate.CommitteeBits = []byte{0x81, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02}
+	root, err = attestationRoot(aggregateAndProof.Aggregate)
+	require.NoError(t, err)
+	require.Equal(t, _byte("7e2c04673b874853cba7a7f7d15be2403b746a77e699f22d0d7a25d467ba85c4"), root)
+	root, err = aggregateAndProofRoot(aggregateAndProof)
+	require.NoError(t, err)
+	require.Equal(t, _byte("5738f0b566c4c5fdb12b716713790e9b6c6da89f49e6deae683ea62495f25b09"), root)
+}
+
+func TestContributionAndProofRoot(t *testing.T) {
+	contributionAndProof := &ContributionAndProof{
+		AggregatorIndex: 11,
+		Contribution: &SyncCommitteeContribution{
+			Slot:              100,
+			BeaconBlockRoot:   bytes.Repeat([]byte{0x06}, 32),
+			SubcommitteeIndex: 2,
+			AggregationBits:   bytes.Repeat([]byte{0xa5}, 16),
+			Signature:         bytes.Repeat([]byte{0x07}, 96),
+		},
+		SelectionProof: bytes.Repeat([]byte{0x08}, 96),
+	}
+	require.Equal(t, _byte("3db19214945b04c83bdc8ae27f37e7eadca9f3fe7461390216332f1a87ec7408"), contributionAndProofRoot(contributionAndProof))
+}
+
+func TestValidatorRegistrationRoot(t *testing.T) {
+	registration := &ValidatorRegistration{
+		FeeRecipient: bytes.Repeat([]byte{0x09}, 20),
+		GasLimit:     30000000,
+		Timestamp:    1700000000,
+		Pubkey:       bytes.Repeat([]byte{0x0a}, 48),
+	}
+	require.Equal(t, _byte("39a7fa3c6a859bd9f47b757b3fbec43d7bfced1a6afd8c6afd77f478f52d1137"), validatorRegistrationRoot(registration))
+}
@@ -0,0 +1,299 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// SlashableError is returned when local slashing protection refuses a
+// request to sign.
+type SlashableError struct {
+	// PubKey is the public key of the validator.
+	PubKey [48]byte
+	// Reason is the reason the request was refused.
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *SlashableError) Error() string {
+	return fmt.Sprintf("slashing protection refused request for %#x: %s", e.PubKey, e.Reason)
+}
+
+// Is returns true if the target is ErrSlashable or ErrDenied.
+func (*SlashableError) Is(target error) bool {
+	return target == ErrSlashable || target == ErrDenied
+}
+
+// SlashingProtectionRecord is the slashing protection record of a validator,
+// holding the highest slot and epochs for which it has signed.
+type SlashingProtectionRecord struct {
+	// Proposed is true if a proposal has been signed.
+	Proposed bool
+	// ProposalSlot is the highest slot for which a proposal has been signed.
+	ProposalSlot uint64
+	// Attested is true if an attestation has been signed.
+	Attested bool
+	// SourceEpoch is the highest source epoch for which an attestation has been signed.
+	SourceEpoch uint64
+	// TargetEpoch is the highest target epoch for which an attestation has been signed.
+	TargetEpoch uint64
+}
+
+// merge merges another record in to the record, keeping the highest values.
+func (r *SlashingProtectionRecord) merge(other *SlashingProtectionRecord) {
+	if other.Proposed {
+		if !r.Proposed || other.ProposalSlot > r.ProposalSlot {
+			r.ProposalSlot = other.ProposalSlot
+		}
+		r.Proposed = true
+	}
+	if other.Attested {
+		if !r.Attested || other.SourceEpoch > r.SourceEpoch {
+			r.SourceEpoch = other.SourceEpoch
+		}
+		if !r.Attested || other.TargetEpoch > r.TargetEpoch {
+			r.TargetEpoch = other.TargetEpoch
+		}
+		r.Attested = true
+	}
+}
+
+// SlashingProtectionStore is the interface for stores of slashing
+// protection records.
+type SlashingProtectionStore interface {
+	// Record returns the record for a validator.  A validator that has
+	// not signed has an empty record.
+	Record(ctx context.Context, pubKey [48]byte) (*SlashingProtectionRecord, error)
+
+	// SetRecord sets the record for a validator.
+	SetRecord(ctx context.Context, pubKey [48]byte, record *SlashingProtectionRecord) error
+
+	// Records returns the records for all validators.
+	Records(ctx context.Context) (map[[48]byte]*SlashingProtectionRecord, error)
+}
+
+// SlashingProtection refuses requests to sign proposals and attestations
+// that are slashable given those previously signed, before they are sent to
+// Dirk.  It is a conservative check in front of Dirk's own slashing
+// protection rather than a replacement for it: a proposal must be for a
+// later slot than any previously signed, and an attestation must have a
+// source epoch no earlier, and a target epoch later, than any previously
+// signed.  Requests are recorded before they are sent to Dirk, and are not
+// sent if they cannot be recorded, so a request that fails or whose response
+// is lost is treated as signed.
+type SlashingProtection struct {
+	mu                    sync.Mutex
+	store                 SlashingProtectionStore
+	genesisValidatorsRoot []byte
+}
+
+// NewSlashingProtection creates slashing protection for the chain with the
+// given genesis validators root, keeping its records in the given store.
+func NewSlashingProtection(store SlashingProtectionStore, genesisValidatorsRoot []byte) (*SlashingProtection, error) {
+	if store == nil {
+		return nil, errors.New("no store specified")
+	}
+	if len(genesisValidatorsRoot) != 32 {
+		return nil, errors.New("genesis validators root must be 32 bytes in length")
+	}
+
+	return &SlashingProtection{
+		store:                 store,
+		genesisValidatorsRoot: genesisValidatorsRoot,
+	}, nil
+}
+
+// reserveProposal checks that a proposal is not slashable and, if not,
+// records it before it is signed.
+func (p *SlashingProtection) reserveProposal(ctx context.Context, pubKey [48]byte, slot uint64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	record, err := p.store.Record(ctx, pubKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain slashing protection record")
+	}
+	if record.Proposed && slot <= record.ProposalSlot {
+		return &SlashableError{
+			PubKey: pubKey,
+			Reason: fmt.Sprintf("proposal slot %d is not after previously signed slot %d", slot, record.ProposalSlot),
+		}
+	}
+
+	return p.reserve(ctx, pubKey, record, &SlashingProtectionRecord{
+		Proposed:     true,
+		ProposalSlot: slot,
+	})
+}
+
+// reserveAttestation checks that an attestation is not slashable and, if
+// not, records it before it is signed.
+func (p *SlashingProtection) reserveAttestation(ctx context.Context, pubKey [48]byte, sourceEpoch uint64, targetEpoch uint64) error {
+	if sourceEpoch > targetEpoch {
+		return &SlashableError{
+			PubKey: pubKey,
+			Reason: fmt.Sprintf("source epoch %d is after target epoch %d", sourceEpoch, targetEpoch),
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	record, err := p.store.Record(ctx, pubKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain slashing protection record")
+	}
+	if record.Attested {
+		if sourceEpoch < record.SourceEpoch {
+			return &SlashableError{
+				PubKey: pubKey,
+				Reason: fmt.Sprintf("source epoch %d is before previously signed source epoch %d", sourceEpoch, record.SourceEpoch),
+			}
+		}
+		if targetEpoch <= record.TargetEpoch {
+			return &SlashableError{
+				PubKey: pubKey,
+				Reason: fmt.Sprintf("target epoch %d is not after previously signed target epoch %d", targetEpoch, record.TargetEpoch),
+			}
+		}
+	}
+
+	return p.reserve(ctx, pubKey, record, &SlashingProtectionRecord{
+		Attested:    true,
+		SourceEpoch: sourceEpoch,
+		TargetEpoch: targetEpoch,
+	})
+}
+
+// reserve merges a proposal or attestation that is about to be signed in to
+// the record of a validator.  It must be called with the lock held, so that
+// no other request can pass the check in the meantime.  The record is written
+// even if the context is done, as the caller may still send the request.
+func (p *SlashingProtection) reserve(ctx context.Context,
+	pubKey [48]byte,
+	record *SlashingProtectionRecord,
+	signing *SlashingProtectionRecord,
+) error {
+	record.merge(signing)
+	if err := p.store.SetRecord(context.WithoutCancel(ctx), pubKey, record); err != nil {
+		return errors.Wrap(err, "failed to set slashing protection record")
+	}
+
+	return nil
+}
+
+// record merges a record, for example an imported record, in to the record
+// of a validator.
+func (p *SlashingProtection) record(ctx context.Context, pubKey [48]byte, other *SlashingProtectionRecord) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	record, err := p.store.Record(ctx, pubKey)
+	if err != nil {
+		return errors.Wrap(err, "failed to obtain slashing protection record")
+	}
+	record.merge(other)
+	if err := p.store.SetRecord(ctx, pubKey, record); err != nil {
+		return errors.Wrap(err, "failed to set slashing protection record")
+	}
+
+	return nil
+}
+
+// slashingProtectionKey returns the public key of the validator for an
+// account, which is the composite public key for distributed accounts.
+func slashingProtectionKey(acc e2wtypes.Account) [48]byte {
+	var key [48]byte
+	if provider, isProvider := acc.(e2wtypes.AccountCompositePublicKeyProvider); isProvider {
+		copy(key[:], provider.CompositePublicKey().Marshal())
+	} else {
+		copy(key[:], acc.PublicKey().Marshal())
+	}
+
+	return key
+}
+
+// reserveProposal checks a proposal against the wallet's slashing
+// protection, if any, recording it if it is not slashable.
+func (w *wallet) reserveProposal(ctx context.Context, acc e2wtypes.Account, slot uint64) error {
+	if w.slashingProtection == nil {
+		return nil
+	}
+
+	return w.slashingProtection.reserveProposal(ctx, slashingProtectionKey(acc), slot)
+}
+
+// reserveAttestation checks an attestation against the wallet's slashing
+// protection, if any, recording it if it is not slashable.
+func (w *wallet) reserveAttestation(ctx context.Context, acc e2wtypes.Account, sourceEpoch uint64, targetEpoch uint64) error {
+	if w.slashingProtection == nil {
+		return nil
+	}
+
+	return w.slashingProtection.reserveAttestation(ctx, slashingProtectionKey(acc), sourceEpoch, targetEpoch)
+}
+
+// protectAttestations checks multiple attestations against the wallet's
+// slashing protection, recording and then signing those that are not
+// slashable.  Refused attestations are given their reason as their error.
+func (w *wallet) protectAttestations(ctx context.Context,
+	accounts []e2wtypes.Account,
+	committeeIndices []uint64,
+	sourceEpoch uint64,
+	targetEpoch uint64,
+	sign func(accounts []e2wtypes.Account, committeeIndices []uint64) (*MultiSignResult, error),
+) (
+	*MultiSignResult,
+	error,
+) {
+	if len(accounts) != len(committeeIndices) {
+		return nil, errors.New("number of accounts does not match number of committee indices")
+	}
+
+	res := &MultiSignResult{
+		Items: make([]*MultiSignItemResult, len(accounts)),
+	}
+	indices := make([]int, 0, len(accounts))
+	signAccounts := make([]e2wtypes.Account, 0, len(accounts))
+	signCommitteeIndices := make([]uint64, 0, len(accounts))
+	for i, acc := range accounts {
+		if err := w.reserveAttestation(ctx, acc, sourceEpoch, targetEpoch); err != nil {
+			res.Items[i] = &MultiSignItemResult{Err: err}
+
+			continue
+		}
+		indices = append(indices, i)
+		signAccounts = append(signAccounts, acc)
+		signCommitteeIndices = append(signCommitteeIndices, committeeIndices[i])
+	}
+	if len(indices) == 0 {
+		return res, nil
+	}
+
+	signed, err := sign(signAccounts, signCommitteeIndices)
+	if err != nil {
+		return nil, err
+	}
+	for i, index := range indices {
+		res.Items[index] = signed.Items[i]
+	}
+
+	return res, nil
+}
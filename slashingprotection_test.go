@@ -0,0 +1,346 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+var genesisValidatorsRoot = bytes.Repeat([]byte{0x0a}, 32)
+
+func TestNewSlashingProtection(t *testing.T) {
+	_, err := dirk.NewSlashingProtection(nil, genesisValidatorsRoot)
+	require.EqualError(t, err, "no store specified")
+
+	_, err = dirk.NewSlashingProtection(dirk.NewMemorySlashingProtectionStore(), genesisValidatorsRoot[1:])
+	require.EqualError(t, err, "genesis validators root must be 32 bytes in length")
+}
+
+func TestSlashingProtection(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddAccount("Wallet", "Account 1", []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 3", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	protection, err := dirk.NewSlashingProtection(dirk.NewMemorySlashingProtectionStore(), genesisValidatorsRoot)
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithSlashingProtection(protection))
+	accounts := make([]e2wtypes.Account, 3)
+	for i := range accounts {
+		accounts[i], err = wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, fmt.Sprintf("Account %d", i+1))
+		require.NoError(t, err)
+	}
+
+	signAttestation := func(account e2wtypes.Account, sourceEpoch uint64, targetEpoch uint64) error {
+		_, err := account.(e2wtypes.AccountProtectingSigner).SignBeaconAttestation(ctx,
+			targetEpoch*32,
+			0,
+			bytes.Repeat([]byte{0x01}, 32),
+			sourceEpoch,
+			bytes.Repeat([]byte{0x02}, 32),
+			targetEpoch,
+			bytes.Repeat([]byte{0x03}, 32),
+			bytes.Repeat([]byte{0x04}, 32),
+		)
+
+		return err
+	}
+	signProposal := func(account e2wtypes.Account, slot uint64) error {
+		_, err := account.(e2wtypes.AccountProtectingSigner).SignBeaconProposal(ctx,
+			slot,
+			1,
+			bytes.Repeat([]byte{0x01}, 32),
+			bytes.Repeat([]byte{0x02}, 32),
+			bytes.Repeat([]byte{0x03}, 32),
+			bytes.Repeat([]byte{0x04}, 32),
+		)
+
+		return err
+	}
+
+	for _, account := range accounts[:2] {
+		require.NoError(t, signAttestation(account, 0, 2))
+		require.NoError(t, signProposal(account, 64))
+	}
+	attestationRequests := requests(cluster, "SignBeaconAttestation")
+	proposalRequests := requests(cluster, "SignBeaconProposal")
+
+	// Slashable requests are refused without contacting Dirk.
+	for _, account := range accounts[:2] {
+		err = signAttestation(account, 0, 2)
+		require.ErrorIs(t, err, dirk.ErrSlashable)
+		require.ErrorIs(t, err, dirk.ErrDenied)
+		require.ErrorContains(t, err, "target epoch 2 is not after previously signed target epoch 2")
+		require.ErrorContains(t, signAttestation(account, 1, 1), "target epoch 1 is not after previously signed target epoch 2")
+		require.ErrorContains(t, signAttestation(account, 3, 2), "source epoch 3 is after target epoch 2")
+		err = signProposal(account, 64)
+		require.ErrorIs(t, err, dirk.ErrSlashable)
+		require.ErrorContains(t, err, "proposal slot 64 is not after previously signed slot 64")
+	}
+	require.Equal(t, attestationRequests, requests(cluster, "SignBeaconAttestation"))
+	require.Equal(t, proposalRequests, requests(cluster, "SignBeaconProposal"))
+
+	// Only the attestations that are not slashable are sent.
+	res, err := accounts[1].(dirk.AccountMultiSignResultsProvider).SignBeaconAttestationsResults(ctx,
+		64,
+		accounts[1:],
+		[]uint64{0, 1},
+		bytes.Repeat([]byte{0x01}, 32),
+		0,
+		bytes.Repeat([]byte{0x02}, 32),
+		2,
+		bytes.Repeat([]byte{0x03}, 32),
+		bytes.Repeat([]byte{0x04}, 32),
+	)
+	require.NoError(t, err)
+	require.Equal(t, dirk.MultiSignStatusPartial, res.Status())
+	require.ErrorIs(t, res.Items[0].Err, dirk.ErrSlashable)
+	require.NoError(t, res.Items[1].Err)
+	require.NotNil(t, res.Items[1].Signature)
+
+	// Signed attestations are recorded.
+	require.ErrorIs(t, signAttestation(accounts[2], 0, 2), dirk.ErrSlashable)
+	require.NoError(t, signAttestation(accounts[2], 2, 3))
+}
+
+// failingSlashingProtectionStore is a slashing protection store that
+// cannot write records.
+type failingSlashingProtectionStore struct {
+	dirk.SlashingProtectionStore
+}
+
+func (*failingSlashingProtectionStore) SetRecord(_ context.Context, _ [48]byte, _ *dirk.SlashingProtectionRecord) error {
+	return errors.New("mock error")
+}
+
+func TestSlashingProtectionReservation(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 1)
+	_, err := cluster.AddAccount("Wallet", "Account", []byte("pass"))
+	require.NoError(t, err)
+	protection, err := dirk.NewSlashingProtection(dirk.NewMemorySlashingProtectionStore(), genesisValidatorsRoot)
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithSlashingProtection(protection))
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+
+	signAttestation := func(targetEpoch uint64, targetRoot []byte) error {
+		_, err := account.(e2wtypes.AccountProtectingSigner).SignBeaconAttestation(ctx,
+			targetEpoch*32,
+			0,
+			bytes.Repeat([]byte{0x01}, 32),
+			0,
+			bytes.Repeat([]byte{0x02}, 32),
+			targetEpoch,
+			targetRoot,
+			bytes.Repeat([]byte{0x04}, 32),
+		)
+
+		return err
+	}
+
+	// Of concurrent conflicting attestations only one is sent to Dirk.
+	errs := make([]error, 8)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = signAttestation(1, bytes.Repeat([]byte{byte(i)}, 32))
+		}(i)
+	}
+	wg.Wait()
+	signed := 0
+	for _, err := range errs {
+		if err == nil {
+			signed++
+
+			continue
+		}
+		var slashableErr *dirk.SlashableError
+		require.ErrorAs(t, err, &slashableErr)
+	}
+	require.Equal(t, 1, signed)
+	require.Equal(t, uint64(1), requests(cluster, "SignBeaconAttestation"))
+
+	// Attestations are recorded before they are sent, so are recorded even if signing fails.
+	cluster.Server(1).SetDown(true)
+	require.Error(t, signAttestation(2, bytes.Repeat([]byte{0x03}, 32)))
+	cluster.Server(1).SetDown(false)
+	require.ErrorIs(t, signAttestation(2, bytes.Repeat([]byte{0x03}, 32)), dirk.ErrSlashable)
+
+	// Attestations that cannot be recorded are not sent.
+	protection, err = dirk.NewSlashingProtection(&failingSlashingProtectionStore{dirk.NewMemorySlashingProtectionStore()}, genesisValidatorsRoot)
+	require.NoError(t, err)
+	wallet = openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithSlashingProtection(protection))
+	account, err = wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+	attestationRequests := requests(cluster, "SignBeaconAttestation")
+	require.EqualError(t, signAttestation(3, bytes.Repeat([]byte{0x03}, 32)), "failed to set slashing protection record: mock error")
+	require.Equal(t, attestationRequests, requests(cluster, "SignBeaconAttestation"))
+}
+
+func TestSlashingProtectionUnsentBatch(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddAccount("Wallet", "Account 1", []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	protection, err := dirk.NewSlashingProtection(dirk.NewMemorySlashingProtectionStore(), genesisValidatorsRoot)
+	require.NoError(t, err)
+
+	// Slot 10 is the current slot.
+	slotDuration := 2 * time.Second
+	genesisTime := time.Now().Add(-10*slotDuration - slotDuration/4)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet",
+		dirk.WithSlashingProtection(protection),
+		dirk.WithGenesisTime(genesisTime),
+		dirk.WithSlotDuration(slotDuration),
+	)
+	accounts := make([]e2wtypes.Account, 2)
+	for i := range accounts {
+		accounts[i], err = wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, fmt.Sprintf("Account %d", i+1))
+		require.NoError(t, err)
+	}
+
+	signAttestations := func(slot uint64, accounts []e2wtypes.Account) (*dirk.MultiSignResult, error) {
+		committeeIndices := make([]uint64, len(accounts))
+
+		return accounts[0].(dirk.AccountMultiSignResultsProvider).SignBeaconAttestationsResults(ctx,
+			slot,
+			accounts,
+			committeeIndices,
+			bytes.Repeat([]byte{0x01}, 32),
+			0,
+			bytes.Repeat([]byte{0x02}, 32),
+			1,
+			bytes.Repeat([]byte{0x03}, 32),
+			bytes.Repeat([]byte{0x04}, 32),
+		)
+	}
+
+	// Batches that are not sent to Dirk are not recorded.
+	for _, account := range accounts {
+		_, err = signAttestations(9, []e2wtypes.Account{account})
+		require.ErrorIs(t, err, dirk.ErrDeadlinePassed)
+	}
+	_, err = signAttestations(10, accounts)
+	require.EqualError(t, err, "non-account provided in list")
+	_, err = signAttestations(10, []e2wtypes.Account{accounts[1], accounts[0]})
+	require.EqualError(t, err, "non-distributed account provided in list")
+	require.Zero(t, requests(cluster, "SignBeaconAttestation"))
+
+	for _, account := range accounts {
+		res, err := signAttestations(10, []e2wtypes.Account{account})
+		require.NoError(t, err)
+		require.Equal(t, dirk.MultiSignStatusSucceeded, res.Status())
+	}
+}
+
+func TestSlashingProtectionInterchange(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 1)
+	_, err := cluster.AddAccount("Wallet", "Account", []byte("pass"))
+	require.NoError(t, err)
+	protection, err := dirk.NewSlashingProtection(dirk.NewMemorySlashingProtectionStore(), genesisValidatorsRoot)
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet", dirk.WithSlashingProtection(protection))
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+	pubKey := fmt.Sprintf("%#x", account.PublicKey().Marshal())
+
+	interchange := func(version string, root []byte) string {
+		return fmt.Sprintf(`{"metadata":{"interchange_format_version":"%s","genesis_validators_root":"%#x"},"data":[{"pubkey":"%s","signed_blocks":[{"slot":"81952"},{"slot":"81951"}],"signed_attestations":[{"source_epoch":"2290","target_epoch":"3007"},{"source_epoch":"2289","target_epoch":"3008"}]}]}`, version, root, pubKey)
+	}
+
+	require.EqualError(t, protection.Import(ctx, strings.NewReader(interchange("4", genesisValidatorsRoot))), "unsupported interchange format version 4")
+	require.EqualError(t, protection.Import(ctx, strings.NewReader(interchange("5", bytes.Repeat([]byte{0x0b}, 32)))), "interchange data is for a different chain")
+	require.NoError(t, protection.Import(ctx, strings.NewReader(interchange("5", genesisValidatorsRoot))))
+
+	_, err = account.(e2wtypes.AccountProtectingSigner).SignBeaconProposal(ctx,
+		81952,
+		1,
+		bytes.Repeat([]byte{0x01}, 32),
+		bytes.Repeat([]byte{0x02}, 32),
+		bytes.Repeat([]byte{0x03}, 32),
+		bytes.Repeat([]byte{0x04}, 32),
+	)
+	require.ErrorIs(t, err, dirk.ErrSlashable)
+	require.Zero(t, requests(cluster, "SignBeaconProposal"))
+
+	// The export holds the highest slot and epochs.
+	var exported bytes.Buffer
+	require.NoError(t, protection.Export(ctx, &exported))
+	require.JSONEq(t,
+		fmt.Sprintf(`{"metadata":{"interchange_format_version":"5","genesis_validators_root":"%#x"},"data":[{"pubkey":"%s","signed_blocks":[{"slot":"81952"}],"signed_attestations":[{"source_epoch":"2290","target_epoch":"3008"}]}]}`, genesisValidatorsRoot, pubKey),
+		exported.String(),
+	)
+
+	// The export can be imported elsewhere.
+	other, err := dirk.NewSlashingProtection(dirk.NewMemorySlashingProtectionStore(), genesisValidatorsRoot)
+	require.NoError(t, err)
+	require.NoError(t, other.Import(ctx, &exported))
+	var reexported bytes.Buffer
+	require.NoError(t, other.Export(ctx, &reexported))
+	var original bytes.Buffer
+	require.NoError(t, protection.Export(ctx, &original))
+	require.JSONEq(t, original.String(), reexported.String())
+}
+
+func TestFileSlashingProtectionStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "slashingprotection.json")
+	pubKey := [48]byte{0x01}
+
+	store, err := dirk.NewFileSlashingProtectionStore(path)
+	require.NoError(t, err)
+	record, err := store.Record(ctx, pubKey)
+	require.NoError(t, err)
+	require.Equal(t, &dirk.SlashingProtectionRecord{}, record)
+	require.NoError(t, store.SetRecord(ctx, pubKey, &dirk.SlashingProtectionRecord{Attested: true, SourceEpoch: 1, TargetEpoch: 2}))
+
+	// Records persist across stores.
+	store, err = dirk.NewFileSlashingProtectionStore(path)
+	require.NoError(t, err)
+	record, err = store.Record(ctx, pubKey)
+	require.NoError(t, err)
+	require.Equal(t, &dirk.SlashingProtectionRecord{Attested: true, SourceEpoch: 1, TargetEpoch: 2}, record)
+	records, err := store.Records(ctx)
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	// Records that cannot be written are not kept.
+	store, err = dirk.NewFileSlashingProtectionStore(filepath.Join(t.TempDir(), "missing", "slashingprotection.json"))
+	require.NoError(t, err)
+	require.Error(t, store.SetRecord(ctx, pubKey, &dirk.SlashingProtectionRecord{Proposed: true, ProposalSlot: 1}))
+	records, err = store.Records(ctx)
+	require.NoError(t, err)
+	require.Empty(t, records)
+}
@@ -0,0 +1,204 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// MemorySlashingProtectionStore is a slashing protection store that holds
+// its records in memory.
+type MemorySlashingProtectionStore struct {
+	mu      sync.RWMutex
+	records map[[48]byte]SlashingProtectionRecord
+}
+
+// NewMemorySlashingProtectionStore creates a new in-memory slashing protection store.
+func NewMemorySlashingProtectionStore() *MemorySlashingProtectionStore {
+	return &MemorySlashingProtectionStore{
+		records: make(map[[48]byte]SlashingProtectionRecord),
+	}
+}
+
+// Record returns the record for a validator.
+func (s *MemorySlashingProtectionStore) Record(_ context.Context, pubKey [48]byte) (*SlashingProtectionRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record := s.records[pubKey]
+
+	return &record, nil
+}
+
+// SetRecord sets the record for a validator.
+func (s *MemorySlashingProtectionStore) SetRecord(_ context.Context, pubKey [48]byte, record *SlashingProtectionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[pubKey] = *record
+
+	return nil
+}
+
+// Records returns the records for all validators.
+func (s *MemorySlashingProtectionStore) Records(_ context.Context) (map[[48]byte]*SlashingProtectionRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make(map[[48]byte]*SlashingProtectionRecord, len(s.records))
+	for pubKey, record := range s.records {
+		res[pubKey] = &record
+	}
+
+	return res, nil
+}
+
+// FileSlashingProtectionStore is a slashing protection store that holds its
+// records in memory and writes them to a file whenever they change.
+type FileSlashingProtectionStore struct {
+	path   string
+	memory *MemorySlashingProtectionStore
+}
+
+// fileSlashingProtectionRecord is the JSON representation of a record in a file.
+type fileSlashingProtectionRecord struct {
+	Proposed     bool   `json:"proposed,omitempty"`
+	ProposalSlot uint64 `json:"proposal_slot,omitempty"`
+	Attested     bool   `json:"attested,omitempty"`
+	SourceEpoch  uint64 `json:"source_epoch,omitempty"`
+	TargetEpoch  uint64 `json:"target_epoch,omitempty"`
+}
+
+// NewFileSlashingProtectionStore creates a slashing protection store backed
+// by the file at the given path, loading any records already in the file.
+func NewFileSlashingProtectionStore(path string) (*FileSlashingProtectionStore, error) {
+	s := &FileSlashingProtectionStore{
+		path:   path,
+		memory: NewMemorySlashingProtectionStore(),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+
+		return nil, errors.Wrap(err, "failed to read slashing protection file")
+	}
+
+	records := make(map[string]*fileSlashingProtectionRecord)
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, errors.Wrap(err, "invalid slashing protection file")
+	}
+	for key, record := range records {
+		pubKey, err := hex.DecodeString(strings.TrimPrefix(key, "0x"))
+		if err != nil || len(pubKey) != 48 {
+			return nil, errors.Errorf("invalid public key %s in slashing protection file", key)
+		}
+		s.memory.records[[48]byte(pubKey)] = SlashingProtectionRecord{
+			Proposed:     record.Proposed,
+			ProposalSlot: record.ProposalSlot,
+			Attested:     record.Attested,
+			SourceEpoch:  record.SourceEpoch,
+			TargetEpoch:  record.TargetEpoch,
+		}
+	}
+
+	return s, nil
+}
+
+// Record returns the record for a validator.
+func (s *FileSlashingProtectionStore) Record(ctx context.Context, pubKey [48]byte) (*SlashingProtectionRecord, error) {
+	return s.memory.Record(ctx, pubKey)
+}
+
+// SetRecord sets the record for a validator, and writes the records to the file.
+func (s *FileSlashingProtectionStore) SetRecord(_ context.Context, pubKey [48]byte, record *SlashingProtectionRecord) error {
+	s.memory.mu.Lock()
+	defer s.memory.mu.Unlock()
+
+	previous, existed := s.memory.records[pubKey]
+	s.memory.records[pubKey] = *record
+	if err := s.write(); err != nil {
+		// Keep the records in memory consistent with those in the file.
+		if existed {
+			s.memory.records[pubKey] = previous
+		} else {
+			delete(s.memory.records, pubKey)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// Records returns the records for all validators.
+func (s *FileSlashingProtectionStore) Records(ctx context.Context) (map[[48]byte]*SlashingProtectionRecord, error) {
+	return s.memory.Records(ctx)
+}
+
+// write writes the records to the file, replacing it atomically.
+// The caller must hold the lock.
+func (s *FileSlashingProtectionStore) write() error {
+	records := make(map[string]*fileSlashingProtectionRecord, len(s.memory.records))
+	for pubKey, record := range s.memory.records {
+		records["0x"+hex.EncodeToString(pubKey[:])] = &fileSlashingProtectionRecord{
+			Proposed:     record.Proposed,
+			ProposalSlot: record.ProposalSlot,
+			Attested:     record.Attested,
+			SourceEpoch:  record.SourceEpoch,
+			TargetEpoch:  record.TargetEpoch,
+		}
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal slashing protection records")
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create slashing protection file")
+	}
+	defer func() {
+		// Only present if the file was not renamed.
+		_ = os.Remove(tmpFile.Name())
+	}()
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+
+		return errors.Wrap(err, "failed to write slashing protection file")
+	}
+	if err := tmpFile.Sync(); err != nil {
+		_ = tmpFile.Close()
+
+		return errors.Wrap(err, "failed to sync slashing protection file")
+	}
+	if err := tmpFile.Close(); err != nil {
+		return errors.Wrap(err, "failed to close slashing protection file")
+	}
+	if err := os.Rename(tmpFile.Name(), s.path); err != nil {
+		return errors.Wrap(err, "failed to replace slashing protection file")
+	}
+
+	return nil
+}
@@ -0,0 +1,382 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	pb "github.com/wealdtech/eth2-signer-api/pb/v1"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// domainApplicationBuilder is the domain type for builder API messages.
+var domainApplicationBuilder = e2types.DomainType{0x00, 0x00, 0x00, 0x01}
+
+// Checkpoint is a checkpoint.
+type Checkpoint struct {
+	Epoch uint64
+	Root  []byte
+}
+
+// AttestationData is the data of an attestation.
+type AttestationData struct {
+	Slot            uint64
+	Index           uint64
+	BeaconBlockRoot []byte
+	Source          *Checkpoint
+	Target          *Checkpoint
+}
+
+// pb returns the attestation data as used by the signer API.
+func (d *AttestationData) pb() *pb.AttestationData {
+	return &pb.AttestationData{
+		Slot:            d.Slot,
+		CommitteeIndex:  d.Index,
+		BeaconBlockRoot: d.BeaconBlockRoot,
+		Source: &pb.Checkpoint{
+			Epoch: d.Source.Epoch,
+			Root:  d.Source.Root,
+		},
+		Target: &pb.Checkpoint{
+			Epoch: d.Target.Epoch,
+			Root:  d.Target.Root,
+		},
+	}
+}
+
+// Attestation is an attestation.
+type Attestation struct {
+	// AggregationBits is the serialized bitlist of attesting validators,
+	// including its delimiting bit.
+	AggregationBits []byte
+	Data            *AttestationData
+	Signature       []byte
+	// CommitteeBits is the serialized bitvector of committees whose
+	// validators are included in the aggregation bits.  It is set for
+	// attestations from Electra onwards, and nil for earlier attestations.
+	CommitteeBits []byte
+}
+
+// AggregateAndProof is an aggregate attestation and the proof that the
+// aggregator was selected.
+type AggregateAndProof struct {
+	AggregatorIndex uint64
+	Aggregate       *Attestation
+	SelectionProof  []byte
+}
+
+// SyncCommitteeContribution is an aggregate of sync committee messages for
+// a subcommittee.
+type SyncCommitteeContribution struct {
+	Slot              uint64
+	BeaconBlockRoot   []byte
+	SubcommitteeIndex uint64
+	// AggregationBits is the serialized bitvector of participating members
+	// of the subcommittee.
+	AggregationBits []byte
+	Signature       []byte
+}
+
+// ContributionAndProof is a sync committee contribution and the proof that
+// the aggregator was selected.
+type ContributionAndProof struct {
+	AggregatorIndex uint64
+	Contribution    *SyncCommitteeContribution
+	SelectionProof  []byte
+}
+
+// ValidatorRegistration is a registration of a validator with block builders.
+type ValidatorRegistration struct {
+	FeeRecipient []byte
+	GasLimit     uint64
+	Timestamp    uint64
+	Pubkey       []byte
+}
+
+// AccountTypedSigner is the interface for accounts that sign beacon chain
+// messages other than proposals and attestations.  Each method computes the
+// signing root of its message and the domain from the supplied fork version
+// and genesis validators root, which must be those of the epoch of the
+// message.
+type AccountTypedSigner interface {
+	// SignSyncCommitteeMessage signs a sync committee message for a beacon block root.
+	SignSyncCommitteeMessage(ctx context.Context,
+		beaconBlockRoot []byte,
+		forkVersion []byte,
+		genesisValidatorsRoot []byte,
+	) (
+		e2types.Signature,
+		error,
+	)
+
+	// SignSyncCommitteeSelectionProof signs a proof of selection as a sync committee aggregator.
+	SignSyncCommitteeSelectionProof(ctx context.Context,
+		slot uint64,
+		subcommitteeIndex uint64,
+		forkVersion []byte,
+		genesisValidatorsRoot []byte,
+	) (
+		e2types.Signature,
+		error,
+	)
+
+	// SignContributionAndProof signs a sync committee contribution and proof.
+	SignContributionAndProof(ctx context.Context,
+		contributionAndProof *ContributionAndProof,
+		forkVersion []byte,
+		genesisValidatorsRoot []byte,
+	) (
+		e2types.Signature,
+		error,
+	)
+
+	// SignSelectionProof signs a proof of selection as an attestation aggregator.
+	SignSelectionProof(ctx context.Context,
+		slot uint64,
+		forkVersion []byte,
+		genesisValidatorsRoot []byte,
+	) (
+		e2types.Signature,
+		error,
+	)
+
+	// SignAggregateAndProof signs an aggregate and proof.
+	SignAggregateAndProof(ctx context.Context,
+		aggregateAndProof *AggregateAndProof,
+		forkVersion []byte,
+		genesisValidatorsRoot []byte,
+	) (
+		e2types.Signature,
+		error,
+	)
+
+	// SignRANDAOReveal signs a RANDAO reveal for an epoch.
+	SignRANDAOReveal(ctx context.Context,
+		epoch uint64,
+		forkVersion []byte,
+		genesisValidatorsRoot []byte,
+	) (
+		e2types.Signature,
+		error,
+	)
+
+	// SignVoluntaryExit signs a voluntary exit.
+	SignVoluntaryExit(ctx context.Context,
+		epoch uint64,
+		validatorIndex uint64,
+		forkVersion []byte,
+		genesisValidatorsRoot []byte,
+	) (
+		e2types.Signature,
+		error,
+	)
+
+	// SignValidatorRegistration signs a validator registration.  Its
+	// domain uses the genesis fork version of the chain.
+	SignValidatorRegistration(ctx context.Context,
+		registration *ValidatorRegistration,
+		genesisForkVersion []byte,
+	) (
+		e2types.Signature,
+		error,
+	)
+}
+
+// signTypedRoot signs an object root with the domain computed from the
+// domain type, fork version and genesis validators root.
+func signTypedRoot(ctx context.Context,
+	signer e2wtypes.AccountProtectingSigner,
+	objectRoot []byte,
+	domainType e2types.DomainType,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	domain, err := e2types.ComputeDomain(domainType, forkVersion, genesisValidatorsRoot)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to compute domain")
+	}
+
+	return signer.SignGeneric(ctx, objectRoot, domain)
+}
+
+// signSyncCommitteeMessage signs a sync committee message.
+func signSyncCommitteeMessage(ctx context.Context,
+	signer e2wtypes.AccountProtectingSigner,
+	beaconBlockRoot []byte,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	if len(beaconBlockRoot) != 32 {
+		return nil, errors.New("beacon block root must be 32 bytes in length")
+	}
+
+	return signTypedRoot(ctx, signer, beaconBlockRoot, e2types.DomainSyncCommittee, forkVersion, genesisValidatorsRoot)
+}
+
+// signSyncCommitteeSelectionProof signs a sync committee selection proof.
+func signSyncCommitteeSelectionProof(ctx context.Context,
+	signer e2wtypes.AccountProtectingSigner,
+	slot uint64,
+	subcommitteeIndex uint64,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signTypedRoot(ctx, signer, syncAggregatorSelectionDataRoot(slot, subcommitteeIndex), e2types.DomainSyncCommitteeSelectionProof, forkVersion, genesisValidatorsRoot)
+}
+
+// signContributionAndProof signs a contribution and proof.
+func signContributionAndProof(ctx context.Context,
+	signer e2wtypes.AccountProtectingSigner,
+	contributionAndProof *ContributionAndProof,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	if contributionAndProof == nil || contributionAndProof.Contribution == nil {
+		return nil, errors.New("contribution missing")
+	}
+	contribution := contributionAndProof.Contribution
+	if len(contribution.BeaconBlockRoot) != 32 {
+		return nil, errors.New("beacon block root must be 32 bytes in length")
+	}
+	if len(contribution.AggregationBits) != 16 {
+		return nil, errors.New("aggregation bits must be 16 bytes in length")
+	}
+	if len(contribution.Signature) != 96 {
+		return nil, errors.New("contribution signature must be 96 bytes in length")
+	}
+	if len(contributionAndProof.SelectionProof) != 96 {
+		return nil, errors.New("selection proof must be 96 bytes in length")
+	}
+
+	return signTypedRoot(ctx, signer, contributionAndProofRoot(contributionAndProof), e2types.DomainContributionAndProof, forkVersion, genesisValidatorsRoot)
+}
+
+// signSelectionProof signs a selection proof.
+func signSelectionProof(ctx context.Context,
+	signer e2wtypes.AccountProtectingSigner,
+	slot uint64,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	root := uint64Chunk(slot)
+
+	return signTypedRoot(ctx, signer, root[:], e2types.DomainSelectionProof, forkVersion, genesisValidatorsRoot)
+}
+
+// signAggregateAndProof signs an aggregate and proof.
+func signAggregateAndProof(ctx context.Context,
+	signer e2wtypes.AccountProtectingSigner,
+	aggregateAndProof *AggregateAndProof,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	if aggregateAndProof == nil || aggregateAndProof.Aggregate == nil {
+		return nil, errors.New("aggregate missing")
+	}
+	aggregate := aggregateAndProof.Aggregate
+	if aggregate.Data == nil || aggregate.Data.Source == nil || aggregate.Data.Target == nil {
+		return nil, errors.New("aggregate data missing")
+	}
+	if len(aggregate.Data.BeaconBlockRoot) != 32 ||
+		len(aggregate.Data.Source.Root) != 32 ||
+		len(aggregate.Data.Target.Root) != 32 {
+		return nil, errors.New("aggregate data roots must be 32 bytes in length")
+	}
+	if len(aggregate.Signature) != 96 {
+		return nil, errors.New("aggregate signature must be 96 bytes in length")
+	}
+	if aggregate.CommitteeBits != nil && len(aggregate.CommitteeBits) != maxCommitteesPerSlot/8 {
+		return nil, errors.New("aggregate committee bits must be 8 bytes in length")
+	}
+	if len(aggregateAndProof.SelectionProof) != 96 {
+		return nil, errors.New("selection proof must be 96 bytes in length")
+	}
+	root, err := aggregateAndProofRoot(aggregateAndProof)
+	if err != nil {
+		return nil, err
+	}
+
+	return signTypedRoot(ctx, signer, root, e2types.DomainAggregateAndProof, forkVersion, genesisValidatorsRoot)
+}
+
+// signRANDAOReveal signs a RANDAO reveal.
+func signRANDAOReveal(ctx context.Context,
+	signer e2wtypes.AccountProtectingSigner,
+	epoch uint64,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	root := uint64Chunk(epoch)
+
+	return signTypedRoot(ctx, signer, root[:], e2types.DomainRANDAO, forkVersion, genesisValidatorsRoot)
+}
+
+// signVoluntaryExit signs a voluntary exit.
+func signVoluntaryExit(ctx context.Context,
+	signer e2wtypes.AccountProtectingSigner,
+	epoch uint64,
+	validatorIndex uint64,
+	forkVersion []byte,
+	genesisValidatorsRoot []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	return signTypedRoot(ctx, signer, voluntaryExitRoot(epoch, validatorIndex), e2types.DomainVoluntaryExit, forkVersion, genesisValidatorsRoot)
+}
+
+// signValidatorRegistration signs a validator registration.
+func signValidatorRegistration(ctx context.Context,
+	signer e2wtypes.AccountProtectingSigner,
+	registration *ValidatorRegistration,
+	genesisForkVersion []byte,
+) (
+	e2types.Signature,
+	error,
+) {
+	if registration == nil {
+		return nil, errors.New("registration missing")
+	}
+	if len(registration.FeeRecipient) != 20 {
+		return nil, errors.New("fee recipient must be 20 bytes in length")
+	}
+	if len(registration.Pubkey) != 48 {
+		return nil, errors.New("public key must be 48 bytes in length")
+	}
+
+	return signTypedRoot(ctx, signer, validatorRegistrationRoot(registration), domainApplicationBuilder, genesisForkVersion, e2types.ZeroGenesisValidatorsRoot)
+}
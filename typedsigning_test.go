@@ -0,0 +1,248 @@
+// Copyright © 2024 Weald Technology Trading.
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dirk_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	e2types "github.com/wealdtech/go-eth2-types/v2"
+	dirk "github.com/wealdtech/go-eth2-wallet-dirk"
+	e2wtypes "github.com/wealdtech/go-eth2-wallet-types/v2"
+)
+
+// uint64Root returns the hash tree root of a uint64.
+func uint64Root(val uint64) []byte {
+	res := make([]byte, 32)
+	binary.LittleEndian.PutUint64(res, val)
+
+	return res
+}
+
+func TestTypedSigning(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 3)
+	_, err := cluster.AddAccount("Wallet", "Account 1", []byte("pass"))
+	require.NoError(t, err)
+	_, err = cluster.AddDistributedAccount("Wallet", "Account 2", 3, 2, []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet")
+
+	forkVersion := []byte{0x04, 0x00, 0x00, 0x00}
+	genesisValidatorsRoot := bytes.Repeat([]byte{0x0a}, 32)
+	domain := func(domainType e2types.DomainType) []byte {
+		res, err := e2types.ComputeDomain(domainType, forkVersion, genesisValidatorsRoot)
+		require.NoError(t, err)
+
+		return res
+	}
+	// The builder domain on mainnet, which has a genesis fork version of 0.
+	builderDomain, err := hex.DecodeString("00000001f5a5fd42d16a20302798ef6ed309979b43003d2320d9f0e8ea9831a9")
+	require.NoError(t, err)
+	objectRoot := func(input string) []byte {
+		res, err := hex.DecodeString(input)
+		require.NoError(t, err)
+
+		return res
+	}
+
+	aggregateAndProof := &dirk.AggregateAndProof{
+		AggregatorIndex: 9,
+		Aggregate: &dirk.Attestation{
+			AggregationBits: []byte{0x05, 0x80, 0x03},
+			Data: &dirk.AttestationData{
+				Slot:            12345,
+				Index:           7,
+				BeaconBlockRoot: bytes.Repeat([]byte{0x01}, 32),
+				Source:          &dirk.Checkpoint{Epoch: 3, Root: bytes.Repeat([]byte{0x02}, 32)},
+				Target:          &dirk.Checkpoint{Epoch: 4, Root: bytes.Repeat([]byte{0x03}, 32)},
+			},
+			Signature: bytes.Repeat([]byte{0x04}, 96),
+		},
+		SelectionProof: bytes.Repeat([]byte{0x05}, 96),
+	}
+	electraAggregate := *aggregateAndProof.Aggregate
+	electraAggregate.CommitteeBits = []byte{0x81, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02}
+	electraAggregateAndProof := &dirk.AggregateAndProof{
+		AggregatorIndex: 9,
+		Aggregate:       &electraAggregate,
+		SelectionProof:  bytes.Repeat([]byte{0x05}, 96),
+	}
+	contributionAndProof := &dirk.ContributionAndProof{
+		AggregatorIndex: 11,
+		Contribution: &dirk.SyncCommitteeContribution{
+			Slot:              100,
+			BeaconBlockRoot:   bytes.Repeat([]byte{0x06}, 32),
+			SubcommitteeIndex: 2,
+			AggregationBits:   bytes.Repeat([]byte{0xa5}, 16),
+			Signature:         bytes.Repeat([]byte{0x07}, 96),
+		},
+		SelectionProof: bytes.Repeat([]byte{0x08}, 96),
+	}
+	registration := &dirk.ValidatorRegistration{
+		FeeRecipient: bytes.Repeat([]byte{0x09}, 20),
+		GasLimit:     30000000,
+		Timestamp:    1700000000,
+		Pubkey:       bytes.Repeat([]byte{0x0a}, 48),
+	}
+
+	tests := []struct {
+		name   string
+		sign   func(signer dirk.AccountTypedSigner) (e2types.Signature, error)
+		root   []byte
+		domain []byte
+	}{
+		{
+			name: "SyncCommitteeMessage",
+			sign: func(signer dirk.AccountTypedSigner) (e2types.Signature, error) {
+				return signer.SignSyncCommitteeMessage(ctx, bytes.Repeat([]byte{0x01}, 32), forkVersion, genesisValidatorsRoot)
+			},
+			root:   bytes.Repeat([]byte{0x01}, 32),
+			domain: domain(e2types.DomainSyncCommittee),
+		},
+		{
+			name: "SyncCommitteeSelectionProof",
+			sign: func(signer dirk.AccountTypedSigner) (e2types.Signature, error) {
+				return signer.SignSyncCommitteeSelectionProof(ctx, 100, 2, forkVersion, genesisValidatorsRoot)
+			},
+			root:   genericSigningRoot(uint64Root(100), uint64Root(2)),
+			domain: domain(e2types.DomainSyncCommitteeSelectionProof),
+		},
+		{
+			name: "ContributionAndProof",
+			sign: func(signer dirk.AccountTypedSigner) (e2types.Signature, error) {
+				return signer.SignContributionAndProof(ctx, contributionAndProof, forkVersion, genesisValidatorsRoot)
+			},
+			root:   objectRoot("3db19214945b04c83bdc8ae27f37e7eadca9f3fe7461390216332f1a87ec7408"),
+			domain: domain(e2types.DomainContributionAndProof),
+		},
+		{
+			name: "SelectionProof",
+			sign: func(signer dirk.AccountTypedSigner) (e2types.Signature, error) {
+				return signer.SignSelectionProof(ctx, 12345, forkVersion, genesisValidatorsRoot)
+			},
+			root:   uint64Root(12345),
+			domain: domain(e2types.DomainSelectionProof),
+		},
+		{
+			name: "AggregateAndProof",
+			sign: func(signer dirk.AccountTypedSigner) (e2types.Signature, error) {
+				return signer.SignAggregateAndProof(ctx, aggregateAndProof, forkVersion, genesisValidatorsRoot)
+			},
+			root:   objectRoot("7b06cb14b3c1411119e80de61961771534bc8d1da4ebef01a39ae2298f0da5c1"),
+			domain: domain(e2types.DomainAggregateAndProof),
+		},
+		{
+			name: "ElectraAggregateAndProof",
+			sign: func(signer dirk.AccountTypedSigner) (e2types.Signature, error) {
+				return signer.SignAggregateAndProof(ctx, electraAggregateAndProof, forkVersion, genesisValidatorsRoot)
+			},
+			root:   objectRoot("5738f0b566c4c5fdb12b716713790e9b6c6da89f49e6deae683ea62495f25b09"),
+			domain: domain(e2types.DomainAggregateAndProof),
+		},
+		{
+			name: "RANDAOReveal",
+			sign: func(signer dirk.AccountTypedSigner) (e2types.Signature, error) {
+				return signer.SignRANDAOReveal(ctx, 385, forkVersion, genesisValidatorsRoot)
+			},
+			root:   uint64Root(385),
+			domain: domain(e2types.DomainRANDAO),
+		},
+		{
+			name: "VoluntaryExit",
+			sign: func(signer dirk.AccountTypedSigner) (e2types.Signature, error) {
+				return signer.SignVoluntaryExit(ctx, 385, 12, forkVersion, genesisValidatorsRoot)
+			},
+			root:   genericSigningRoot(uint64Root(385), uint64Root(12)),
+			domain: domain(e2types.DomainVoluntaryExit),
+		},
+		{
+			name: "ValidatorRegistration",
+			sign: func(signer dirk.AccountTypedSigner) (e2types.Signature, error) {
+				return signer.SignValidatorRegistration(ctx, registration, e2types.ZeroForkVersion)
+			},
+			root:   objectRoot("39a7fa3c6a859bd9f47b757b3fbec43d7bfced1a6afd8c6afd77f478f52d1137"),
+			domain: builderDomain,
+		},
+	}
+
+	for _, name := range []string{"Account 1", "Account 2"} {
+		account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, name)
+		require.NoError(t, err)
+		for _, test := range tests {
+			t.Run(name+"/"+test.name, func(t *testing.T) {
+				sig, err := test.sign(account.(dirk.AccountTypedSigner))
+				require.NoError(t, err)
+				require.True(t, sig.Verify(genericSigningRoot(test.root, test.domain), verificationKey(account)))
+			})
+		}
+	}
+}
+
+func TestTypedSigningInvalid(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, 1)
+	_, err := cluster.AddAccount("Wallet", "Account", []byte("pass"))
+	require.NoError(t, err)
+	wallet := openClusterWallet(ctx, t, cluster, "Wallet")
+	account, err := wallet.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Account")
+	require.NoError(t, err)
+	signer := account.(dirk.AccountTypedSigner)
+	genesisValidatorsRoot := bytes.Repeat([]byte{0x0a}, 32)
+
+	_, err = signer.SignRANDAOReveal(ctx, 1, []byte{0x00}, genesisValidatorsRoot)
+	require.EqualError(t, err, "failed to compute domain: fork version must be 4 bytes in length")
+
+	_, err = signer.SignSyncCommitteeMessage(ctx, []byte{0x01}, e2types.ZeroForkVersion, genesisValidatorsRoot)
+	require.EqualError(t, err, "beacon block root must be 32 bytes in length")
+
+	_, err = signer.SignAggregateAndProof(ctx, &dirk.AggregateAndProof{
+		Aggregate: &dirk.Attestation{
+			AggregationBits: []byte{0x00},
+			Data: &dirk.AttestationData{
+				BeaconBlockRoot: bytes.Repeat([]byte{0x01}, 32),
+				Source:          &dirk.Checkpoint{Root: bytes.Repeat([]byte{0x02}, 32)},
+				Target:          &dirk.Checkpoint{Root: bytes.Repeat([]byte{0x03}, 32)},
+			},
+			Signature: bytes.Repeat([]byte{0x04}, 96),
+		},
+		SelectionProof: bytes.Repeat([]byte{0x05}, 96),
+	}, e2types.ZeroForkVersion, genesisValidatorsRoot)
+	require.EqualError(t, err, "invalid aggregation bits: missing delimiting bit")
+
+	_, err = signer.SignAggregateAndProof(ctx, &dirk.AggregateAndProof{
+		Aggregate: &dirk.Attestation{
+			AggregationBits: []byte{0x01},
+			Data: &dirk.AttestationData{
+				BeaconBlockRoot: bytes.Repeat([]byte{0x01}, 32),
+				Source:          &dirk.Checkpoint{Root: bytes.Repeat([]byte{0x02}, 32)},
+				Target:          &dirk.Checkpoint{Root: bytes.Repeat([]byte{0x03}, 32)},
+			},
+			Signature:     bytes.Repeat([]byte{0x04}, 96),
+			CommitteeBits: []byte{0x01},
+		},
+		SelectionProof: bytes.Repeat([]byte{0x05}, 96),
+	}, e2types.ZeroForkVersion, genesisValidatorsRoot)
+	require.EqualError(t, err, "aggregate committee bits must be 8 bytes in length")
+
+	_, err = signer.SignValidatorRegistration(ctx, &dirk.ValidatorRegistration{
+		FeeRecipient: bytes.Repeat([]byte{0x09}, 19),
+		Pubkey:       bytes.Repeat([]byte{0x0a}, 48),
+	}, e2types.ZeroForkVersion)
+	require.EqualError(t, err, "fee recipient must be 20 bytes in length")
+}
@@ -14,6 +14,7 @@
 package dirk
 
 import (
+	"bytes"
 	"context"
 	"sync"
 	"time"
@@ -30,6 +31,22 @@ const (
 	walletType = "dirk"
 )
 
+// WalletCloser is the interface for wallets that hold resources that should be
+// released when the wallet is no longer required.
+type WalletCloser interface {
+	// Close stops background activity and closes the wallet's connections.
+	Close(ctx context.Context) error
+}
+
+// WalletAccountByPublicKeyProvider is the interface for wallets that provide
+// an account given its public key.
+type WalletAccountByPublicKeyProvider interface {
+	// AccountByPublicKey provides a single account from the wallet given its
+	// public key, which for distributed accounts may be either the composite
+	// public key or the account's own public key.
+	AccountByPublicKey(ctx context.Context, pubKey []byte) (e2wtypes.Account, error)
+}
+
 // wallet contains the details of a remote dirk wallet.
 type wallet struct {
 	log                zerolog.Logger
@@ -37,8 +54,27 @@ type wallet struct {
 	name               string
 	version            uint
 	endpoints          []*Endpoint
+	endpointSelector   *endpointSelector
+	healthChecker      *healthChecker
+	certificateMonitor *certificateMonitor
 	timeout            time.Duration
 	connectionProvider ConnectionProvider
+	// verifyCompositeSignatures is true if recovered composite signatures should be verified.
+	verifyCompositeSignatures bool
+	// hedgeDelay is the time to wait for signature shares before contacting further participants; 0 disables hedging.
+	hedgeDelay time.Duration
+	// attestationCoalescer gathers attestations to sign together; nil if coalescing is disabled.
+	attestationCoalescer *attestationCoalescer
+	// genesisTime is the genesis time of the chain, used with slotDuration to provide duty deadlines.
+	genesisTime time.Time
+	// slotDuration is the duration of a slot of the chain; 0 disables duty deadlines.
+	slotDuration time.Duration
+	// slashingProtection is local slashing protection; nil if disabled.
+	slashingProtection *SlashingProtection
+	// accountCache caches the wallet's accounts; nil if disabled.
+	accountCache *accountCache
+	// listingQuorum is the number of endpoints that must respond when listing accounts from all endpoints; 0 disables quorum listing.
+	listingQuorum int
 
 	accountMap   map[[48]byte]e2wtypes.Account
 	accountMapMu sync.RWMutex
@@ -54,7 +90,8 @@ func newWallet() *wallet {
 	}
 }
 
-// Open opens an existing wallet with the given name.
+// Open opens an existing wallet with the given name.  Background activity
+// continues after the context is done, until the wallet is closed.
 func Open(ctx context.Context,
 	params ...Parameter,
 ) (
@@ -76,15 +113,36 @@ func Open(ctx context.Context,
 		return nil, errors.Wrap(err, "failed to register metrics")
 	}
 
+	if info, isInfo := parameters.credentials.(CertificateInfo); isInfo && info.ClientCertificate() != nil {
+		if err := checkClientCertificate(info.ClientCertificate(), time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
 	wallet := newWallet()
 	wallet.log = log
 	wallet.name = parameters.name
 	wallet.timeout = parameters.timeout
+	wallet.verifyCompositeSignatures = parameters.verifyCompositeSignatures
+	wallet.hedgeDelay = parameters.hedgeDelay
+	wallet.genesisTime = parameters.genesisTime
+	wallet.slotDuration = parameters.slotDuration
+	wallet.slashingProtection = parameters.slashingProtection
+	wallet.listingQuorum = parameters.listingQuorum
+	if parameters.attestationCoalescingWindow > 0 {
+		wallet.attestationCoalescer = newAttestationCoalescer(wallet, parameters.attestationCoalescingWindow)
+	}
+	if parameters.accountCacheTTL > 0 {
+		wallet.accountCache = newAccountCache(log, parameters.accountCacheTTL, parameters.accountCacheRefreshInterval, func(ctx context.Context) ([]e2wtypes.Account, error) {
+			return wallet.List(ctx, "")
+		})
+	}
 	wallet.endpoints = make([]*Endpoint, len(parameters.endpoints))
 	wallet.connectionProvider = &PuddleConnectionProvider{
-		name:            parameters.name,
-		poolConnections: parameters.poolConnections,
-		credentials:     parameters.credentials.Clone(),
+		name:              parameters.name,
+		poolConnections:   parameters.poolConnections,
+		credentials:       parameters.credentials.Clone(),
+		credentialsSource: parameters.credentials,
 	}
 	for i := range parameters.endpoints {
 		wallet.endpoints[i] = &Endpoint{
@@ -92,6 +150,23 @@ func Open(ctx context.Context,
 			port: parameters.endpoints[i].port,
 		}
 	}
+	wallet.endpointSelector = newEndpointSelector(parameters.endpointSelection, wallet.endpoints)
+	// Background activity is stopped by Close rather than by the context.
+	background := context.WithoutCancel(ctx)
+	if parameters.healthCheckInterval > 0 {
+		wallet.healthChecker = newHealthChecker(log, parameters.healthCheckInterval, min(parameters.timeout, parameters.healthCheckInterval), wallet.probeEndpoint)
+		for _, endpoint := range wallet.endpoints {
+			wallet.healthChecker.register(endpoint)
+		}
+		wallet.healthChecker.start(background)
+	}
+	if info, isInfo := parameters.credentials.(CertificateInfo); isInfo {
+		wallet.certificateMonitor = newCertificateMonitor(log, info, parameters.certificateExpiryWarnings)
+		wallet.certificateMonitor.start(background)
+	}
+	if wallet.accountCache != nil {
+		wallet.accountCache.start(background)
+	}
 	wallet.log.Trace().Str("name", wallet.name).Msg("Opened wallet")
 
 	return wallet, nil
@@ -104,8 +179,9 @@ func OpenWallet(_ context.Context, name string, credentials credentials.Transpor
 	wallet.name = name
 	wallet.endpoints = make([]*Endpoint, len(endpoints))
 	wallet.connectionProvider = &PuddleConnectionProvider{
-		poolConnections: 32,
-		credentials:     credentials.Clone(),
+		poolConnections:   32,
+		credentials:       credentials.Clone(),
+		credentialsSource: credentials,
 	}
 	for i := range endpoints {
 		wallet.endpoints[i] = &Endpoint{
@@ -113,6 +189,7 @@ func OpenWallet(_ context.Context, name string, credentials credentials.Transpor
 			port: endpoints[i].port,
 		}
 	}
+	wallet.endpointSelector = newEndpointSelector(EndpointSelectionOrdered, wallet.endpoints)
 
 	return wallet, nil
 }
@@ -156,17 +233,26 @@ func (w *wallet) IsUnlocked(_ context.Context) (bool, error) {
 	return true, nil
 }
 
-// Accounts provides all accounts in the wallet.
+// WalletAccountsWithErrorProvider is the interface for wallets that provide
+// all of their accounts along with any error obtaining them.
+type WalletAccountsWithErrorProvider interface {
+	// AccountsWithError provides all accounts in the wallet.  If some
+	// accounts are malformed the valid accounts are returned along with an
+	// *AccountListError.
+	AccountsWithError(ctx context.Context) ([]e2wtypes.Account, error)
+}
+
+// Accounts provides all accounts in the wallet.  Errors are logged; use
+// AccountsWithError to obtain them.
 func (w *wallet) Accounts(ctx context.Context) <-chan e2wtypes.Account {
 	ch := make(chan e2wtypes.Account, 1024)
 	go func() {
-		accounts, err := w.List(ctx, "")
+		accounts, err := w.AccountsWithError(ctx)
 		if err != nil {
 			w.log.Error().Err(err).Msg("Failed to obtain accounts")
-		} else {
-			for _, account := range accounts {
-				ch <- account
-			}
+		}
+		for _, account := range accounts {
+			ch <- account
 		}
 		close(ch)
 	}()
@@ -174,11 +260,33 @@ func (w *wallet) Accounts(ctx context.Context) <-chan e2wtypes.Account {
 	return ch
 }
 
+// AccountsWithError provides all accounts in the wallet.  If some accounts
+// are malformed the valid accounts are returned along with an
+// *AccountListError.
+func (w *wallet) AccountsWithError(ctx context.Context) ([]e2wtypes.Account, error) {
+	if w.accountCache != nil {
+		return w.accountCache.all(ctx)
+	}
+
+	return w.List(ctx, "")
+}
+
 // AccountByName provides a single account from the wallet given its name.
 // This will error if the account is not found.
 func (w *wallet) AccountByName(ctx context.Context, name string) (e2wtypes.Account, error) {
+	if w.accountCache != nil {
+		account, exists, err := w.accountCache.account(ctx, name)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to obtain account")
+		}
+		if exists {
+			return account, nil
+		}
+		// The account may have been created since the cache was refreshed.
+	}
+
 	accounts, err := w.List(ctx, name)
-	if err != nil {
+	if err != nil && (!isAccountListError(err) || len(accounts) == 0) {
 		return nil, errors.Wrap(err, "failed to obtain account")
 	}
 	if len(accounts) == 0 {
@@ -190,8 +298,60 @@ func (w *wallet) AccountByName(ctx context.Context, name string) (e2wtypes.Accou
 
 // AccountByID provides a single account from the wallet given its ID.
 // This will error if the account is not found.
-func (w *wallet) AccountByID(_ context.Context, _ uuid.UUID) (e2wtypes.Account, error) {
-	return nil, errors.New("not supported")
+func (w *wallet) AccountByID(ctx context.Context, id uuid.UUID) (e2wtypes.Account, error) {
+	return w.findAccount(ctx, func(account e2wtypes.Account) bool {
+		return account.ID() == id
+	})
+}
+
+// AccountByPublicKey provides a single account from the wallet given its
+// public key.  This will error if the account is not found.
+func (w *wallet) AccountByPublicKey(ctx context.Context, pubKey []byte) (e2wtypes.Account, error) {
+	if len(pubKey) != 48 {
+		return nil, errors.New("public key must be 48 bytes in length")
+	}
+
+	return w.findAccount(ctx, func(account e2wtypes.Account) bool {
+		if provider, isProvider := account.(e2wtypes.AccountCompositePublicKeyProvider); isProvider &&
+			bytes.Equal(provider.CompositePublicKey().Marshal(), pubKey) {
+			return true
+		}
+
+		return bytes.Equal(account.PublicKey().Marshal(), pubKey)
+	})
+}
+
+// findAccount provides the first account in the wallet that matches,
+// searching the cached accounts before those in Dirk.
+func (w *wallet) findAccount(ctx context.Context, match func(account e2wtypes.Account) bool) (e2wtypes.Account, error) {
+	if w.accountCache != nil {
+		accounts, err := w.accountCache.all(ctx)
+		if err != nil && !isAccountListError(err) {
+			return nil, errors.Wrap(err, "failed to obtain accounts")
+		}
+		for _, account := range accounts {
+			if match(account) {
+				return account, nil
+			}
+		}
+		// The account may have been created since the cache was refreshed.
+	}
+
+	accounts, err := w.List(ctx, "")
+	if err != nil && !isAccountListError(err) {
+		return nil, errors.Wrap(err, "failed to obtain accounts")
+	}
+	for _, account := range accounts {
+		if match(account) {
+			return account, nil
+		}
+	}
+	if err != nil {
+		// The account may be one of those that are malformed.
+		return nil, errors.Wrap(err, "failed to obtain accounts")
+	}
+
+	return nil, errors.New("not found")
 }
 
 // CreateAccount creates an account.
@@ -204,6 +364,35 @@ func (w *wallet) CreateDistributedAccount(ctx context.Context, name string, part
 	return w.GenerateDistributedAccount(ctx, name, participants, signingThreshold, passphrase)
 }
 
+// Close stops background activity and closes the wallet's connections once
+// requests in flight have completed, or the context is done.  Connections
+// shared with other open wallets remain open for them.  The wallet cannot be
+// used once it has been closed.
+func (w *wallet) Close(ctx context.Context) error {
+	if w.healthChecker != nil {
+		w.healthChecker.stop()
+	}
+	if w.certificateMonitor != nil {
+		w.certificateMonitor.stop()
+	}
+	if w.accountCache != nil {
+		w.accountCache.stop()
+	}
+	if w.attestationCoalescer != nil {
+		// Sign pending attestations while the connections are still open.
+		w.attestationCoalescer.stop(ctx)
+	}
+
+	if closer, isCloser := w.connectionProvider.(ClosingConnectionProvider); isCloser {
+		if err := closer.Close(ctx); err != nil {
+			return errors.Wrap(err, "failed to close connection provider")
+		}
+	}
+	w.log.Trace().Str("name", w.name).Msg("Closed wallet")
+
+	return nil
+}
+
 // SetConnectionProvider sets a connection provider for the wallet.
 // This should, in general, only be used for testing.
 func (w *wallet) SetConnectionProvider(connectionProvider ConnectionProvider) {
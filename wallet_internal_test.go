@@ -70,6 +70,7 @@ func TestListErroringConnectionProvider(t *testing.T) {
 	w.(*wallet).SetConnectionProvider(connectionProvider)
 	_, err = w.(*wallet).List(ctx, "")
 	require.EqualError(t, err, "failed to access dirk: mock error")
+	require.ErrorIs(t, err, ErrTransport)
 }
 
 func TestListErroringServer(t *testing.T) {
@@ -84,6 +85,49 @@ func TestListErroringServer(t *testing.T) {
 	require.EqualError(t, err, "failed to access dirk: rpc error: code = Unknown desc = mock error")
 }
 
+func TestListMalformedAccounts(t *testing.T) {
+	require.NoError(t, e2types.InitBLS())
+	ctx := context.Background()
+	connectionProvider, err := NewBufConnectionProvider(ctx, []pb.ListerServer{&mock.MalformedListerServer{}})
+	require.NoError(t, err)
+	w, err := OpenWallet(ctx, "Test wallet", credentials.NewTLS(nil), []*Endpoint{{host: "localhost", port: 12345}})
+	require.NoError(t, err)
+	w.(*wallet).SetConnectionProvider(connectionProvider)
+
+	accounts, err := w.(*wallet).List(ctx, "")
+	require.ErrorIs(t, err, ErrInvalidAccount)
+	require.Len(t, accounts, 1)
+	require.Equal(t, "Valid", accounts[0].Name())
+
+	var listErr *AccountListError
+	require.ErrorAs(t, err, &listErr)
+	require.Len(t, listErr.Invalid, 4)
+	require.Equal(t, "Bad UUID", listErr.Invalid[0].Name)
+	require.Equal(t, "Bad participants", listErr.Invalid[1].Name)
+	require.EqualError(t, listErr.Invalid[1], "account Bad participants invalid: participant 2 duplicated")
+	require.Equal(t, "Bad public key", listErr.Invalid[2].Name)
+	require.Equal(t, "Bad threshold", listErr.Invalid[3].Name)
+	require.EqualError(t, listErr.Invalid[3], "account Bad threshold invalid: signing threshold 3 invalid for 2 participants")
+
+	// Valid accounts remain available.
+	accounts, err = w.(WalletAccountsWithErrorProvider).AccountsWithError(ctx)
+	require.ErrorIs(t, err, ErrInvalidAccount)
+	require.Len(t, accounts, 1)
+	found := 0
+	for range w.Accounts(ctx) {
+		found++
+	}
+	require.Equal(t, 1, found)
+	_, err = w.(e2wtypes.WalletAccountByNameProvider).AccountByName(ctx, "Valid")
+	require.NoError(t, err)
+	_, err = w.(e2wtypes.WalletAccountByIDProvider).AccountByID(ctx, uuid.MustParse("00000000-0000-0000-0000-000000000000"))
+	require.NoError(t, err)
+
+	// Lookups of malformed accounts report why they failed.
+	_, err = w.(e2wtypes.WalletAccountByIDProvider).AccountByID(ctx, uuid.MustParse("01000000-0000-0000-0000-000000000000"))
+	require.ErrorIs(t, err, ErrInvalidAccount)
+}
+
 func TestListDenyingServer(t *testing.T) {
 	require.NoError(t, e2types.InitBLS())
 	ctx := context.Background()
@@ -93,7 +137,8 @@ func TestListDenyingServer(t *testing.T) {
 	require.NoError(t, err)
 	w.(*wallet).SetConnectionProvider(connectionProvider)
 	_, err = w.(*wallet).List(ctx, "")
-	require.EqualError(t, err, "request to list wallet accounts returned state DENIED")
+	require.EqualError(t, err, "request to list wallet accounts denied")
+	require.ErrorIs(t, err, ErrDenied)
 }
 
 func TestList(t *testing.T) {
@@ -183,6 +228,69 @@ func TestAccountByID(t *testing.T) {
 	require.NoError(t, err)
 	w.(*wallet).SetConnectionProvider(connectionProvider)
 
-	_, err = w.(e2wtypes.WalletAccountByIDProvider).AccountByID(ctx, uuid.MustParse("00000000-0000-0000-0000-000000000002"))
-	require.EqualError(t, err, "not supported")
+	account, err := w.(e2wtypes.WalletAccountByIDProvider).AccountByID(ctx, uuid.MustParse("00000000-0000-0000-0000-000000000002"))
+	require.NoError(t, err)
+	require.Equal(t, "Interop 2", account.Name())
+
+	account, err = w.(e2wtypes.WalletAccountByIDProvider).AccountByID(ctx, uuid.MustParse("01000000-0000-0000-0000-000000000000"))
+	require.NoError(t, err)
+	require.Equal(t, "Distributed 0", account.Name())
+
+	_, err = w.(e2wtypes.WalletAccountByIDProvider).AccountByID(ctx, uuid.MustParse("00000000-0000-0000-0000-0000000000ff"))
+	require.EqualError(t, err, "not found")
+}
+
+func TestAccountByPublicKey(t *testing.T) {
+	require.NoError(t, e2types.InitBLS())
+	ctx := context.Background()
+	connectionProvider, err := NewBufConnectionProvider(ctx, []pb.ListerServer{&mock.MockListerServer{}})
+	require.NoError(t, err)
+	w, err := OpenWallet(ctx, "Test wallet", credentials.NewTLS(nil), []*Endpoint{{host: "localhost", port: 12345}})
+	require.NoError(t, err)
+	w.(*wallet).SetConnectionProvider(connectionProvider)
+
+	tests := []struct {
+		name    string
+		pubKey  []byte
+		account string
+		err     string
+	}{
+		{
+			name:   "PubKeyShort",
+			pubKey: _byte("a3a32b0f8b4ddb83f1a0a853d81dd725dfe577d4f4c3db8ece52ce2b026eca84815c1a7e8e92a4de3d755733bf7e4a"),
+			err:    "public key must be 48 bytes in length",
+		},
+		{
+			name:    "Account",
+			pubKey:  _byte("a3a32b0f8b4ddb83f1a0a853d81dd725dfe577d4f4c3db8ece52ce2b026eca84815c1a7e8e92a4de3d755733bf7e4a9b"),
+			account: "Interop 2",
+		},
+		{
+			name:    "DistributedAccountComposite",
+			pubKey:  _byte("a155a5fb0a6d732fa0f4d3714a8550ee5b90690475e010fbf89277e98e060203d69eba05fa71b2d0fa6aa6d091172f1e"),
+			account: "Distributed 0",
+		},
+		{
+			name:    "DistributedAccountShare",
+			pubKey:  _byte("aaf4abea98732aa9da46a4ddd8c56c03ec173a4daae90424e986be61d2b07999db746e103d6f505dc98716e91d4f946a"),
+			account: "Distributed 0",
+		},
+		{
+			name:   "Missing",
+			pubKey: make([]byte, 48),
+			err:    "not found",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			account, err := w.(WalletAccountByPublicKeyProvider).AccountByPublicKey(ctx, test.pubKey)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.account, account.Name())
+			}
+		})
+	}
 }